@@ -5,11 +5,17 @@ import (
 	"os"
 
 	"github.com/AkaraChen/gnpm/internal/cli"
+	"github.com/AkaraChen/gnpm/internal/process"
 )
 
 func main() {
 	if err := cli.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		// Exit with the status of a failed script or command, so callers
+		// see the same code as when running it directly
+		if code, ok := process.ExitCode(err); ok {
+			os.Exit(code)
+		}
 		os.Exit(1)
 	}
 }
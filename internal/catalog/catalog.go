@@ -0,0 +1,259 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+// DefaultName is the catalog a bare `catalog:` specifier refers to
+const DefaultName = "default"
+
+// Protocol prefixes catalog references in package.json
+const Protocol = "catalog:"
+
+// Source is the file a catalog entry was defined in
+type Source string
+
+const (
+	// SourcePnpm is pnpm's catalog in pnpm-workspace.yaml
+	SourcePnpm Source = "pnpm-workspace.yaml"
+	// SourceBun is bun's catalog in the root package.json
+	SourceBun Source = "package.json"
+	// SourceConfig is gnpm's own catalog in .gnpm/config.yaml
+	SourceConfig Source = ".gnpm/config.yaml"
+)
+
+// Entry is one package range in a catalog
+type Entry struct {
+	Catalog string
+	Name    string
+	Range   string
+	Source  Source
+}
+
+// Native reports whether pm resolves `catalog:` references to this entry
+// itself: pnpm reads pnpm-workspace.yaml and bun reads package.json
+func (e Entry) Native(pm pmcombo.PackageManager) bool {
+	return (e.Source == SourcePnpm && pm == pmcombo.PNPM) ||
+		(e.Source == SourceBun && pm == pmcombo.Bun)
+}
+
+// Spec returns the specifier to declare the entry with: a `catalog:`
+// reference when pm resolves it natively, the concrete range otherwise
+func (e Entry) Spec(pm pmcombo.PackageManager) string {
+	if e.Native(pm) {
+		return Ref(e.Catalog)
+	}
+	return e.Range
+}
+
+// Catalogs holds every catalog defined for a workspace
+type Catalogs struct {
+	entries map[string]map[string]Entry
+	// Shadowed lists gnpm config entries ignored because a package manager
+	// catalog already defines the same package with another range
+	Shadowed []Entry
+}
+
+// Load reads the catalogs of the workspace at rootDir from
+// pnpm-workspace.yaml, the root package.json (bun) and the gnpm config.
+// Package manager catalogs take precedence over gnpm config entries.
+func Load(rootDir string, cfg *config.Config) (*Catalogs, error) {
+	c := &Catalogs{entries: map[string]map[string]Entry{}}
+
+	pnpm, err := readPnpmCatalogs(filepath.Join(rootDir, "pnpm-workspace.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	c.merge(pnpm, SourcePnpm)
+
+	bun, err := readBunCatalogs(filepath.Join(rootDir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	c.merge(bun, SourceBun)
+
+	if cfg != nil {
+		c.merge(withDefault(cfg.Catalog, cfg.Catalogs), SourceConfig)
+	}
+	return c, nil
+}
+
+// merge adds catalogs from source, keeping entries already defined
+func (c *Catalogs) merge(catalogs map[string]map[string]string, source Source) {
+	for catalog, ranges := range catalogs {
+		if c.entries[catalog] == nil {
+			c.entries[catalog] = map[string]Entry{}
+		}
+		for name, rng := range ranges {
+			entry := Entry{Catalog: catalog, Name: name, Range: rng, Source: source}
+			if existing, ok := c.entries[catalog][name]; ok {
+				if existing.Range != rng {
+					c.Shadowed = append(c.Shadowed, entry)
+				}
+				continue
+			}
+			c.entries[catalog][name] = entry
+		}
+	}
+}
+
+// Lookup returns the entry for name in catalog
+func (c *Catalogs) Lookup(catalog string, name string) (Entry, bool) {
+	entry, ok := c.entries[catalog][name]
+	return entry, ok
+}
+
+// Find returns every catalog entry for name, the default catalog first
+func (c *Catalogs) Find(name string) []Entry {
+	var entries []Entry
+	for _, catalog := range c.Names() {
+		if entry, ok := c.entries[catalog][name]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Names returns the catalog names, the default catalog first
+func (c *Catalogs) Names() []string {
+	var names []string
+	for name, entries := range c.entries {
+		if len(entries) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == DefaultName) != (names[j] == DefaultName) {
+			return names[i] == DefaultName
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// Entries returns the entries of catalog sorted by package name
+func (c *Catalogs) Entries(catalog string) []Entry {
+	var entries []Entry
+	for _, entry := range c.entries[catalog] {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// Empty reports whether no catalog defines any package
+func (c *Catalogs) Empty() bool {
+	return len(c.Names()) == 0
+}
+
+// ParseRef parses a `catalog:` or `catalog:<name>` specifier and returns the
+// catalog it refers to
+func ParseRef(spec string) (string, bool) {
+	if !strings.HasPrefix(spec, Protocol) {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(spec, Protocol))
+	if name == "" {
+		name = DefaultName
+	}
+	return name, true
+}
+
+// Ref returns the specifier referring to catalog
+func Ref(catalog string) string {
+	if catalog == DefaultName {
+		return Protocol
+	}
+	return Protocol + catalog
+}
+
+// pnpmCatalogs is the catalog part of pnpm-workspace.yaml
+type pnpmCatalogs struct {
+	Catalog  map[string]string            `yaml:"catalog"`
+	Catalogs map[string]map[string]string `yaml:"catalogs"`
+}
+
+func readPnpmCatalogs(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ws pnpmCatalogs
+	if err := yaml.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return withDefault(ws.Catalog, ws.Catalogs), nil
+}
+
+// bunCatalogs is the catalog part of package.json; bun accepts it at the top
+// level or inside the workspaces object
+type bunCatalogs struct {
+	Catalog    map[string]string            `json:"catalog"`
+	Catalogs   map[string]map[string]string `json:"catalogs"`
+	Workspaces json.RawMessage              `json:"workspaces"`
+}
+
+func readBunCatalogs(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg bunCatalogs
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	catalogs := withDefault(pkg.Catalog, pkg.Catalogs)
+
+	// The array form of workspaces has no catalogs
+	var ws bunCatalogs
+	if json.Unmarshal(pkg.Workspaces, &ws) == nil {
+		for catalog, ranges := range withDefault(ws.Catalog, ws.Catalogs) {
+			if catalogs[catalog] == nil {
+				catalogs[catalog] = map[string]string{}
+			}
+			for name, rng := range ranges {
+				catalogs[catalog][name] = rng
+			}
+		}
+	}
+	return catalogs, nil
+}
+
+// withDefault folds the default catalog into the named ones; it may be
+// given either way
+func withDefault(catalog map[string]string, catalogs map[string]map[string]string) map[string]map[string]string {
+	merged := map[string]map[string]string{}
+	for name, ranges := range catalogs {
+		merged[name] = map[string]string{}
+		for pkg, rng := range ranges {
+			merged[name][pkg] = rng
+		}
+	}
+	if len(catalog) > 0 && merged[DefaultName] == nil {
+		merged[DefaultName] = map[string]string{}
+	}
+	for pkg, rng := range catalog {
+		merged[DefaultName][pkg] = rng
+	}
+	return merged
+}
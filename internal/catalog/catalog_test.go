@@ -0,0 +1,248 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+func TestLoad(t *testing.T) {
+	type lookup struct {
+		catalog, name, rng string
+		source             Source
+	}
+	tests := []struct {
+		name     string
+		files    map[string]string
+		cfg      *config.Config
+		names    []string
+		lookups  []lookup
+		shadowed []string            // ranges of config entries the native catalogs override
+		find     map[string][]string // catalogs Find returns for a package
+	}{
+		{
+			name: "pnpm workspace with config",
+			files: map[string]string{
+				"package.json":        `{"name": "root"}`,
+				"pnpm-workspace.yaml": "packages:\n  - packages/*\ncatalog:\n  react: ^18.3.1\ncatalogs:\n  legacy:\n    react: ^17.0.2\n",
+			},
+			cfg:   &config.Config{Catalog: map[string]string{"react": "^19.0.0", "lodash": "^4.17.21"}},
+			names: []string{"default", "legacy"},
+			lookups: []lookup{
+				{"default", "react", "^18.3.1", SourcePnpm},
+				{"default", "lodash", "^4.17.21", SourceConfig},
+			},
+			shadowed: []string{"^19.0.0"},
+			find:     map[string][]string{"react": {"default", "legacy"}},
+		},
+		{
+			name: "bun workspaces",
+			files: map[string]string{"package.json": `{
+  "name": "root",
+  "workspaces": {
+    "packages": ["packages/*"],
+    "catalog": {"react": "^18.3.1"},
+    "catalogs": {"testing": {"vitest": "^2.1.0"}}
+  }
+}`},
+			names: []string{"default", "testing"},
+			lookups: []lookup{
+				{"default", "react", "^18.3.1", SourceBun},
+				{"testing", "vitest", "^2.1.0", SourceBun},
+			},
+		},
+		{
+			name:  "no catalogs",
+			files: map[string]string{"package.json": `{"name": "root", "workspaces": ["packages/*"]}`},
+			cfg:   &config.Config{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalogs, err := Load(loadContext(t, pmcombo.NPM, tt.files).RootDir, tt.cfg)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			if names := catalogs.Names(); len(names) != len(tt.names) || (len(names) > 0 && !reflect.DeepEqual(names, tt.names)) {
+				t.Errorf("Names = %v, want %v", names, tt.names)
+			}
+			if catalogs.Empty() != (len(tt.names) == 0) {
+				t.Errorf("Empty = %v", catalogs.Empty())
+			}
+			for _, l := range tt.lookups {
+				entry, ok := catalogs.Lookup(l.catalog, l.name)
+				if !ok || entry.Range != l.rng || entry.Source != l.source {
+					t.Errorf("Lookup(%s, %s) = %+v, %v", l.catalog, l.name, entry, ok)
+				}
+			}
+			var shadowed []string
+			for _, entry := range catalogs.Shadowed {
+				shadowed = append(shadowed, entry.Range)
+			}
+			if !reflect.DeepEqual(shadowed, tt.shadowed) {
+				t.Errorf("Shadowed = %v, want %v", shadowed, tt.shadowed)
+			}
+			for name, want := range tt.find {
+				var got []string
+				for _, entry := range catalogs.Find(name) {
+					got = append(got, entry.Catalog)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Find(%s) = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEntrySpec(t *testing.T) {
+	pnpmEntry := Entry{Catalog: "default", Name: "react", Range: "^18.3.1", Source: SourcePnpm}
+	configEntry := Entry{Catalog: "legacy", Name: "react", Range: "^17.0.2", Source: SourceConfig}
+
+	tests := []struct {
+		entry Entry
+		pm    pmcombo.PackageManager
+		want  string
+	}{
+		{pnpmEntry, pmcombo.PNPM, "catalog:"},
+		{pnpmEntry, pmcombo.Bun, "^18.3.1"},
+		{pnpmEntry, pmcombo.NPM, "^18.3.1"},
+		{configEntry, pmcombo.PNPM, "^17.0.2"},
+		{Entry{Catalog: "legacy", Source: SourceBun}, pmcombo.Bun, "catalog:legacy"},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.Spec(tt.pm); got != tt.want {
+			t.Errorf("%+v.Spec(%s) = %q, want %q", tt.entry, tt.pm, got, tt.want)
+		}
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+		ok   bool
+	}{
+		{"catalog:", "default", true},
+		{"catalog:legacy", "legacy", true},
+		{"^18.3.1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRef(tt.spec)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRef(%q) = %q, %v", tt.spec, got, ok)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		pm    pmcombo.PackageManager
+		files map[string]string
+		cfg   *config.Config
+		want  []string // "<dir> <name> <problem> <expected>"
+	}{
+		{
+			name: "config catalogs",
+			pm:   pmcombo.NPM,
+			files: map[string]string{
+				"package.json":            `{"name": "root", "workspaces": ["packages/*"], "devDependencies": {"typescript": "~5.6.3"}}`,
+				"packages/a/package.json": `{"name": "a", "dependencies": {"react": "^18.2.0", "lodash": "catalog:", "left-pad": "^1.3.0"}}`,
+				"packages/b/package.json": `{"name": "b", "dependencies": {"react": "^17.0.2", "vue": "catalog:"}, "peerDependencies": {"react-dom": "catalog:"}}`,
+			},
+			cfg: &config.Config{
+				Catalog:  map[string]string{"react": "^18.3.1", "lodash": "^4.17.21", "typescript": "~5.6.3", "react-dom": "^18.3.1"},
+				Catalogs: map[string]map[string]string{"legacy": {"react": "^17.0.2"}},
+			},
+			want: []string{
+				"packages/a lodash unsupported ^4.17.21",
+				"packages/a react drift ^18.3.1",
+				"packages/b react-dom unsupported ^18.3.1",
+				"packages/b vue unknown ",
+			},
+		},
+		{
+			name: "native pnpm catalog",
+			pm:   pmcombo.PNPM,
+			files: map[string]string{
+				"package.json":            `{"name": "root"}`,
+				"pnpm-workspace.yaml":     "packages:\n  - packages/*\ncatalog:\n  react: ^18.3.1\n",
+				"packages/a/package.json": `{"name": "a", "dependencies": {"react": "catalog:"}}`,
+				"packages/b/package.json": `{"name": "b", "dependencies": {"react": "^18.0.0"}}`,
+			},
+			want: []string{"packages/b react drift catalog:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := loadContext(t, tt.pm, tt.files)
+			catalogs, err := Load(ctx.RootDir, tt.cfg)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			issues := Check(ctx, catalogs)
+			var got []string
+			for _, issue := range issues {
+				got = append(got, issue.RelDir+" "+issue.Name+" "+issue.Problem+" "+issue.Expected)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Check = %q, want %q", got, tt.want)
+			}
+
+			// Applying the fixes leaves nothing to report
+			var changes []deps.Change
+			for _, issue := range issues {
+				if issue.Problem == ProblemDrift {
+					changes = append(changes, issue.Change())
+				}
+			}
+			if err := deps.Apply(changes); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+			for _, change := range changes {
+				pkg, err := context.ReadPackageJSON(change.Manifest)
+				if err != nil {
+					t.Fatalf("read %s: %v", change.Manifest, err)
+				}
+				if got := workspace.DependencyField(pkg, change.Type)[change.Name]; got != change.To {
+					t.Errorf("%s = %q after apply, want %q", change.Name, got, change.To)
+				}
+			}
+		})
+	}
+}
+
+// loadContext writes the files into a new project using pm
+func loadContext(t *testing.T, pm pmcombo.PackageManager, files map[string]string) *context.ProjectContext {
+	t.Helper()
+
+	rootDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(rootDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, err := context.Detect(rootDir)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	ctx.PackageManager = pm
+	return ctx
+}
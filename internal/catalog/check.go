@@ -0,0 +1,134 @@
+package catalog
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/semver"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// Problem kinds reported by Check
+const (
+	// ProblemDrift is a concrete range that differs from every catalog entry
+	ProblemDrift = "drift"
+	// ProblemUnknown is a `catalog:` reference to a missing entry
+	ProblemUnknown = "unknown"
+	// ProblemUnsupported is a `catalog:` reference the package manager
+	// can't resolve, because it has no catalogs or the entry lives in gnpm
+	// config only
+	ProblemUnsupported = "unsupported"
+)
+
+// Issue is a dependency declaration out of line with the catalogs
+type Issue struct {
+	Name      string
+	Spec      string
+	Type      string
+	Workspace string
+	Dir       string
+	RelDir    string
+	Problem   string
+	Catalog   string // catalog the declaration belongs to
+	Range     string // range in the catalog; empty when the entry is missing
+	Expected  string // specifier to write; empty when there's no fix
+}
+
+// Change returns the manifest rewrite fixing the issue
+func (i Issue) Change() deps.Change {
+	return deps.Change{
+		Manifest: filepath.Join(i.Dir, "package.json"),
+		RelPath:  path.Join(i.RelDir, "package.json"),
+		Type:     i.Type,
+		Name:     i.Name,
+		From:     i.Spec,
+		To:       i.Expected,
+	}
+}
+
+// Check compares every workspace package's dependencies with the catalogs.
+// References must point at an entry pm can resolve, and concrete ranges of
+// cataloged packages must match one of their catalog entries.
+func Check(ctx *context.ProjectContext, catalogs *Catalogs) []Issue {
+	pm := ctx.PackageManager
+
+	var issues []Issue
+	for _, importer := range workspace.Importers(ctx) {
+		pkg := importer.PackageJSON
+		if pkg == nil {
+			continue
+		}
+		label := pkg.Name
+		if label == "" {
+			label = importer.RelDir
+		}
+
+		for _, depType := range workspace.DependencyTypes {
+			for name, spec := range workspace.DependencyField(pkg, depType) {
+				issue := Issue{
+					Name:      name,
+					Spec:      spec,
+					Type:      depType,
+					Workspace: label,
+					Dir:       importer.Dir,
+					RelDir:    importer.RelDir,
+				}
+				if checkSpec(&issue, catalogs, pm) {
+					issues = append(issues, issue)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Name != issues[j].Name {
+			return issues[i].Name < issues[j].Name
+		}
+		return issues[i].RelDir < issues[j].RelDir
+	})
+	return issues
+}
+
+// checkSpec fills in the problem and fix for a declaration and reports
+// whether there is one
+func checkSpec(issue *Issue, catalogs *Catalogs, pm pmcombo.PackageManager) bool {
+	if catalog, ok := ParseRef(issue.Spec); ok {
+		issue.Catalog = catalog
+		entry, found := catalogs.Lookup(catalog, issue.Name)
+		switch {
+		case !found:
+			issue.Problem = ProblemUnknown
+		case !entry.Native(pm):
+			issue.Problem = ProblemUnsupported
+			issue.Range, issue.Expected = entry.Range, entry.Range
+		default:
+			return false
+		}
+		return true
+	}
+
+	entries := catalogs.Find(issue.Name)
+	if len(entries) == 0 {
+		return false
+	}
+	// Aliases, workspace links, git and file specifiers are left alone
+	dep := semver.ParseDependency(issue.Name, issue.Spec)
+	if _, ok := dep.SemverRange(); !ok || dep.Protocol != "" {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Range == issue.Spec {
+			return false
+		}
+	}
+
+	issue.Problem = ProblemDrift
+	issue.Catalog = entries[0].Catalog
+	issue.Range = entries[0].Range
+	issue.Expected = entries[0].Spec(pm)
+	return true
+}
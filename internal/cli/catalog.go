@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/catalog"
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/runner"
+)
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Manage shared dependency versions",
+	Long: `Manage shared dependency versions (catalogs) for every package manager.
+
+Catalogs are read from pnpm-workspace.yaml (pnpm), the root package.json
+(bun) and .gnpm/config.yaml:
+
+  catalog:
+    react: ^18.3.1
+  catalogs:
+    legacy:
+      react: ^17.0.2
+
+pnpm and bun resolve "catalog:" references themselves. For npm and yarn,
+gnpm writes the concrete range into package.json instead and
+"gnpm catalog check" reports ranges that drift from the catalog.`,
+}
+
+var catalogListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List catalog entries",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, catalogs, err := loadCatalogs()
+		if err != nil {
+			return err
+		}
+		if catalogs.Empty() {
+			logger.Info("No catalogs defined; add one under catalog: in %s", catalog.SourceConfig)
+			return nil
+		}
+
+		for _, name := range catalogs.Names() {
+			logger.Header(fmt.Sprintf("%s (%s)", name, catalog.Ref(name)))
+			entries := catalogs.Entries(name)
+			width := 0
+			for _, entry := range entries {
+				width = max(width, len(entry.Name))
+			}
+			for _, entry := range entries {
+				logger.List(fmt.Sprintf("%-*s  %s  %s", width, entry.Name, entry.Range, entry.Source))
+			}
+		}
+		return nil
+	},
+}
+
+var catalogCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Report dependencies that drift from the catalogs",
+	Long: `Report dependencies out of line with the catalogs: concrete ranges that
+differ from their catalog entry, and "catalog:" references that are
+missing or that the package manager can't resolve. Exits with an error
+when issues are found, for use in CI.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, catalogs, err := loadCatalogs()
+		if err != nil {
+			return err
+		}
+
+		issues := catalog.Check(projectCtx, catalogs)
+		if len(issues) == 0 {
+			logger.Success("All dependencies match the catalogs")
+			return nil
+		}
+
+		for _, issue := range issues {
+			logger.List(describeCatalogIssue(issue, projectCtx.PackageManager))
+		}
+		return fmt.Errorf("%d dependency declaration(s) out of line with the catalogs; run `gnpm catalog apply` to fix", len(issues))
+	},
+}
+
+var catalogApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Rewrite dependencies to match the catalogs",
+	Long: `Rewrite dependencies to match the catalogs: "catalog:" references for
+package managers that support them, concrete catalog ranges otherwise.
+
+Examples:
+  gnpm catalog apply             # Fix every workspace package
+  gnpm catalog apply --dry-run   # Show what would change`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, catalogs, err := loadCatalogs()
+		if err != nil {
+			return err
+		}
+
+		var changes []deps.Change
+		for _, issue := range catalog.Check(projectCtx, catalogs) {
+			if issue.Expected == "" {
+				logger.Warn("%s; skipped", describeCatalogIssue(issue, projectCtx.PackageManager))
+				continue
+			}
+			changes = append(changes, issue.Change())
+		}
+		if len(changes) == 0 {
+			logger.Success("All dependencies match the catalogs")
+			return nil
+		}
+
+		for _, change := range changes {
+			logger.List(fmt.Sprintf("%s %s → %s (%s)", change.Name, change.From, change.To, change.RelPath))
+		}
+		if dryRun {
+			return nil
+		}
+
+		if err := deps.Apply(changes); err != nil {
+			return err
+		}
+		logger.Success("Updated %d dependency range(s); run `gnpm install` to update the lockfile", len(changes))
+		return nil
+	},
+}
+
+func init() {
+	catalogCmd.AddCommand(catalogListCmd)
+	catalogCmd.AddCommand(catalogCheckCmd)
+	catalogCmd.AddCommand(catalogApplyCmd)
+}
+
+// runCatalogAdd declares packages in the manifest at workDir with their
+// catalog version, as a "catalog:" reference when the package manager
+// supports it, then installs from the workspace root
+func runCatalogAdd(packages []string, workDir string) error {
+	if installGlobal {
+		return fmt.Errorf("--catalog can't be used with --global")
+	}
+	if installExact {
+		return fmt.Errorf("--catalog can't be used with --exact; the range comes from the catalog")
+	}
+
+	projectCtx, catalogs, err := loadCatalogs()
+	if err != nil {
+		return err
+	}
+
+	depType := "dependencies"
+	switch {
+	case installDev:
+		depType = "devDependencies"
+	case installOptional:
+		depType = "optionalDependencies"
+	case installPeer:
+		depType = "peerDependencies"
+	}
+
+	manifest := filepath.Join(workDir, "package.json")
+	relPath, err := filepath.Rel(projectCtx.RootDir, manifest)
+	if err != nil {
+		return err
+	}
+
+	var changes []deps.Change
+	for _, arg := range packages {
+		name, spec := registry.SplitSpec(arg)
+		if spec != "" {
+			return fmt.Errorf("%s: drop the version, --catalog takes it from the %s catalog", arg, installCatalog)
+		}
+		entry, ok := catalogs.Lookup(installCatalog, name)
+		if !ok {
+			return fmt.Errorf("%s is not in the %s catalog", name, installCatalog)
+		}
+		changes = append(changes, deps.Change{
+			Manifest: manifest,
+			RelPath:  filepath.ToSlash(relPath),
+			Type:     depType,
+			Name:     name,
+			To:       entry.Spec(projectCtx.PackageManager),
+		})
+	}
+
+	for _, change := range changes {
+		logger.List(fmt.Sprintf("%s %s (%s of %s)", change.Name, change.To, change.Type, change.RelPath))
+	}
+	if !dryRun {
+		if err := deps.Apply(changes); err != nil {
+			return err
+		}
+	}
+
+	installCommand := pmcombo.NewInstallCommand(pmcombo.InstallOptions{})
+	cmdArgs, err := installCommand.Concat(projectCtx.PackageManager)
+	if err != nil {
+		return err
+	}
+	return runner.Run(projectCtx.PackageManager, cmdArgs, projectCtx.RootDir, runnerOpts())
+}
+
+// loadCatalogs reads the catalogs of the current workspace
+func loadCatalogs() (*context.ProjectContext, *catalog.Catalogs, error) {
+	projectCtx, err := workspaceContext()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg, err := config.Load(projectCtx.RootDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	catalogs, err := catalog.Load(projectCtx.RootDir, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range catalogs.Shadowed {
+		logger.Warn("%s %s in %s is ignored; %s defines it in the %s catalog",
+			entry.Name, entry.Range, entry.Source, catalogSourceOf(catalogs, entry), entry.Catalog)
+	}
+	return projectCtx, catalogs, nil
+}
+
+func catalogSourceOf(catalogs *catalog.Catalogs, shadowed catalog.Entry) catalog.Source {
+	entry, _ := catalogs.Lookup(shadowed.Catalog, shadowed.Name)
+	return entry.Source
+}
+
+func describeCatalogIssue(issue catalog.Issue, pm pmcombo.PackageManager) string {
+	where := fmt.Sprintf("%s %s in %s", issue.Name, issue.Spec, issue.Workspace)
+	switch issue.Problem {
+	case catalog.ProblemUnknown:
+		return fmt.Sprintf("%s: not in the %s catalog", where, issue.Catalog)
+	case catalog.ProblemUnsupported:
+		return fmt.Sprintf("%s: %s can't resolve this catalog, use %s", where, pm, issue.Range)
+	default:
+		return fmt.Sprintf("%s: %s catalog has %s", where, issue.Catalog, issue.Range)
+	}
+}
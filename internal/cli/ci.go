@@ -19,6 +19,10 @@ var ciCmd = &cobra.Command{
 
 		runPackageManagerSecurityCheck()
 
+		if err := runEnginesCheck(); err != nil {
+			return err
+		}
+
 		installCmd := pmcombo.NewInstallCommand(pmcombo.InstallOptions{
 			Frozen: true,
 		})
@@ -31,3 +35,8 @@ var ciCmd = &cobra.Command{
 		return runner.Run(ctx.PackageManager, cmdArgs, workDir, runnerOpts())
 	},
 }
+
+func init() {
+	ciCmd.Flags().BoolVar(&engineStrict, "engine-strict", false, "Fail when engines or platforms are incompatible")
+	ciCmd.Flags().BoolVar(&checkPlatform, "check-platform", false, "Check os/cpu/libc of direct dependencies")
+}
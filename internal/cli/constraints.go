@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/constraints"
+	"github.com/AkaraChen/gnpm/internal/logger"
+)
+
+var constraintsFix bool
+
+var constraintsCmd = &cobra.Command{
+	Use:   "constraints",
+	Short: "Check workspace manifests against the rules in .gnpm/constraints.yaml",
+	Long: `Check every workspace package.json against declarative rules and
+report violations. Exits with an error when rules are broken, for use in CI.
+
+Rules live in .gnpm/constraints.yaml at the workspace root:
+
+  rules:
+    - field: license
+      value: MIT                # must equal; fixable
+    - field: scripts.test       # must exist
+    - field: repository.url
+      pattern: ^https://github.com/acme/
+    - field: publishConfig
+      forbidden: true           # must not be set; fixable
+    - dependency: lodash
+      forbidden: true           # fixable
+      message: Use lodash-es instead
+    - dependency: react
+      range: ^18.3.1            # fixable
+      types: [dependencies, peerDependencies]
+    - internal: workspace:^     # dependencies on workspace packages; fixable
+      packages: ["@acme/*"]     # only these packages (default all)
+      exclude: ["@acme/legacy"]
+
+Examples:
+  gnpm constraints            # Report violations
+  gnpm constraints --fix      # Fix what can be fixed`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+
+		file, err := constraints.Load(projectCtx.RootDir)
+		if err != nil {
+			return err
+		}
+		if len(file.Rules) == 0 {
+			logger.Info("No rules found; add them to .gnpm/constraints.yaml")
+			return nil
+		}
+
+		violations, err := constraints.Check(projectCtx.RootDir, file.Rules)
+		if err != nil {
+			return err
+		}
+		if len(violations) == 0 {
+			logger.Success("All %d package manifest rule(s) pass", len(file.Rules))
+			return nil
+		}
+
+		fixable := printViolations(violations)
+		if !constraintsFix {
+			if fixable > 0 {
+				return fmt.Errorf("found %d violation(s); run `gnpm constraints --fix` to fix %d of them", len(violations), fixable)
+			}
+			return fmt.Errorf("found %d violation(s)", len(violations))
+		}
+
+		if !dryRun && fixable > 0 {
+			fixed, err := constraints.Fix(violations)
+			if err != nil {
+				return err
+			}
+			logger.Success("Fixed %d violation(s)", fixed)
+		}
+		if remaining := len(violations) - fixable; remaining > 0 {
+			return fmt.Errorf("%d violation(s) need to be fixed by hand", remaining)
+		}
+		return nil
+	},
+}
+
+func init() {
+	constraintsCmd.Flags().BoolVar(&constraintsFix, "fix", false, "Fix violations where possible")
+}
+
+// printViolations lists violations by package and returns how many are
+// fixable
+func printViolations(violations []constraints.Violation) int {
+	fixable := 0
+	pkg := ""
+	for _, v := range violations {
+		if v.Package != pkg {
+			pkg = v.Package
+			logger.Header(fmt.Sprintf("%s (%s)", pkg, v.RelPath))
+		}
+
+		detail := v.Detail
+		if v.Fixable() {
+			fixable++
+		} else {
+			detail += ", fix by hand"
+		}
+		logger.List(fmt.Sprintf("%s (%s)", v.Rule, detail))
+		if v.Rule.Message != "" {
+			logger.Dim("    %s", v.Rule.Message)
+		}
+	}
+	return fixable
+}
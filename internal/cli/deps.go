@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/logger"
+)
+
+var (
+	depsPolicy string
+	depsFix    bool
+)
+
+var depsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "Check and fix dependency declarations across the workspace",
+	Long: `Check and fix dependency declarations across the workspace.
+
+Settings live in .gnpm/config.yaml at the workspace root:
+
+  deps:
+    policy: highest        # highest, most-common or pinned
+    pins:
+      react: ^18.3.1       # always use this range
+    ignore:
+      - "@types/*"`,
+}
+
+var depsCheckCmd = &cobra.Command{
+	Use:   "check [package...]",
+	Short: "Report dependencies declared with different ranges",
+	Long: `Report external dependencies declared with different ranges across
+workspace packages, or with a range other than their pin. Exits with an
+error when mismatches are found, for use in CI.
+
+Examples:
+  gnpm deps check            # All dependencies
+  gnpm deps check react      # Only react`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mismatches, err := findDependencyMismatches(args)
+		if err != nil {
+			return err
+		}
+		if len(mismatches) == 0 {
+			logger.Success("All dependency ranges are in sync")
+			return nil
+		}
+
+		printMismatches(mismatches)
+		logger.Info("Run `gnpm deps sync` to fix them")
+		return fmt.Errorf("%d dependency mismatch(es)", len(mismatches))
+	},
+}
+
+var depsSyncCmd = &cobra.Command{
+	Use:   "sync [package...]",
+	Short: "Rewrite mismatched dependencies to a single range",
+	Long: `Rewrite external dependencies declared with different ranges to a
+single range, picked by the policy: the highest range, the most common one,
+or only pinned ranges.
+
+Examples:
+  gnpm deps sync                        # Sync using the configured policy
+  gnpm deps sync --policy most-common   # Use the most common range
+  gnpm deps sync --dry-run              # Show what would change`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mismatches, err := findDependencyMismatches(args)
+		if err != nil {
+			return err
+		}
+		if len(mismatches) == 0 {
+			logger.Success("All dependency ranges are in sync")
+			return nil
+		}
+
+		var changes []deps.Change
+		for _, m := range mismatches {
+			if m.Target == "" {
+				logger.Warn("%s has mismatched ranges (%s) but no pin; skipped", m.Name, strings.Join(m.Ranges(), ", "))
+				continue
+			}
+			changes = append(changes, m.Changes()...)
+		}
+
+		for _, change := range changes {
+			logger.List(fmt.Sprintf("%s %s → %s (%s)", change.Name, change.From, change.To, change.RelPath))
+		}
+		if len(changes) == 0 || dryRun {
+			return nil
+		}
+
+		if err := deps.Apply(changes); err != nil {
+			return err
+		}
+		logger.Success("Updated %d dependency range(s); run `gnpm install` to update the lockfile", len(changes))
+		return nil
+	},
+}
+
+var depsLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Find unused, missing and misplaced dependencies",
+	Long: `Scan the source files of every workspace package for imports and compare
+them with its package.json. Reports:
+
+  unused        declared dependencies nothing imports or runs in a script
+  missing       imports of packages that aren't declared (phantom dependencies)
+  dev-in-prod   production code importing a devDependency
+
+Tests, stories, configs and files in test or scripts directories count as
+development code. They may use dependencies declared at the workspace root.
+
+Examples:
+  gnpm deps lint             # Report problems
+  gnpm deps lint --fix       # Add, remove and move declarations`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Load(projectCtx.RootDir)
+		if err != nil {
+			return err
+		}
+
+		issues, err := deps.Lint(projectCtx, deps.LintOptions{Ignore: cfg.Deps.Ignore})
+		if err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			logger.Success("No dependency problems found")
+			return nil
+		}
+
+		printLintIssues(issues)
+		if !depsFix {
+			return fmt.Errorf("found %d dependency problem(s); run `gnpm deps lint --fix` to fix them", len(issues))
+		}
+
+		var changes []deps.Change
+		for _, issue := range issues {
+			fix := issue.Changes()
+			if len(fix) == 0 {
+				logger.Warn("%s: no version known for %s; add it with `gnpm add`", issue.Workspace, issue.Name)
+			}
+			changes = append(changes, fix...)
+		}
+		if len(changes) == 0 || dryRun {
+			return nil
+		}
+
+		if err := deps.Apply(changes); err != nil {
+			return err
+		}
+		logger.Success("Fixed %d declaration(s); run `gnpm install` to update the lockfile", len(changes))
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{depsCheckCmd, depsSyncCmd} {
+		cmd.Flags().StringVar(&depsPolicy, "policy", "", "Range to sync to: highest, most-common or pinned (default from config, else highest)")
+		depsCmd.AddCommand(cmd)
+	}
+	depsLintCmd.Flags().BoolVar(&depsFix, "fix", false, "Add missing, remove unused and move misplaced dependencies")
+	depsCmd.AddCommand(depsLintCmd)
+}
+
+// findDependencyMismatches loads the workspace config and compares every
+// package's declarations
+func findDependencyMismatches(packages []string) ([]deps.Mismatch, error) {
+	projectCtx, err := workspaceContext()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(projectCtx.RootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	policyName := depsPolicy
+	if policyName == "" {
+		policyName = cfg.Deps.Policy
+	}
+	policy, err := deps.ParsePolicy(policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return deps.FindMismatches(projectCtx, deps.SyncOptions{
+		Policy:   policy,
+		Pins:     cfg.Deps.Pins,
+		Ignore:   cfg.Deps.Ignore,
+		Packages: packages,
+	}), nil
+}
+
+func printMismatches(mismatches []deps.Mismatch) {
+	logger.Warn("mismatched ranges found for %d package(s):", len(mismatches))
+	for _, m := range mismatches {
+		logger.Header(m.Name)
+		for _, spec := range m.Ranges() {
+			var users []string
+			for _, d := range m.Declarations {
+				if d.Spec == spec {
+					users = append(users, d.Workspace)
+				}
+			}
+			logger.List(fmt.Sprintf("%s  %s", spec, strings.Join(users, ", ")))
+		}
+
+		switch {
+		case m.Target == "":
+			logger.Dim("    no pin; add one under deps.pins in .gnpm/config.yaml")
+		case m.Pinned:
+			logger.Dim("    pinned to %s", m.Target)
+		default:
+			logger.Dim("    sync to %s", m.Target)
+		}
+	}
+}
+
+func printLintIssues(issues []deps.LintIssue) {
+	workspace := ""
+	for _, issue := range issues {
+		if issue.Workspace != workspace {
+			workspace = issue.Workspace
+			logger.Header(workspace)
+		}
+
+		switch issue.Problem {
+		case deps.LintUnused:
+			logger.List(fmt.Sprintf("unused       %s (%s)", issue.Name, issue.Type))
+		case deps.LintMissing:
+			logger.List(fmt.Sprintf("missing      %s, imported by %s", issue.Name, strings.Join(issue.Files, ", ")))
+		case deps.LintDevInProd:
+			logger.List(fmt.Sprintf("dev-in-prod  %s, imported by %s", issue.Name, strings.Join(issue.Files, ", ")))
+		}
+	}
+}
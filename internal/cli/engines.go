@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"github.com/AkaraChen/gnpm/internal/engines"
+	"github.com/AkaraChen/gnpm/internal/native"
+)
+
+var engineStrict bool
+var checkPlatform bool
+
+func runEnginesCheck() error {
+	// Nothing gets installed, and probing versions would spawn processes
+	if dryRun {
+		return nil
+	}
+
+	strict := engineStrict
+	if !strict {
+		if value, ok := native.GetConfig(ctx.RootDir, "engine-strict"); ok && value == "true" {
+			strict = true
+		}
+	}
+
+	return engines.Run(ctx, engines.Options{
+		Strict:        strict,
+		CheckPlatform: checkPlatform,
+		Verbose:       verbose,
+	})
+}
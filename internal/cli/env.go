@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/dotenv"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/native"
+)
+
+var (
+	envFiles  []string
+	noEnvFile bool
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{runCmd, testCmd, execCmd} {
+		cmd.Flags().StringArrayVar(&envFiles, "env-file", nil, "Load variables from this file instead of the .env files (repeatable)")
+		cmd.Flags().BoolVar(&noEnvFile, "no-env-file", false, "Don't load .env files")
+	}
+}
+
+// scriptDirs returns the package directory holding dir and its workspace
+// root, which is the package directory outside workspaces
+func scriptDirs(dir string) (pkgDir string, rootDir string) {
+	pkgDir = dir
+	if pkgPath, err := context.FindPackageJSON(dir); err == nil {
+		pkgDir = filepath.Dir(pkgPath)
+	}
+	rootDir = pkgDir
+	if wsRoot, err := context.FindWorkspaceRoot(pkgDir); err == nil {
+		rootDir = wsRoot
+	}
+	return pkgDir, rootDir
+}
+
+// scriptShell returns the shell scripts in dir run with, from scripts.shell
+// in .gnpm/config.yaml
+func scriptShell(dir string) (string, error) {
+	_, rootDir := scriptDirs(dir)
+	cfg, err := config.Load(rootDir)
+	if err != nil {
+		return "", err
+	}
+	switch cfg.Scripts.Shell {
+	case "", native.ShellSystem:
+		return native.ShellSystem, nil
+	case native.ShellBuiltin:
+		return native.ShellBuiltin, nil
+	default:
+		return "", fmt.Errorf("%s: unknown scripts.shell %q (use %s or %s)", cfg.Path, cfg.Scripts.Shell, native.ShellSystem, native.ShellBuiltin)
+	}
+}
+
+// dotenvVars returns the variables from the .env files that apply to a
+// command run in dir. Loading is opt-in: --env-file picks the files
+// explicitly, and an env block in .gnpm/config.yaml turns on env.files or
+// else .env, .env.local, .env.<mode> and .env.<mode>.local from the
+// workspace root and then the package directory. Nothing is loaded
+// otherwise, as npm, pnpm and yarn don't read .env files.
+func dotenvVars(dir string) ([]string, error) {
+	if noEnvFile {
+		return nil, nil
+	}
+
+	pkgDir, rootDir := scriptDirs(dir)
+
+	var paths []string
+	required := false
+	if len(envFiles) > 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range envFiles {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(cwd, file)
+			}
+			paths = append(paths, file)
+		}
+		required = true
+	} else {
+		cfg, err := config.Load(rootDir)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Env == nil || cfg.Env.Disable {
+			return nil, nil
+		}
+		if len(cfg.Env.Files) > 0 {
+			paths = dotenv.Paths([]string{rootDir}, cfg.Env.Files)
+		} else {
+			paths = dotenv.Paths([]string{rootDir, pkgDir}, dotenv.Files(dotenv.Mode(cfg.Env.Mode)))
+		}
+	}
+
+	env, err := dotenv.Load(paths, required)
+	if err != nil {
+		return nil, err
+	}
+	if verbose && len(env.Files) > 0 {
+		var loaded []string
+		for _, file := range env.Files {
+			if rel, err := filepath.Rel(rootDir, file); err == nil {
+				file = rel
+			}
+			loaded = append(loaded, file)
+		}
+		logger.Dim("Loaded %s", strings.Join(loaded, ", "))
+	}
+	return env.Environ(), nil
+}
@@ -39,6 +39,11 @@ Examples:
 		// Try to find the binary locally first
 		_, err = native.FindBinary(workDir, command)
 		if err == nil {
+			env, err := dotenvVars(workDir)
+			if err != nil {
+				return err
+			}
+
 			// Binary found locally, execute it
 			return native.Exec(native.ExecOptions{
 				Dir:     workDir,
@@ -46,6 +51,7 @@ Examples:
 				Args:    commandArgs,
 				Verbose: verbose,
 				DryRun:  dryRun,
+				Env:     env,
 			})
 		}
 
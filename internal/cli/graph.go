@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/graph"
+	"github.com/AkaraChen/gnpm/internal/logger"
+)
+
+var (
+	graphFormat string
+	graphFocus  string
+	graphProd   bool
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Show the dependency graph between workspace packages",
+	Long: `Show which workspace packages depend on each other, and warn about
+dependency cycles. devDependencies are drawn dashed, cycles in red.
+
+Examples:
+  gnpm graph                               # List packages and their dependencies
+  gnpm graph --format dot | dot -Tsvg > graph.svg
+  gnpm graph --format mermaid              # Paste into Markdown
+  gnpm graph --focus @acme/ui              # Only what @acme/ui uses and what uses it
+  gnpm graph --prod                        # Leave out devDependencies`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+
+		g, err := graph.Load(projectCtx.RootDir)
+		if err != nil {
+			return err
+		}
+		if graphProd {
+			g = g.WithoutDev()
+		}
+		if graphFocus != "" {
+			if g, err = g.Focus(graphFocus); err != nil {
+				return err
+			}
+		}
+
+		output, err := g.Render(graphFormat)
+		if err != nil {
+			return err
+		}
+		logger.Plain("%s", output)
+
+		for _, cycle := range g.Cycles() {
+			logger.Warn("dependency cycle: %s", graph.FormatCycle(cycle))
+		}
+		return nil
+	},
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFormat, "format", graph.FormatText, "Output format: text, dot, mermaid or json")
+	graphCmd.Flags().StringVar(&graphFocus, "focus", "", "Only show a package's dependencies and dependents")
+	graphCmd.Flags().BoolVar(&graphProd, "prod", false, "Leave out devDependencies")
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/AkaraChen/gnpm/internal/catalog"
 	"github.com/AkaraChen/gnpm/internal/pmcombo"
 	"github.com/AkaraChen/gnpm/internal/runner"
 )
@@ -12,6 +13,7 @@ var installExact bool
 var installGlobal bool
 var installPeer bool
 var installOptional bool
+var installCatalog string
 
 var installCmd = &cobra.Command{
 	Use:     "install [packages...]",
@@ -28,7 +30,9 @@ Examples:
   gnpm install react     # Add react package
   gnpm i react -D        # Add react as dev dependency
   gnpm add react         # Same as install react
-  gnpm a react           # Same as above`,
+  gnpm a react           # Same as above
+  gnpm add react --catalog          # Add react from the default catalog
+  gnpm add react --catalog=legacy   # Add react from the legacy catalog`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runInstall(args)
 	},
@@ -40,6 +44,10 @@ func init() {
 	installCmd.Flags().BoolVarP(&installGlobal, "global", "g", false, "Add globally")
 	installCmd.Flags().BoolVar(&installPeer, "peer", false, "Add as peer dependency")
 	installCmd.Flags().BoolVarP(&installOptional, "optional", "O", false, "Add as optional dependency")
+	installCmd.Flags().StringVar(&installCatalog, "catalog", "", "Add packages with the version from a catalog (default catalog when no name is given)")
+	installCmd.Flags().Lookup("catalog").NoOptDefVal = catalog.DefaultName
+	installCmd.Flags().BoolVar(&engineStrict, "engine-strict", false, "Fail when engines or platforms are incompatible")
+	installCmd.Flags().BoolVar(&checkPlatform, "check-platform", false, "Check os/cpu/libc of direct dependencies")
 }
 
 func runInstall(args []string) error {
@@ -50,6 +58,10 @@ func runInstall(args []string) error {
 
 	runPackageManagerSecurityCheck()
 
+	if err := runEnginesCheck(); err != nil {
+		return err
+	}
+
 	// If no packages specified, install all dependencies
 	if len(args) == 0 {
 		installCmd := pmcombo.NewInstallCommand(pmcombo.InstallOptions{
@@ -64,6 +76,10 @@ func runInstall(args []string) error {
 		return runner.Run(ctx.PackageManager, cmdArgs, workDir, runnerOpts())
 	}
 
+	if installCatalog != "" {
+		return runCatalogAdd(args, workDir)
+	}
+
 	// Otherwise, add the specified packages
 	addCmd := pmcombo.NewAddCommand(pmcombo.AddOptions{
 		Packages: args,
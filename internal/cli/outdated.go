@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/logger"
+)
+
+var outdatedJSON bool
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated [package...]",
+	Short: "Check for outdated dependencies",
+	Long: `Check every workspace package for outdated dependencies.
+
+Declared ranges come from each package.json, the current version from the
+lockfile (or node_modules), and wanted/latest from the registry, so the
+output is the same for every package manager.
+
+  current   installed version
+  wanted    highest version the declared range allows
+  latest    version tagged latest on the registry
+
+Examples:
+  gnpm outdated          # All dependencies in the workspace
+  gnpm outdated react    # Only react
+  gnpm outdated --json   # Machine-readable output`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+
+		client, err := newRegistryClient(projectCtx.RootDir)
+		if err != nil {
+			return err
+		}
+
+		results := deps.FindOutdated(projectCtx, client, deps.Options{
+			Packages: args,
+			Verbose:  verbose,
+		})
+
+		if outdatedJSON {
+			if results == nil {
+				results = []deps.Outdated{}
+			}
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return err
+			}
+			logger.Plainln(string(data))
+			return nil
+		}
+
+		if len(results) == 0 {
+			logger.Success("All dependencies are up to date")
+			return nil
+		}
+		printOutdatedTable(results)
+		return nil
+	},
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&outdatedJSON, "json", false, "Output JSON")
+}
+
+// workspaceContext returns the context of the workspace root, so commands
+// that report on every package work from any package directory
+func workspaceContext() (*context.ProjectContext, error) {
+	if ctx.PackageJSON == nil {
+		return nil, fmt.Errorf("no package.json found")
+	}
+
+	root, err := context.FindWorkspaceRoot(ctx.RootDir)
+	if err != nil || root == ctx.RootDir {
+		return ctx, nil
+	}
+
+	rootCtx, err := context.Detect(root)
+	if err != nil {
+		return ctx, nil
+	}
+	if usePM != "" {
+		rootCtx.PackageManager = ctx.PackageManager
+		rootCtx.PackageManagerSpec = ctx.PackageManagerSpec
+	}
+	return rootCtx, nil
+}
+
+func printOutdatedTable(results []deps.Outdated) {
+	showWorkspace := false
+	for _, r := range results {
+		if r.RelDir != "." {
+			showWorkspace = true
+			break
+		}
+	}
+
+	header := []string{"Package", "Current", "Wanted", "Latest", "Type"}
+	if showWorkspace {
+		header = append(header, "Workspace")
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		name := r.Name
+		if r.Package != r.Name {
+			name += " (" + r.Package + ")"
+		}
+		row := []string{name, orDefault(r.Current, "missing"), orDefault(r.Wanted, "-"), orDefault(r.Latest, "-"), r.Type}
+		if showWorkspace {
+			row = append(row, r.Workspace)
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = len(cell)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	bold := color.New(color.Bold).SprintFunc()
+	dim := color.New(color.FgHiBlack).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	logger.Plainln(formatRow(header, widths, func(i int, cell string) string { return bold(cell) }))
+	for idx, row := range rows {
+		r := results[idx]
+		logger.Plainln(formatRow(row, widths, func(i int, cell string) string {
+			switch i {
+			case 1:
+				if r.Current == "" {
+					return red(cell)
+				}
+			case 2:
+				return bumpColor(deps.Bump(r.Current, r.Wanted))(cell)
+			case 3:
+				return bumpColor(r.Bump)(cell)
+			case 4, 5:
+				return dim(cell)
+			}
+			return cell
+		}))
+	}
+}
+
+// formatRow pads cells to their column width before coloring them, so
+// escape codes don't break the alignment
+func formatRow(cells []string, widths []int, paint func(int, string) string) string {
+	parts := make([]string, len(cells))
+	for i, cell := range cells {
+		padded := cell
+		if i < len(cells)-1 {
+			padded += strings.Repeat(" ", widths[i]-len(cell))
+		}
+		parts[i] = paint(i, padded)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// bumpColor colors major updates red, minor yellow and patch green
+func bumpColor(bump string) func(a ...interface{}) string {
+	switch strings.TrimPrefix(bump, "pre") {
+	case "major":
+		return color.New(color.FgRed).SprintFunc()
+	case "minor":
+		return color.New(color.FgYellow).SprintFunc()
+	case "patch":
+		return color.New(color.FgGreen).SprintFunc()
+	case "":
+		return fmt.Sprint
+	default:
+		return color.New(color.FgCyan).SprintFunc()
+	}
+}
+
+func orDefault(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/lockfile"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/overrides"
+)
+
+var overrideCmd = &cobra.Command{
+	Use:     "override",
+	Aliases: []string{"overrides", "resolutions"},
+	Short:   "Force dependency versions across the whole tree",
+	Long: `Force the version of a package wherever it appears in the dependency
+tree, written where the package manager reads it:
+
+  npm    overrides in package.json
+  bun    overrides in package.json
+  yarn   resolutions in package.json
+  pnpm   overrides in pnpm-workspace.yaml, or pnpm.overrides in package.json
+
+Targets are "<pkg>", "<pkg>@<range>" to only replace matching versions, or
+"<parent>><pkg>" to only replace it below another package.`,
+}
+
+var overrideSetCmd = &cobra.Command{
+	Use:   "set <pkg>[@range] <version>",
+	Short: "Add or change an override",
+	Long: `Add or change an override and show which locked packages it applies to.
+
+Examples:
+  gnpm override set lodash 4.17.21               # Every lodash
+  gnpm override set "lodash@<4.17.21" 4.17.21    # Only vulnerable versions
+  gnpm override set "webpack>lodash" 4.17.21     # Only below webpack`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		o, err := overrides.ParseTarget(args[0])
+		if err != nil {
+			return err
+		}
+		o.Version = args[1]
+
+		projectCtx, store, err := openOverrides()
+		if err != nil {
+			return err
+		}
+		if err := store.Set(o); err != nil {
+			return err
+		}
+		if dryRun {
+			logger.DryRun(fmt.Sprintf("set override %s → %s", o, o.Version), store.Path)
+			printOverrideMatches(projectCtx, []overrides.Override{o})
+			return nil
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+
+		logger.Success("Set override %s → %s in %s", o, o.Version, store.Location())
+		printOverrideMatches(projectCtx, []overrides.Override{o})
+		logger.Info("Run `gnpm install` to apply it")
+		return nil
+	},
+}
+
+var overrideListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List overrides and the locked packages they apply to",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, store, err := openOverrides()
+		if err != nil {
+			return err
+		}
+
+		list := store.List()
+		if len(list) == 0 {
+			logger.Info("No overrides in %s", store.Location())
+			return nil
+		}
+
+		logger.Header(fmt.Sprintf("Overrides in %s", store.Location()))
+		printOverrideMatches(projectCtx, list)
+		return nil
+	},
+}
+
+var overrideRemoveCmd = &cobra.Command{
+	Use:     "remove <pkg>[@range]...",
+	Aliases: []string{"rm"},
+	Short:   "Remove overrides",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, store, err := openOverrides()
+		if err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			o, err := overrides.ParseTarget(arg)
+			if err != nil {
+				return err
+			}
+			removed, err := store.Remove(o)
+			if err != nil {
+				return err
+			}
+			if !removed {
+				return fmt.Errorf("no override for %s in %s", o, store.Location())
+			}
+			if dryRun {
+				logger.DryRun(fmt.Sprintf("remove override %s", o), store.Path)
+			} else {
+				logger.List(o.String())
+			}
+		}
+
+		if dryRun {
+			return nil
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		logger.Success("Removed %d override(s); run `gnpm install` to update the lockfile", len(args))
+		return nil
+	},
+}
+
+func init() {
+	overrideCmd.AddCommand(overrideSetCmd)
+	overrideCmd.AddCommand(overrideListCmd)
+	overrideCmd.AddCommand(overrideRemoveCmd)
+}
+
+// openOverrides opens the overrides of the workspace root, where package
+// managers read them
+func openOverrides() (*context.ProjectContext, *overrides.Store, error) {
+	projectCtx, err := workspaceContext()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := overrides.Open(projectCtx.RootDir, projectCtx.PackageManager)
+	if err != nil {
+		return nil, nil, err
+	}
+	return projectCtx, store, nil
+}
+
+// printOverrideMatches lists each override with the locked versions it
+// replaces
+func printOverrideMatches(projectCtx *context.ProjectContext, list []overrides.Override) {
+	lock, lockErr := lockfile.Read(projectCtx.RootDir, projectCtx.PackageManager)
+
+	for _, o := range list {
+		logger.List(fmt.Sprintf("%s → %s", o, o.Version))
+		if lockErr != nil {
+			continue
+		}
+		if o.Parent != "" {
+			logger.Dim("    only below %s", o.Parent)
+		}
+
+		matches := overrides.Explain(lock, o)
+		if len(matches) == 0 {
+			logger.Dim("    no matching %s in %s", o.Name, filepath.Base(lock.Path))
+			continue
+		}
+		for _, m := range matches {
+			entry := fmt.Sprintf("%s@%s", o.Name, m.Version)
+			if m.Count > 1 {
+				entry += fmt.Sprintf(" (%d entries)", m.Count)
+			}
+			if m.Forced {
+				logger.Dim("    %s already overridden", entry)
+			} else {
+				logger.Dim("    %s → %s", entry, o.Version)
+			}
+		}
+	}
+
+	if lockErr != nil {
+		logger.Dim("No lockfile read (%v); install to see which packages are affected", lockErr)
+	}
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/patch"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/runner"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+var patchCmd = &cobra.Command{
+	Use:   "patch <pkg>[@version]",
+	Short: "Prepare an installed package for patching",
+	Long: `Extract an installed package into a temporary directory to edit, then
+run "gnpm patch commit <dir>" to save the changes as a patch in patches/.
+
+pnpm, yarn and bun use their own patch commands. For npm and yarn classic,
+gnpm writes the patch itself and applies it from a postinstall script.
+
+Examples:
+  gnpm patch lodash                 # Patch the installed version
+  gnpm patch lodash@4.17.21         # Patch a specific version
+  gnpm patch commit /tmp/gnpm-patch-123/edit`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+
+		patchCommand := pmcombo.NewPatchCommand(pmcombo.PatchOptions{
+			Package: args[0],
+		})
+		cmdArgs, err := patchCommand.Concat(projectCtx.PackageManager)
+		if errors.Is(err, pmcombo.ErrNoNativePatch) {
+			return startPatch(projectCtx, args[0])
+		}
+		if err != nil {
+			return err
+		}
+		return runner.Run(projectCtx.PackageManager, cmdArgs, projectCtx.RootDir, runnerOpts())
+	},
+}
+
+var patchCommitCmd = &cobra.Command{
+	Use:   "commit <dir>",
+	Short: "Save the changes made in a patch directory",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+
+		commitCommand := pmcombo.NewPatchCommitCommand(pmcombo.PatchCommitOptions{
+			Dir: args[0],
+		})
+		cmdArgs, err := commitCommand.Concat(projectCtx.PackageManager)
+		if errors.Is(err, pmcombo.ErrNoNativePatch) {
+			return commitPatch(projectCtx, args[0])
+		}
+		if err != nil {
+			return err
+		}
+		return runner.Run(projectCtx.PackageManager, cmdArgs, projectCtx.RootDir, runnerOpts())
+	},
+}
+
+var patchApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Apply the patches in patches/ to node_modules",
+	Long: `Apply every patch in patches/ to the installed copies of its package.
+Copies that already carry a patch are left alone. This runs as a postinstall
+script for package managers without native patching.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectCtx, err := workspaceContext()
+		if err != nil {
+			return err
+		}
+		return applyPatches(projectCtx)
+	},
+}
+
+func init() {
+	patchCmd.AddCommand(patchCommitCmd)
+	patchCmd.AddCommand(patchApplyCmd)
+}
+
+// startPatch extracts the registry tarball of an installed package twice,
+// one copy to edit and one to diff against
+func startPatch(projectCtx *context.ProjectContext, spec string) error {
+	name, version := registry.SplitSpec(spec)
+	if version == "" {
+		workDir, err := getWorkingDir()
+		if err != nil {
+			return err
+		}
+		version = deps.InstalledVersion(workDir, projectCtx.RootDir, name)
+		if version == "" {
+			return fmt.Errorf("%s is not installed; run `gnpm install` or pass <pkg>@<version>", name)
+		}
+	}
+
+	client, err := newRegistryClient(projectCtx.RootDir)
+	if err != nil {
+		return err
+	}
+	manifest, err := client.Manifest(name, version)
+	if err != nil {
+		return err
+	}
+	tarball, err := client.Tarball(manifest.Dist)
+	if err != nil {
+		return err
+	}
+
+	session, err := patch.Start(projectCtx.RootDir, name, manifest.Version, tarball)
+	if err != nil {
+		return err
+	}
+	logger.Success("Extracted %s@%s for editing:", name, manifest.Version)
+	logger.Plainln(session.EditDir)
+	logger.Info("Edit the files, then run `gnpm patch commit %s`", session.EditDir)
+	return nil
+}
+
+// commitPatch saves the changes of a patch session, makes sure patches are
+// applied after every install and applies it to node_modules right away
+func commitPatch(projectCtx *context.ProjectContext, dir string) error {
+	session, err := patch.OpenSession(dir)
+	if err != nil {
+		return err
+	}
+	relPath, err := filepath.Rel(projectCtx.RootDir, session.PatchPath())
+	if err != nil {
+		relPath = session.PatchPath()
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if dryRun {
+		diff, err := patch.DiffDirs(session.Original, session.EditDir)
+		if err != nil {
+			return err
+		}
+		logger.Info("Would write %s:", relPath)
+		logger.Plain("%s", diff)
+		return nil
+	}
+
+	if _, err := session.Commit(); err != nil {
+		return err
+	}
+	logger.Success("Saved %s", relPath)
+
+	manifest, err := context.ReadManifest(filepath.Join(projectCtx.RootDir, "package.json"))
+	if err != nil {
+		return err
+	}
+	changed, err := patch.EnsurePostinstall(manifest)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := manifest.Write(); err != nil {
+			return err
+		}
+		logger.Info("Added `%s` to the postinstall script in package.json", patch.ApplyCommand)
+	}
+
+	return applyPatches(projectCtx)
+}
+
+// applyPatches applies every saved patch to the packages installed in the
+// workspace
+func applyPatches(projectCtx *context.ProjectContext) error {
+	var dirs []string
+	for _, importer := range workspace.Importers(projectCtx) {
+		dirs = append(dirs, importer.Dir)
+	}
+
+	if dryRun {
+		logger.DryRun(patch.ApplyCommand, projectCtx.RootDir)
+		return nil
+	}
+
+	results, err := patch.ApplyAll(projectCtx.RootDir, dirs)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		location := r.Dir
+		if rel, err := filepath.Rel(projectCtx.RootDir, r.Dir); err == nil {
+			location = filepath.ToSlash(rel)
+		}
+		switch {
+		case r.Err != nil:
+			logger.Error("%s: %v", r.File, r.Err)
+			failed++
+		case r.Dir == "":
+			logger.Warn("%s: %s@%s is not installed", r.File, r.Name, r.Version)
+		case r.Changed:
+			logger.Success("Patched %s", location)
+		default:
+			logger.Dim("%s is already patched", location)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d patch(es) failed to apply", failed)
+	}
+	return nil
+}
@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 
 	"github.com/AkaraChen/gnpm/internal/logger"
 	"github.com/AkaraChen/gnpm/internal/native"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/toolchain"
 )
 
 var registryGlobal bool
@@ -58,3 +62,18 @@ Examples:
 func init() {
 	registryCmd.Flags().BoolVarP(&registryGlobal, "global", "g", false, "Use global .npmrc")
 }
+
+// newRegistryClient builds a registry client from the .npmrc files that apply to dir
+func newRegistryClient(dir string) (*registry.Client, error) {
+	registryURL, err := native.GetRegistry(native.RegistryOptions{Dir: dir})
+	if err != nil {
+		return nil, err
+	}
+
+	return registry.New(registry.Options{
+		Registry: registryURL,
+		Config:   registry.Config(native.LoadNpmrc(dir)),
+		CacheDir: filepath.Join(toolchain.CacheDir(), "registry"),
+		Offline:  toolchain.Offline(),
+	}), nil
+}
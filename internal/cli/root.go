@@ -7,7 +7,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/AkaraChen/gnpm/internal/context"
-	"github.com/AkaraChen/gnpm/internal/logger"
 	"github.com/AkaraChen/gnpm/internal/native"
 	"github.com/AkaraChen/gnpm/internal/pmcombo"
 	"github.com/AkaraChen/gnpm/internal/runner"
@@ -46,6 +45,12 @@ Unknown commands are automatically resolved:
 			return nil
 		}
 
+		// Flags and args are valid by now, so later errors are about the
+		// command failing, such as a script's exit status: main reports
+		// those without the usage text
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		cwd, err := os.Getwd()
 		if err != nil {
 			return err
@@ -77,6 +82,9 @@ Unknown commands are automatically resolved:
 				return err
 			}
 			ctx.PackageManager = pm
+			if ctx.PackageManagerSpec != nil && ctx.PackageManagerSpec.Name != pm.Executable() {
+				ctx.PackageManagerSpec = nil
+			}
 		}
 
 		return nil
@@ -111,18 +119,27 @@ func runFallback(command string, args []string) error {
 		return err
 	}
 
+	env, err := dotenvVars(cwd)
+	if err != nil {
+		return err
+	}
+	shellName, err := scriptShell(cwd)
+	if err != nil {
+		return err
+	}
+
 	result, err := native.Fallback(native.FallbackOptions{
 		Dir:     cwd,
 		Command: command,
 		Args:    args,
 		Verbose: verbose,
 		DryRun:  dryRun,
+		Env:     env,
+		Shell:   shellName,
 	})
 
 	if result == native.FallbackNotFound {
-		logger.Error("unknown command %q", command)
-		logger.Plainln("\nRun 'gnpm --help' for usage.")
-		return nil
+		return fmt.Errorf("unknown command %q\nRun 'gnpm --help' for usage.", command)
 	}
 
 	return err
@@ -150,6 +167,13 @@ func init() {
 	rootCmd.AddCommand(useCmd)
 	rootCmd.AddCommand(registryCmd)
 	rootCmd.AddCommand(viewCmd)
+	rootCmd.AddCommand(outdatedCmd)
+	rootCmd.AddCommand(depsCmd)
+	rootCmd.AddCommand(catalogCmd)
+	rootCmd.AddCommand(overrideCmd)
+	rootCmd.AddCommand(patchCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(constraintsCmd)
 	rootCmd.AddCommand(scaffoldCmd)
 }
 
@@ -171,5 +195,6 @@ func runnerOpts() runner.Options {
 	return runner.Options{
 		Verbose: verbose,
 		DryRun:  dryRun,
+		Spec:    ctx.PackageManagerSpec,
 	}
 }
@@ -1,35 +1,228 @@
 package cli
 
 import (
+	"path/filepath"
+
 	"github.com/spf13/cobra"
 
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/graph"
+	"github.com/AkaraChen/gnpm/internal/logger"
 	"github.com/AkaraChen/gnpm/internal/native"
+	"github.com/AkaraChen/gnpm/internal/taskcache"
+	"github.com/AkaraChen/gnpm/internal/toolchain"
+)
+
+var (
+	runIgnoreScripts bool
+	runInteractive   bool
+	runNoCache       bool
+	runWatch         bool
 )
 
 var runCmd = &cobra.Command{
-	Use:     "run <script> [args...]",
+	Use:     "run [script] [args...]",
 	Aliases: []string{"r"},
 	Short:   "Run a script from package.json",
-	Long:  `Run a script defined in package.json.`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Run a script defined in package.json.
+
+Without a script, lists the scripts with their commands; at a workspace root
+the scripts of every workspace package are listed too. With -i, pick the
+script to run from a fuzzy finder.
+
+Like npm, pre<script> and post<script> run before and after the script when
+they exist. A failing pre hook stops the script from running. Skip the hooks
+with --ignore-scripts, or set ignore-scripts=true or
+enable-pre-post-scripts=false in .npmrc.
+
+Scripts listed under scripts.tasks in .gnpm/config.yaml are cached: when
+their inputs, env variables, the lockfile and the workspace packages they
+depend on are unchanged, the outputs are restored and the logs replayed
+from ~/.cache/gnpm/tasks instead of running the script. Skip the cache
+with --no-cache.
+
+With --watch, the script re-runs whenever a file in the package changes,
+leaving out files ignored by .gitignore (pick files with scripts.watch in
+.gnpm/config.yaml). A run still in progress is stopped first, along with
+everything it started. In a workspace, the same script then runs in the
+packages depending on this one. Watched scripts can't read from the
+terminal.
+
+Examples:
+  gnpm run                    # List scripts
+  gnpm run build              # Run the build script
+  gnpm run test -- --watch    # Pass arguments to the script
+  gnpm run build --watch      # Re-run on changes
+  gnpm run -i                 # Pick a script interactively`,
+	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		workDir, err := getWorkingDir()
 		if err != nil {
 			return err
 		}
 
-		script := args[0]
-		scriptArgs := []string{}
-		if len(args) > 1 {
-			scriptArgs = args[1:]
+		if runInteractive {
+			groups, err := native.ScriptGroups(workDir)
+			if err != nil {
+				return err
+			}
+			group, script, err := native.FuzzySelectScript(groups)
+			if err != nil || script == nil {
+				return err
+			}
+			return runScript(group.Dir, script.Name, args)
 		}
 
-		return native.Run(native.RunOptions{
-			Dir:     workDir,
-			Script:  script,
-			Args:    scriptArgs,
-			Verbose: verbose,
-			DryRun:  dryRun,
-		})
+		if len(args) == 0 {
+			groups, err := native.ScriptGroups(workDir)
+			if err != nil {
+				return err
+			}
+			for _, group := range groups {
+				if len(group.Scripts) > 0 {
+					native.PrintScripts(groups)
+					return nil
+				}
+			}
+			logger.Info("No scripts found in package.json")
+			return nil
+		}
+
+		return runScript(workDir, args[0], args[1:])
 	},
 }
+
+func init() {
+	runCmd.Flags().BoolVar(&runIgnoreScripts, "ignore-scripts", false, "Skip pre/post hooks")
+	runCmd.Flags().BoolVarP(&runInteractive, "interactive", "i", false, "Pick the script from a fuzzy finder")
+	runCmd.Flags().BoolVar(&runNoCache, "no-cache", false, "Run the script even when its result is cached")
+	runCmd.Flags().BoolVar(&runWatch, "watch", false, "Re-run the script when files change")
+}
+
+// runScript runs a package.json script in dir with the .env files and shell
+// that apply there, or keeps re-running it with --watch
+func runScript(dir string, script string, args []string) error {
+	opts, err := scriptRunOptions(dir, script, args)
+	if err != nil {
+		return err
+	}
+	if runWatch && !dryRun {
+		return watchScript(opts)
+	}
+	return native.Run(opts)
+}
+
+// scriptRunOptions returns the options a script in dir runs with
+func scriptRunOptions(dir string, script string, args []string) (native.RunOptions, error) {
+	env, err := dotenvVars(dir)
+	if err != nil {
+		return native.RunOptions{}, err
+	}
+	shellName, err := scriptShell(dir)
+	if err != nil {
+		return native.RunOptions{}, err
+	}
+	task, err := scriptTask(dir, script)
+	if err != nil {
+		return native.RunOptions{}, err
+	}
+
+	opts := native.RunOptions{
+		Dir:     dir,
+		Script:  script,
+		Args:    args,
+		Verbose: verbose,
+		DryRun:  dryRun,
+
+		IgnoreScripts: runIgnoreScripts,
+		Env:           env,
+		Shell:         shellName,
+	}
+	if task != nil && !runNoCache {
+		opts.Task = task
+		opts.Cache = &taskcache.Cache{Dir: filepath.Join(toolchain.CacheDir(), "tasks")}
+	}
+	return opts, nil
+}
+
+// watchScript re-runs a script whenever its package changes, followed by
+// the same script in the workspace packages depending on it
+func watchScript(opts native.RunOptions) error {
+	pkgDir, rootDir := scriptDirs(opts.Dir)
+	cfg, err := config.Load(rootDir)
+	if err != nil {
+		return err
+	}
+
+	watchOpts := native.WatchOptions{
+		Run:     opts,
+		Include: cfg.Scripts.Watch.Include,
+		Ignore:  cfg.Scripts.Watch.Ignore,
+	}
+	if g, name := packageGraph(pkgDir, rootDir); g != nil {
+		for _, dep := range g.Order(g.Dependents(name)) {
+			scripts, err := native.ListScripts(dep.Dir)
+			if err != nil {
+				return err
+			}
+			for _, s := range scripts {
+				if s.Name != opts.Script {
+					continue
+				}
+				depOpts, err := scriptRunOptions(dep.Dir, opts.Script, nil)
+				if err != nil {
+					return err
+				}
+				watchOpts.Dependents = append(watchOpts.Dependents, depOpts)
+			}
+		}
+	}
+	return native.Watch(watchOpts)
+}
+
+// scriptTask returns the cache settings of a script from scripts.tasks in
+// .gnpm/config.yaml, or nil when the script isn't cached
+func scriptTask(dir string, script string) (*taskcache.Task, error) {
+	pkgDir, rootDir := scriptDirs(dir)
+	cfg, err := config.Load(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	spec, ok := cfg.Scripts.Tasks[script]
+	if !ok {
+		return nil, nil
+	}
+
+	task := &taskcache.Task{
+		Name:    script,
+		Inputs:  spec.Inputs,
+		Outputs: spec.Outputs,
+		Env:     spec.Env,
+		Dir:     pkgDir,
+		RootDir: rootDir,
+	}
+	if g, name := packageGraph(pkgDir, rootDir); g != nil {
+		for _, dep := range g.Dependencies(name) {
+			task.Dependencies = append(task.Dependencies, dep.Dir)
+		}
+	}
+	return task, nil
+}
+
+// packageGraph returns the workspace graph and the name of the package in
+// pkgDir, or nil when pkgDir isn't a named workspace package
+func packageGraph(pkgDir string, rootDir string) (*graph.Graph, string) {
+	if pkgDir == rootDir {
+		return nil, ""
+	}
+	g, err := graph.Load(rootDir)
+	if err != nil {
+		return nil, ""
+	}
+	for _, p := range g.Packages {
+		if p.Dir == pkgDir {
+			return g, p.Name
+		}
+	}
+	return nil, ""
+}
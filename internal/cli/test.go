@@ -17,12 +17,23 @@ var testCmd = &cobra.Command{
 			return err
 		}
 
+		env, err := dotenvVars(workDir)
+		if err != nil {
+			return err
+		}
+		shellName, err := scriptShell(workDir)
+		if err != nil {
+			return err
+		}
+
 		return native.Run(native.RunOptions{
 			Dir:     workDir,
 			Script:  "test",
 			Args:    args,
 			Verbose: verbose,
 			DryRun:  dryRun,
+			Env:     env,
+			Shell:   shellName,
 		})
 	},
 }
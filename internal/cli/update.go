@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 
+	"github.com/AkaraChen/gnpm/internal/deps"
+	"github.com/AkaraChen/gnpm/internal/logger"
 	"github.com/AkaraChen/gnpm/internal/pmcombo"
 	"github.com/AkaraChen/gnpm/internal/runner"
 )
@@ -14,8 +18,24 @@ var updateCmd = &cobra.Command{
 	Use:     "update [packages...]",
 	Aliases: []string{"up", "upgrade"},
 	Short:   "Update packages",
-	Long:    `Update one or more packages to their latest versions.`,
+	Long: `Update one or more packages to their latest versions.
+
+With -i, picks upgrades interactively across the whole workspace: outdated
+dependencies are grouped by semver bump, each offering the wanted version
+(highest the range allows) and the latest one. Selected ranges are
+rewritten in package.json and a single install runs afterwards, the same
+way for every package manager.
+
+Examples:
+  gnpm update              # Update within declared ranges
+  gnpm update react        # Update react
+  gnpm update -i           # Pick upgrades interactively
+  gnpm update -i -L        # Only offer latest versions`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if updateInteractive {
+			return runInteractiveUpdate(args)
+		}
+
 		workDir, err := getWorkingDir()
 		if err != nil {
 			return err
@@ -24,9 +44,8 @@ var updateCmd = &cobra.Command{
 		runPackageManagerSecurityCheck()
 
 		updateCommand := pmcombo.NewUpdateCommand(pmcombo.UpdateOptions{
-			Packages:    args,
-			Interactive: updateInteractive,
-			Latest:      updateLatest,
+			Packages: args,
+			Latest:   updateLatest,
 		})
 
 		cmdArgs, err := updateCommand.Concat(ctx.PackageManager)
@@ -42,3 +61,58 @@ func init() {
 	updateCmd.Flags().BoolVarP(&updateInteractive, "interactive", "i", false, "Interactive mode")
 	updateCmd.Flags().BoolVarP(&updateLatest, "latest", "L", false, "Update to latest version (ignore semver)")
 }
+
+// runInteractiveUpdate lets the user pick upgrades, rewrites the manifests
+// and runs one install at the workspace root
+func runInteractiveUpdate(packages []string) error {
+	projectCtx, err := workspaceContext()
+	if err != nil {
+		return err
+	}
+
+	client, err := newRegistryClient(projectCtx.RootDir)
+	if err != nil {
+		return err
+	}
+
+	outdated := deps.FindOutdated(projectCtx, client, deps.Options{
+		Packages: packages,
+		Full:     true,
+		Verbose:  verbose,
+	})
+	targets := deps.Targets(outdated, updateLatest)
+	if len(targets) == 0 {
+		logger.Success("All dependencies are up to date")
+		return nil
+	}
+
+	selected, err := deps.SelectTargets(targets)
+	if err != nil {
+		return err
+	}
+	changes := deps.Plan(selected)
+	if len(changes) == 0 {
+		logger.Info("No upgrades selected")
+		return nil
+	}
+
+	for _, change := range changes {
+		logger.List(fmt.Sprintf("%s %s → %s (%s)", change.Name, change.From, change.To, change.RelPath))
+	}
+
+	if !dryRun {
+		if err := deps.Apply(changes); err != nil {
+			return err
+		}
+		logger.Success("Updated %d dependency range(s)", len(changes))
+	}
+
+	runPackageManagerSecurityCheck()
+
+	installCommand := pmcombo.NewInstallCommand(pmcombo.InstallOptions{})
+	cmdArgs, err := installCommand.Concat(projectCtx.PackageManager)
+	if err != nil {
+		return err
+	}
+	return runner.Run(projectCtx.PackageManager, cmdArgs, projectCtx.RootDir, runnerOpts())
+}
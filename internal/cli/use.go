@@ -3,34 +3,105 @@ package cli
 import (
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/native"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/toolchain"
 )
 
+var useFrom string
+var useRegistry string
+
 var useCmd = &cobra.Command{
-	Use:   "use <pm>[@version]",
-	Short: "Switch package manager version using corepack",
-	Long: `Switch package manager version using corepack.
+	Use:   "use [pm[@version]]",
+	Short: "Install a package manager version and pin it in package.json",
+	Long: `Install a package manager version into gnpm's cache (~/.cache/gnpm/pm)
+and record it in the packageManager field of package.json.
+
+The version can be exact, a major/minor prefix, or a dist-tag (default: latest).
+Without arguments, installs the version already pinned in package.json.
+
+Package managers are downloaded from --registry, then GNPM_PM_MIRROR, then
+the registry configured in .npmrc. File mirrors (file:///path) use the layout
+<mirror>/<package>/index.json and <mirror>/<package>/-/<file>.tgz.
 
 Examples:
-  gnpm use pnpm@8
-  gnpm use yarn@4
-  gnpm use npm@10`,
-	Args: cobra.ExactArgs(1),
+  gnpm use pnpm@9
+  gnpm use yarn@4.1.0
+  gnpm use npm@latest
+  gnpm use pnpm@9.1.0 --from ./pnpm-9.1.0.tgz
+  gnpm use                  # Install the pinned version`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pmSpec := args[0]
+		var spec context.PackageManagerSpec
+		if len(args) > 0 {
+			parsed, ok := context.ParsePackageManagerSpec(args[0])
+			if !ok {
+				return fmt.Errorf("invalid package manager %q", args[0])
+			}
+			spec = parsed
+		} else if ctx.PackageManagerSpec != nil {
+			spec = *ctx.PackageManagerSpec
+		} else {
+			return fmt.Errorf("no package manager specified and none pinned in package.json")
+		}
+
+		pkgPath := filepath.Join(ctx.RootDir, "package.json")
+		_, statErr := os.Stat(pkgPath)
+		hasPackageJSON := statErr == nil
+
+		if dryRun {
+			logger.DryRun(fmt.Sprintf("install %s", spec), toolchain.CacheDir())
+			if hasPackageJSON {
+				logger.DryRun(fmt.Sprintf("set packageManager=%s", spec), pkgPath)
+			}
+			return nil
+		}
+
+		registryURL, _ := native.GetRegistry(native.RegistryOptions{Dir: ctx.RootDir})
 
-		// Use corepack to enable the package manager
-		corepackCmd := exec.Command("corepack", "use", pmSpec)
-		corepackCmd.Stdout = os.Stdout
-		corepackCmd.Stderr = os.Stderr
-		corepackCmd.Stdin = os.Stdin
+		installation, err := toolchain.Install(spec.Name, spec.Version, toolchain.InstallOptions{
+			Mirror:   useRegistry,
+			Registry: registryURL,
+			Config:   registry.Config(native.LoadNpmrc(ctx.RootDir)),
+			Tarball:  useFrom,
+			Hash:     spec.Hash,
+		})
+		if err != nil {
+			return err
+		}
+
+		logger.Success("installed %s@%s", installation.Name, installation.Version)
+		if verbose {
+			logger.Dim(installation.Dir)
+		}
 
-		if err := corepackCmd.Run(); err != nil {
-			return fmt.Errorf("corepack use failed: %w", err)
+		if !hasPackageJSON {
+			return nil
 		}
 
+		manifest, err := context.ReadManifest(pkgPath)
+		if err != nil {
+			return err
+		}
+		if err := manifest.Set([]string{"packageManager"}, installation.Spec()); err != nil {
+			return err
+		}
+		if err := manifest.Write(); err != nil {
+			return err
+		}
+
+		logger.Success("packageManager set to %s@%s", installation.Name, installation.Version)
 		return nil
 	},
 }
+
+func init() {
+	useCmd.Flags().StringVar(&useFrom, "from", "", "Import from a local tarball instead of downloading")
+	useCmd.Flags().StringVar(&useRegistry, "registry", "", "Registry or mirror to download from (http(s):// or file://)")
+}
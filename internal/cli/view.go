@@ -1,53 +1,354 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"runtime"
+	"sort"
+	"strings"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/registry"
 )
 
-var viewRepo bool
+var (
+	viewRepo bool
+	viewWeb  bool
+	viewJSON bool
+)
 
 var viewCmd = &cobra.Command{
-	Use:     "view [package]",
+	Use:     "view [package[@version|range|tag]] [field...]",
 	Aliases: []string{"v", "info", "show"},
-	Short:   "Open package on npm or repository",
-	Long: `Open package page on npm registry or its repository.
+	Short:   "Show package metadata from the registry",
+	Long: `Show package metadata from the registry: dist-tags, matching versions,
+publish times, dependencies, maintainers, tarball size, deprecation notice
+and repository. Uses the registry and credentials from .npmrc, so it works
+the same for every package manager and behind private registries.
 
-Without a package name, opens the current package.
-With -r flag, opens the repository instead of npm.
+Without a package name, shows the current package.
+Fields are dotted paths into the metadata, like npm view.
+With --web, opens the npm page; with -r, opens the repository.
 
 Examples:
-  gnpm view lodash      # Open lodash on npm
-  gnpm view lodash -r   # Open lodash repository
-  gnpm view             # Open current package on npm`,
+  gnpm view lodash                  # Show lodash metadata
+  gnpm view react@^17 version       # Versions matching a range
+  gnpm view lodash dist.tarball     # A single field
+  gnpm view lodash versions --json  # A field as JSON
+  gnpm view lodash -r               # Open lodash repository
+  gnpm view lodash --web            # Open lodash on npm`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var pkgName string
-
+		var name, spec string
 		if len(args) > 0 {
-			pkgName = args[0]
+			name, spec = registry.SplitSpec(args[0])
 		} else if ctx.PackageJSON != nil && ctx.PackageJSON.Name != "" {
-			pkgName = ctx.PackageJSON.Name
+			name = ctx.PackageJSON.Name
 		} else {
 			return fmt.Errorf("no package specified and no package.json found")
 		}
 
-		var url string
+		var fieldPaths []string
+		if len(args) > 1 {
+			fieldPaths = args[1:]
+		}
+
+		if viewWeb && !viewRepo {
+			return openBrowser("https://www.npmjs.com/package/" + name)
+		}
+
+		workDir, err := getWorkingDir()
+		if err != nil {
+			return err
+		}
+		client, err := newRegistryClient(workDir)
+		if err != nil {
+			return err
+		}
+
+		packument, err := client.Packument(name)
+		if err != nil {
+			if registry.IsNotFound(err) {
+				return fmt.Errorf("package %s not found in %s", name, client.RegistryFor(name))
+			}
+			return err
+		}
+
 		if viewRepo {
-			// Try to get repo URL from npm
-			url = fmt.Sprintf("https://www.npmjs.com/package/%s", pkgName)
-		} else {
-			url = fmt.Sprintf("https://www.npmjs.com/package/%s", pkgName)
+			manifest, err := packument.Resolve(spec)
+			if err != nil {
+				return err
+			}
+			repoURL := registry.RepositoryURL(manifest.Repository)
+			if repoURL == "" {
+				repoURL = registry.RepositoryURL(packument.Repository)
+			}
+			if repoURL == "" {
+				return fmt.Errorf("%s has no repository url", name)
+			}
+			if dryRun {
+				logger.Plainln(repoURL)
+				return nil
+			}
+			return openBrowser(repoURL)
+		}
+
+		matches, err := packument.Matches(spec)
+		if err != nil {
+			return err
+		}
+
+		if len(fieldPaths) > 0 {
+			return printViewFields(packument, matches, fieldPaths)
 		}
 
-		return openBrowser(url)
+		latest := matches[len(matches)-1]
+		if viewJSON {
+			if len(matches) == 1 {
+				return printViewJSON(packument.Fields(latest))
+			}
+			docs := make([]map[string]interface{}, 0, len(matches))
+			for _, manifest := range matches {
+				docs = append(docs, packument.Fields(manifest))
+			}
+			return printViewJSON(docs)
+		}
+
+		printViewSummary(packument, latest, matches, spec)
+		return nil
 	},
 }
 
 func init() {
-	viewCmd.Flags().BoolVarP(&viewRepo, "repo", "r", false, "Open repository instead of npm page")
+	viewCmd.Flags().BoolVarP(&viewRepo, "repo", "r", false, "Open the package repository")
+	viewCmd.Flags().BoolVar(&viewWeb, "web", false, "Open the package page on npm")
+	viewCmd.Flags().BoolVar(&viewJSON, "json", false, "Output JSON")
+}
+
+// printViewFields prints selected fields. Like npm view, a single version
+// prints bare values; several versions print one "name@version value" line
+// per version.
+func printViewFields(packument *registry.Packument, matches []*registry.Manifest, paths []string) error {
+	type result struct {
+		manifest *registry.Manifest
+		values   map[string]interface{}
+	}
+
+	var results []result
+	for _, manifest := range matches {
+		fields := packument.Fields(manifest)
+		values := map[string]interface{}{}
+		for _, path := range paths {
+			if value, ok := registry.SelectField(fields, path); ok {
+				values[path] = value
+			}
+		}
+		if len(values) > 0 {
+			results = append(results, result{manifest, values})
+		}
+	}
+
+	if viewJSON {
+		var docs []interface{}
+		for _, r := range results {
+			if len(paths) == 1 {
+				docs = append(docs, r.values[paths[0]])
+			} else {
+				docs = append(docs, r.values)
+			}
+		}
+		if len(docs) == 1 {
+			return printViewJSON(docs[0])
+		}
+		return printViewJSON(docs)
+	}
+
+	for _, r := range results {
+		prefix := ""
+		if len(matches) > 1 {
+			prefix = r.manifest.Name + "@" + r.manifest.Version + " "
+		}
+		for _, path := range paths {
+			value, ok := r.values[path]
+			if !ok {
+				continue
+			}
+			label := prefix
+			if len(paths) > 1 {
+				label += path + " = "
+			}
+			logger.Plainln("%s%s", label, formatViewValue(value, len(results) > 1 || len(paths) > 1))
+		}
+	}
+	return nil
+}
+
+// formatViewValue prints strings bare (quoted when mixed with other output)
+// and everything else as indented JSON
+func formatViewValue(value interface{}, quote bool) string {
+	if s, ok := value.(string); ok {
+		if quote {
+			return fmt.Sprintf("'%s'", s)
+		}
+		return s
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func printViewJSON(value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	logger.Plainln(string(data))
+	return nil
+}
+
+func printViewSummary(packument *registry.Packument, manifest *registry.Manifest, matches []*registry.Manifest, spec string) {
+	bold := color.New(color.Bold).SprintFunc()
+	dim := color.New(color.FgHiBlack).SprintFunc()
+	cyan := color.New(color.FgCyan).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	license := string(manifest.License)
+	if license == "" {
+		license = string(packument.License)
+	}
+	if license == "" {
+		license = "Proprietary"
+	}
+
+	header := fmt.Sprintf("%s@%s | %s | deps: %s | versions: %d",
+		bold(packument.Name), bold(manifest.Version), license,
+		countLabel(len(manifest.Dependencies)), len(packument.Versions))
+	logger.Plainln(header)
+
+	description := manifest.Description
+	if description == "" {
+		description = packument.Description
+	}
+	if description != "" {
+		logger.Plainln(description)
+	}
+	homepage := manifest.Homepage
+	if homepage == "" {
+		homepage = packument.Homepage
+	}
+	if homepage != "" {
+		logger.Plainln(cyan(homepage))
+	}
+
+	if manifest.Deprecated != "" {
+		logger.Plainln("")
+		logger.Plainln("%s %s", red(bold("DEPRECATED")), manifest.Deprecated)
+	}
+
+	repository := manifest.Repository
+	if repository.URL == "" {
+		repository = packument.Repository
+	}
+	if repoURL := registry.RepositoryURL(repository); repoURL != "" {
+		logger.Plainln("")
+		logger.Plainln("%s %s", bold("repository:"), cyan(repoURL))
+	}
+
+	logger.Plainln("")
+	logger.Plainln(bold("dist"))
+	logger.Plainln(".tarball: %s", cyan(manifest.Dist.Tarball))
+	if manifest.Dist.Shasum != "" {
+		logger.Plainln(".shasum: %s", manifest.Dist.Shasum)
+	}
+	if manifest.Dist.Integrity != "" {
+		logger.Plainln(".integrity: %s", manifest.Dist.Integrity)
+	}
+	if manifest.Dist.UnpackedSize > 0 {
+		logger.Plainln(".unpackedSize: %s", formatBytes(manifest.Dist.UnpackedSize))
+	}
+
+	if len(manifest.Dependencies) > 0 {
+		logger.Plainln("")
+		logger.Plainln(bold("dependencies:"))
+		for _, name := range sortedKeys(manifest.Dependencies) {
+			logger.Plainln("%s: %s", name, manifest.Dependencies[name])
+		}
+	}
+
+	maintainers := manifest.Maintainers
+	if len(maintainers) == 0 {
+		maintainers = packument.Maintainers
+	}
+	if len(maintainers) > 0 {
+		logger.Plainln("")
+		logger.Plainln(bold("maintainers:"))
+		for _, person := range maintainers {
+			logger.Plainln("- %s", person.String())
+		}
+	}
+
+	if len(packument.DistTags) > 0 {
+		logger.Plainln("")
+		logger.Plainln(bold("dist-tags:"))
+		for _, tag := range sortedKeys(packument.DistTags) {
+			logger.Plainln("%s: %s", tag, packument.DistTags[tag])
+		}
+	}
+
+	if len(matches) > 1 {
+		logger.Plainln("")
+		logger.Plainln(bold(fmt.Sprintf("versions matching %s:", spec)))
+		for _, match := range matches {
+			line := match.Version
+			if published, ok := packument.Time[match.Version]; ok {
+				line += " " + dim(published.Format("2006-01-02"))
+			}
+			if match.Deprecated != "" {
+				line += " " + red("deprecated")
+			}
+			logger.Plainln(line)
+		}
+	}
+
+	if published, ok := packument.Time[manifest.Version]; ok {
+		logger.Plainln("")
+		logger.Plainln("published %s", dim(published.Format("2006-01-02 15:04 MST")))
+	}
+}
+
+func countLabel(n int) string {
+	if n == 0 {
+		return "none"
+	}
+	return fmt.Sprint(n)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatBytes(size int64) string {
+	units := []string{"B", "kB", "MB", "GB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[0])
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + " " + units[unit]
 }
 
 func openBrowser(url string) error {
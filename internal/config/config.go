@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dir is the directory holding gnpm's project configuration
+const Dir = ".gnpm"
+
+// fileNames are the accepted config file names inside Dir, in lookup order
+var fileNames = []string{"config.yaml", "config.yml"}
+
+// Config is the project configuration in .gnpm/config.yaml at the
+// workspace root
+type Config struct {
+	Path string `yaml:"-"` // file the config was read from, empty when none exists
+
+	Deps    Deps    `yaml:"deps"`
+	Env     *Env    `yaml:"env"` // nil unless the config has an env block
+	Scripts Scripts `yaml:"scripts"`
+
+	// Catalog is the default catalog: package names to version ranges
+	// referenced with `catalog:`
+	Catalog map[string]string `yaml:"catalog"`
+	// Catalogs are named catalogs referenced with `catalog:<name>`
+	Catalogs map[string]map[string]string `yaml:"catalogs"`
+}
+
+// Deps configures the `gnpm deps` commands
+type Deps struct {
+	// Policy picks the range mismatched dependencies are synced to:
+	// highest, most-common or pinned
+	Policy string `yaml:"policy"`
+	// Pins force a range for a dependency regardless of the policy
+	Pins map[string]string `yaml:"pins"`
+	// Ignore lists dependency names (globs such as "@types/*") every deps
+	// command leaves alone
+	Ignore []string `yaml:"ignore"`
+}
+
+// Env configures the .env files loaded for scripts and binaries. Loading
+// is opt-in: without an env block, no .env file is read.
+type Env struct {
+	// Files replaces the default .env files; paths are relative to the
+	// workspace root
+	Files []string `yaml:"files"`
+	// Mode picks the .env.<mode> files; defaults to NODE_ENV, and they
+	// are skipped when neither is set
+	Mode string `yaml:"mode"`
+	// Disable turns off .env loading
+	Disable bool `yaml:"disable"`
+}
+
+// Scripts configures how `gnpm run` executes package.json scripts
+type Scripts struct {
+	// Shell runs scripts with "system" (sh, or cmd.exe on Windows; the
+	// default) or "builtin", gnpm's portable shell
+	Shell string `yaml:"shell"`
+	// Tasks turns on result caching for the scripts they name
+	Tasks map[string]Task `yaml:"tasks"`
+	// Watch picks the files `gnpm run --watch` watches
+	Watch Watch `yaml:"watch"`
+}
+
+// Watch configures the files `gnpm run --watch` re-runs scripts for. Files
+// ignored by .gitignore are never watched.
+type Watch struct {
+	// Include are globs of the watched files, relative to the package;
+	// every file by default
+	Include []string `yaml:"include"`
+	// Ignore are globs of files not to watch
+	Ignore []string `yaml:"ignore"`
+}
+
+// Task declares what a script's result depends on, so `gnpm run` can
+// restore it from the cache instead of running the script again
+type Task struct {
+	// Inputs are globs of the files the script reads, relative to the
+	// package; every file by default. A ! prefix excludes files.
+	Inputs []string `yaml:"inputs"`
+	// Outputs are globs of the files the script writes, restored on a
+	// cache hit
+	Outputs []string `yaml:"outputs"`
+	// Env names the environment variables the script's result depends on
+	Env []string `yaml:"env"`
+}
+
+// Load reads the config from rootDir. A missing file is not an error and
+// yields an empty config.
+func Load(rootDir string) (*Config, error) {
+	for _, name := range fileNames {
+		path := filepath.Join(rootDir, Dir, name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := &Config{}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		cfg.Path = path
+		return cfg, nil
+	}
+	return &Config{}, nil
+}
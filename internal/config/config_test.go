@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	rootDir := t.TempDir()
+	writeConfig(t, rootDir, "config.yaml", `deps:
+  policy: most-common
+  pins:
+    react: ^18.3.1
+  ignore:
+    - "@types/*"
+catalog:
+  react: ^18.3.1
+catalogs:
+  legacy:
+    react: ^17.0.2
+env:
+  files: [.env, .env.shared]
+  mode: staging
+scripts:
+  shell: builtin
+  tasks:
+    build:
+      inputs: [src/**, tsconfig.json]
+      outputs: [dist/**]
+      env: [NODE_ENV]
+  watch:
+    include: [src/**]
+    ignore: ["**/*.test.ts"]
+`)
+
+	cfg, err := Load(rootDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Path != filepath.Join(rootDir, ".gnpm", "config.yaml") {
+		t.Errorf("Path = %q", cfg.Path)
+	}
+	if cfg.Deps.Policy != "most-common" || cfg.Deps.Pins["react"] != "^18.3.1" || len(cfg.Deps.Ignore) != 1 {
+		t.Errorf("Deps = %+v", cfg.Deps)
+	}
+	if cfg.Catalog["react"] != "^18.3.1" || cfg.Catalogs["legacy"]["react"] != "^17.0.2" {
+		t.Errorf("Catalog = %v, Catalogs = %v", cfg.Catalog, cfg.Catalogs)
+	}
+	if cfg.Env == nil || len(cfg.Env.Files) != 2 || cfg.Env.Mode != "staging" || cfg.Env.Disable {
+		t.Errorf("Env = %+v", cfg.Env)
+	}
+	if build := cfg.Scripts.Tasks["build"]; cfg.Scripts.Shell != "builtin" || len(build.Inputs) != 2 || build.Outputs[0] != "dist/**" || build.Env[0] != "NODE_ENV" || cfg.Scripts.Watch.Include[0] != "src/**" || len(cfg.Scripts.Watch.Ignore) != 1 {
+		t.Errorf("Scripts = %+v", cfg.Scripts)
+	}
+}
+
+func TestLoadYml(t *testing.T) {
+	rootDir := t.TempDir()
+	writeConfig(t, rootDir, "config.yml", "deps:\n  policy: pinned\n")
+
+	cfg, err := Load(rootDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Deps.Policy != "pinned" {
+		t.Errorf("Policy = %q, want pinned", cfg.Deps.Policy)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	cfg, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Path != "" || cfg.Deps.Policy != "" || cfg.Env != nil {
+		t.Errorf("expected an empty config, got %+v", cfg)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	rootDir := t.TempDir()
+	writeConfig(t, rootDir, "config.yaml", "deps: [")
+
+	if _, err := Load(rootDir); err == nil || !strings.Contains(err.Error(), "config.yaml") {
+		t.Fatalf("Load error = %v, want a parse error naming the file", err)
+	}
+}
+
+func writeConfig(t *testing.T, rootDir string, name string, content string) {
+	t.Helper()
+
+	dir := filepath.Join(rootDir, Dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("create %s: %v", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
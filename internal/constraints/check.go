@@ -0,0 +1,182 @@
+package constraints
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// Violation is a workspace manifest breaking a rule
+type Violation struct {
+	Package  string // package name, or its directory when unnamed
+	Manifest string // absolute package.json path
+	RelPath  string // package.json path relative to the root
+	Rule     Rule
+	Detail   string // what was found, e.g. `found "ISC"` or "missing"
+
+	fix *fix
+}
+
+// fix is the manifest edit resolving a violation
+type fix struct {
+	keys   []string
+	value  interface{}
+	remove bool
+}
+
+// Fixable reports whether Fix can resolve the violation
+func (v Violation) Fixable() bool {
+	return v.fix != nil
+}
+
+// Check evaluates the rules against every workspace package, or the root
+// package when the project has no workspaces
+func Check(rootDir string, rules []Rule) ([]Violation, error) {
+	packages, err := workspace.FindPackages(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		packages = []workspace.Package{{Path: filepath.Join(rootDir, "package.json"), Dir: rootDir}}
+	}
+
+	internal := map[string]bool{}
+	for _, pkg := range packages {
+		if pkg.Name != "" {
+			internal[pkg.Name] = true
+		}
+	}
+
+	var violations []Violation
+	for _, pkg := range packages {
+		manifest, err := context.ReadManifest(pkg.Path)
+		if err != nil {
+			return nil, err
+		}
+		relPath, err := filepath.Rel(rootDir, pkg.Path)
+		if err != nil {
+			relPath = pkg.Path
+		}
+		name, _ := manifest.GetString("name")
+		label := name
+		if label == "" {
+			label = filepath.ToSlash(filepath.Dir(relPath))
+		}
+
+		for _, rule := range rules {
+			if !rule.appliesTo(name) {
+				continue
+			}
+			for _, v := range rule.check(manifest, internal) {
+				v.Package, v.Manifest, v.RelPath = label, pkg.Path, filepath.ToSlash(relPath)
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, nil
+}
+
+// check evaluates one rule against one manifest
+func (r Rule) check(manifest *context.Manifest, internal map[string]bool) []Violation {
+	violation := func(detail string, f *fix) Violation {
+		return Violation{Rule: r, Detail: detail, fix: f}
+	}
+
+	if r.Field != "" {
+		keys := splitField(r.Field)
+		raw, exists := manifest.Get(keys...)
+		var value interface{}
+		if exists {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return []Violation{violation("invalid JSON", nil)}
+			}
+		}
+
+		switch {
+		case r.Forbidden:
+			if exists {
+				return []Violation{violation("found "+formatValue(value), &fix{keys: keys, remove: true})}
+			}
+		case !exists:
+			var f *fix
+			if r.Value != nil {
+				f = &fix{keys: keys, value: r.value}
+			}
+			return []Violation{violation("missing", f)}
+		case r.Value != nil:
+			if !reflect.DeepEqual(value, r.value) {
+				return []Violation{violation("found "+formatValue(value), &fix{keys: keys, value: r.value})}
+			}
+		case r.pattern != nil:
+			if s, ok := value.(string); !ok || !r.pattern.MatchString(s) {
+				return []Violation{violation("found "+formatValue(value), nil)}
+			}
+		}
+		return nil
+	}
+
+	var violations []Violation
+	for _, depType := range r.types() {
+		for _, dep := range manifest.Keys(depType) {
+			spec, _ := manifest.GetString(depType, dep)
+			keys := []string{depType, dep}
+			found := fmt.Sprintf("found %s %s in %s", dep, spec, depType)
+
+			switch {
+			case r.Dependency != "" && matchAny([]string{r.Dependency}, dep):
+				if r.Forbidden {
+					violations = append(violations, violation(found, &fix{keys: keys, remove: true}))
+				} else if spec != r.Range {
+					violations = append(violations, violation(found, &fix{keys: keys, value: r.Range}))
+				}
+			case r.Internal != "" && internal[dep] && spec != r.Internal:
+				violations = append(violations, violation(found, &fix{keys: keys, value: r.Internal}))
+			}
+		}
+	}
+	return violations
+}
+
+// Fix applies the fixes of the violations that have one, writing each
+// manifest once. It returns the number of violations fixed.
+func Fix(violations []Violation) (int, error) {
+	byManifest := map[string][]Violation{}
+	var paths []string
+	for _, v := range violations {
+		if !v.Fixable() {
+			continue
+		}
+		if _, ok := byManifest[v.Manifest]; !ok {
+			paths = append(paths, v.Manifest)
+		}
+		byManifest[v.Manifest] = append(byManifest[v.Manifest], v)
+	}
+
+	fixed := 0
+	for _, path := range paths {
+		manifest, err := context.ReadManifest(path)
+		if err != nil {
+			return fixed, err
+		}
+		for _, v := range byManifest[path] {
+			if v.fix.remove {
+				manifest.Delete(v.fix.keys...)
+				// Drop dependency fields left empty
+				if parent := v.fix.keys[:len(v.fix.keys)-1]; len(parent) > 0 && contains(workspace.DependencyTypes, parent[0]) && len(manifest.Keys(parent...)) == 0 {
+					manifest.Delete(parent...)
+				}
+			} else if err := manifest.Set(v.fix.keys, v.fix.value); err != nil {
+				return fixed, err
+			}
+		}
+		if err := manifest.Write(); err != nil {
+			return fixed, err
+		}
+		fixed += len(byManifest[path])
+	}
+	return fixed, nil
+}
@@ -0,0 +1,205 @@
+package constraints
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/AkaraChen/gnpm/internal/config"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// fileNames are the accepted constraints file names inside config.Dir
+var fileNames = []string{"constraints.yaml", "constraints.yml"}
+
+// File is the parsed .gnpm/constraints.yaml
+type File struct {
+	Path  string `yaml:"-"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is one constraint on workspace manifests. Exactly one of Field,
+// Dependency or Internal selects what it checks:
+//
+//   - Field: a dot-separated manifest path that must exist, equal Value,
+//     match Pattern, or be absent when Forbidden is set
+//   - Dependency: a dependency name or glob that must use Range, or must not
+//     be declared at all when Forbidden is set
+//   - Internal: the specifier dependencies on other workspace packages must
+//     use, such as "workspace:^"
+type Rule struct {
+	Field      string      `yaml:"field"`
+	Dependency string      `yaml:"dependency"`
+	Internal   string      `yaml:"internal"`
+	Value      interface{} `yaml:"value"`
+	Pattern    string      `yaml:"pattern"`
+	Range      string      `yaml:"range"`
+	Forbidden  bool        `yaml:"forbidden"`
+
+	Types    []string `yaml:"types"`    // dependency fields to check; all by default
+	Packages []string `yaml:"packages"` // package names or globs the rule applies to; all by default
+	Exclude  []string `yaml:"exclude"`  // package names or globs the rule skips
+	Message  string   `yaml:"message"`  // explanation shown with violations
+
+	pattern *regexp.Regexp
+	value   interface{} // Value normalized to what JSON decoding yields
+}
+
+// Load reads the constraints of the workspace at rootDir. A missing file
+// yields no rules.
+func Load(rootDir string) (*File, error) {
+	for _, name := range fileNames {
+		filePath := filepath.Join(rootDir, config.Dir, name)
+		data, err := os.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		file := &File{}
+		if err := yaml.Unmarshal(data, file); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", filePath, err)
+		}
+		file.Path = filePath
+		for i := range file.Rules {
+			if err := file.Rules[i].compile(); err != nil {
+				return nil, fmt.Errorf("%s: rule %d: %w", filePath, i+1, err)
+			}
+		}
+		return file, nil
+	}
+	return &File{}, nil
+}
+
+// compile validates the rule and prepares its pattern and value
+func (r *Rule) compile() error {
+	selectors := 0
+	for _, s := range []string{r.Field, r.Dependency, r.Internal} {
+		if s != "" {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		return fmt.Errorf("set exactly one of field, dependency or internal")
+	}
+
+	switch {
+	case r.Field != "":
+		checks := 0
+		for _, set := range []bool{r.Value != nil, r.Pattern != "", r.Forbidden} {
+			if set {
+				checks++
+			}
+		}
+		if checks > 1 {
+			return fmt.Errorf("field %s: use only one of value, pattern or forbidden", r.Field)
+		}
+	case r.Dependency != "":
+		if (r.Range == "") == !r.Forbidden {
+			return fmt.Errorf("dependency %s: set either range or forbidden", r.Dependency)
+		}
+	}
+
+	for _, depType := range r.Types {
+		if !contains(workspace.DependencyTypes, depType) {
+			return fmt.Errorf("unknown dependency type %q", depType)
+		}
+	}
+
+	if r.Pattern != "" {
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", r.Field, err)
+		}
+		r.pattern = pattern
+	}
+
+	if r.Value != nil {
+		// Round-trip through JSON so numbers and maps compare equal to
+		// values decoded from package.json
+		data, err := json.Marshal(r.Value)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", r.Field, err)
+		}
+		if err := json.Unmarshal(data, &r.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String describes the rule for reports
+func (r Rule) String() string {
+	switch {
+	case r.Field != "" && r.Forbidden:
+		return fmt.Sprintf("%s must not be set", r.Field)
+	case r.Field != "" && r.Value != nil:
+		return fmt.Sprintf("%s must be %s", r.Field, formatValue(r.value))
+	case r.Field != "" && r.Pattern != "":
+		return fmt.Sprintf("%s must match %s", r.Field, r.Pattern)
+	case r.Field != "":
+		return fmt.Sprintf("%s is required", r.Field)
+	case r.Dependency != "" && r.Forbidden:
+		return fmt.Sprintf("%s must not be a dependency", r.Dependency)
+	case r.Dependency != "":
+		return fmt.Sprintf("%s must use %s", r.Dependency, r.Range)
+	default:
+		return fmt.Sprintf("workspace dependencies must use %s", r.Internal)
+	}
+}
+
+// appliesTo reports whether the rule covers the named package
+func (r Rule) appliesTo(name string) bool {
+	if len(r.Packages) > 0 && !matchAny(r.Packages, name) {
+		return false
+	}
+	return !matchAny(r.Exclude, name)
+}
+
+// types returns the dependency fields the rule checks
+func (r Rule) types() []string {
+	if len(r.Types) > 0 {
+		return r.Types
+	}
+	return workspace.DependencyTypes
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched || pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue renders a decoded JSON value compactly
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// splitField splits a dot-separated field path
+func splitField(field string) []string {
+	return strings.Split(field, ".")
+}
@@ -0,0 +1,186 @@
+package constraints
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const rulesFixture = `rules:
+  - field: license
+    value: MIT
+  - field: scripts.test
+  - field: repository.url
+    pattern: ^https://github.com/acme/
+    packages: ["@acme/*"]
+  - field: publishConfig
+    forbidden: true
+    exclude: ["@acme/public"]
+  - dependency: lodash
+    forbidden: true
+    message: Use lodash-es instead
+  - dependency: react
+    range: ^18.3.1
+    types: [dependencies]
+  - internal: workspace:^
+`
+
+func TestLoad(t *testing.T) {
+	rootDir := writeFiles(t, map[string]string{".gnpm/constraints.yaml": rulesFixture})
+	file, err := Load(rootDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(file.Rules) != 7 || file.Path != filepath.Join(rootDir, ".gnpm", "constraints.yaml") {
+		t.Fatalf("Load = %+v", file)
+	}
+
+	want := []string{
+		`license must be "MIT"`,
+		"scripts.test is required",
+		"repository.url must match ^https://github.com/acme/",
+		"publishConfig must not be set",
+		"lodash must not be a dependency",
+		"react must use ^18.3.1",
+		"workspace dependencies must use workspace:^",
+	}
+	for i, rule := range file.Rules {
+		if rule.String() != want[i] {
+			t.Errorf("rule %d = %q, want %q", i+1, rule.String(), want[i])
+		}
+	}
+
+	empty, err := Load(t.TempDir())
+	if err != nil || len(empty.Rules) != 0 || empty.Path != "" {
+		t.Errorf("Load without a file = %+v, %v", empty, err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for _, rules := range []string{
+		"rules:\n  - field: a\n    dependency: b\n",
+		"rules:\n  - value: MIT\n",
+		"rules:\n  - field: a\n    value: x\n    forbidden: true\n",
+		"rules:\n  - dependency: react\n",
+		"rules:\n  - field: a\n    pattern: \"(\"\n",
+		"rules:\n  - internal: workspace:^\n    types: [deps]\n",
+	} {
+		rootDir := writeFiles(t, map[string]string{".gnpm/constraints.yaml": rules})
+		if _, err := Load(rootDir); err == nil {
+			t.Errorf("Load(%q) should fail", rules)
+		}
+	}
+}
+
+func TestCheckAndFix(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      []string
+		fixed     int
+		manifests map[string]string // package.json files after Fix
+		remaining int
+	}{
+		{
+			name: "workspace",
+			files: map[string]string{
+				"package.json":                 `{"name": "root", "private": true, "workspaces": ["packages/*"]}`,
+				".gnpm/constraints.yaml":       rulesFixture,
+				"packages/ui/package.json":     `{"name": "@acme/ui", "license": "MIT", "scripts": {"test": "vitest"}, "repository": {"url": "https://github.com/acme/ui"}, "dependencies": {"react": "^18.3.1"}}`,
+				"packages/web/package.json":    `{"name": "@acme/web", "license": "ISC", "repository": {"url": "https://gitlab.com/web"}, "publishConfig": {"access": "public"}, "dependencies": {"@acme/ui": "*", "lodash": "^4.17.21", "react": "^18.2.0"}, "devDependencies": {"react": "^18.0.0"}}`,
+				"packages/public/package.json": `{"name": "@acme/public", "license": "MIT", "scripts": {"test": "vitest"}, "repository": {"url": "https://github.com/acme/public"}, "publishConfig": {"access": "public"}, "peerDependencies": {"@acme/ui": "workspace:^"}}`,
+			},
+			want: []string{
+				"@acme/web: license must be \"MIT\" (found \"ISC\")",
+				"@acme/web: scripts.test is required (missing)",
+				"@acme/web: repository.url must match ^https://github.com/acme/ (found \"https://gitlab.com/web\")",
+				"@acme/web: publishConfig must not be set (found {\"access\":\"public\"})",
+				"@acme/web: lodash must not be a dependency (found lodash ^4.17.21 in dependencies)",
+				"@acme/web: react must use ^18.3.1 (found react ^18.2.0 in dependencies)",
+				"@acme/web: workspace dependencies must use workspace:^ (found @acme/ui * in dependencies)",
+			},
+			fixed: 5,
+			manifests: map[string]string{"packages/web/package.json": `{
+  "name": "@acme/web",
+  "license": "MIT",
+  "repository": {
+    "url": "https://gitlab.com/web"
+  },
+  "dependencies": {
+    "@acme/ui": "workspace:^",
+    "react": "^18.3.1"
+  },
+  "devDependencies": {
+    "react": "^18.0.0"
+  }
+}
+`},
+			remaining: 2,
+		},
+		{
+			name: "single package",
+			files: map[string]string{
+				"package.json":           `{"name": "solo", "dependencies": {"lodash": "^4.17.21"}}`,
+				".gnpm/constraints.yaml": "rules:\n  - dependency: lodash\n    forbidden: true\n",
+			},
+			want:      []string{"solo: lodash must not be a dependency (found lodash ^4.17.21 in dependencies)"},
+			fixed:     1,
+			manifests: map[string]string{"package.json": "{\n  \"name\": \"solo\"\n}\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootDir := writeFiles(t, tt.files)
+			file, err := Load(rootDir)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+
+			violations, err := Check(rootDir, file.Rules)
+			if err != nil {
+				t.Fatalf("Check failed: %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.Package+": "+v.Rule.String()+" ("+v.Detail+")")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check =\n%q\nwant\n%q", got, tt.want)
+			}
+
+			fixed, err := Fix(violations)
+			if err != nil || fixed != tt.fixed {
+				t.Fatalf("Fix = %d, %v; want %d", fixed, err, tt.fixed)
+			}
+			for name, want := range tt.manifests {
+				if data, _ := os.ReadFile(filepath.Join(rootDir, name)); string(data) != want {
+					t.Errorf("%s =\n%s\nwant\n%s", name, data, want)
+				}
+			}
+
+			remaining, _ := Check(rootDir, file.Rules)
+			if len(remaining) != tt.remaining {
+				t.Errorf("after Fix = %+v, want %d unfixable violations", remaining, tt.remaining)
+			}
+		})
+	}
+}
+
+// writeFiles creates a project holding the files and returns its directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	rootDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(rootDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return rootDir
+}
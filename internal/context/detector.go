@@ -6,14 +6,16 @@ import (
 	"strings"
 
 	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/semver"
 )
 
 // ProjectContext holds information about the current project
 type ProjectContext struct {
-	RootDir        string
-	PackageJSON    *PackageJSON
-	PackageManager pmcombo.PackageManager
-	IsWorkspace    bool
+	RootDir            string
+	PackageJSON        *PackageJSON
+	PackageManager     pmcombo.PackageManager
+	PackageManagerSpec *PackageManagerSpec // pinned version, nil when not pinned
+	IsWorkspace        bool
 }
 
 // Detect detects the project context from the given directory
@@ -32,10 +34,11 @@ func Detect(startDir string) (*ProjectContext, error) {
 	pm := DetectPackageManager(rootDir, pkg)
 
 	return &ProjectContext{
-		RootDir:        rootDir,
-		PackageJSON:    pkg,
-		PackageManager: pm,
-		IsWorkspace:    pkg.HasWorkspaces(),
+		RootDir:            rootDir,
+		PackageJSON:        pkg,
+		PackageManager:     pm,
+		PackageManagerSpec: pkg.PackageManagerSpecFor(pm),
+		IsWorkspace:        pkg.HasWorkspaces(),
 	}, nil
 }
 
@@ -66,9 +69,11 @@ func DetectPackageManager(rootDir string, pkg *PackageJSON) pmcombo.PackageManag
 		}
 	}
 
-	// Fallback to packageManager field in package.json
-	if pkg != nil && pkg.PackageManager != "" {
-		return parsePackageManagerField(pkg.PackageManager)
+	// Fallback to packageManager or devEngines.packageManager in package.json
+	if pkg != nil {
+		if specs := pkg.PackageManagerSpecs(); len(specs) > 0 {
+			return packageManagerFromSpec(specs[0])
+		}
 	}
 
 	// Default to npm
@@ -103,19 +108,18 @@ func detectYarnVersion(rootDir string) pmcombo.PackageManager {
 	return pmcombo.YarnClassic
 }
 
-// parsePackageManagerField parses the packageManager field from package.json
-// Format: "npm@10.2.0", "pnpm@8.6.0", etc.
-func parsePackageManagerField(field string) pmcombo.PackageManager {
-	// Extract package manager name before @
-	name := field
-	if idx := strings.Index(field, "@"); idx != -1 {
-		name = field[:idx]
-	}
-
-	switch strings.ToLower(name) {
+// packageManagerFromSpec maps a packageManager spec to a PackageManager
+func packageManagerFromSpec(spec PackageManagerSpec) pmcombo.PackageManager {
+	switch spec.Name {
 	case "npm":
 		return pmcombo.NPM
 	case "yarn":
+		// Yarn 1.x is Yarn Classic, everything newer is Berry
+		if rng, err := semver.ParseRange(spec.Version); err == nil && spec.Version != "" {
+			if min, ok := rng.MinVersion(); ok && min.Major == 1 {
+				return pmcombo.YarnClassic
+			}
+		}
 		return pmcombo.Yarn
 	case "pnpm":
 		return pmcombo.PNPM
@@ -0,0 +1,315 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Manifest is an editable package.json that preserves key order and indentation
+type Manifest struct {
+	Path   string
+	root   *jsonObject
+	indent string
+}
+
+// jsonObject is a JSON object that remembers the order of its keys.
+// Values are either nested *jsonObject or json.RawMessage.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// ReadManifest reads a package.json for editing
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := parseJSONObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	return &Manifest{
+		Path:   path,
+		root:   root,
+		indent: detectIndent(data),
+	}, nil
+}
+
+// Get returns the raw JSON value at the given key path
+func (m *Manifest) Get(keys ...string) (json.RawMessage, bool) {
+	obj := m.root
+	for i, key := range keys {
+		value, ok := obj.values[key]
+		if !ok {
+			return nil, false
+		}
+		if i == len(keys)-1 {
+			if child, ok := value.(*jsonObject); ok {
+				data, err := child.marshal("", "")
+				if err != nil {
+					return nil, false
+				}
+				return data, true
+			}
+			return value.(json.RawMessage), true
+		}
+		child, ok := value.(*jsonObject)
+		if !ok {
+			return nil, false
+		}
+		obj = child
+	}
+	return nil, false
+}
+
+// GetString returns the string value at the given key path
+func (m *Manifest) GetString(keys ...string) (string, bool) {
+	raw, ok := m.Get(keys...)
+	if !ok {
+		return "", false
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// Keys returns the keys of the object at the given key path, in file order
+func (m *Manifest) Keys(keys ...string) []string {
+	obj := m.object(keys, false)
+	if obj == nil {
+		return nil
+	}
+	return append([]string{}, obj.keys...)
+}
+
+// Set sets the value at the given key path, creating intermediate objects.
+// Existing keys keep their position; new keys are appended.
+func (m *Manifest) Set(keys []string, value interface{}) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("empty key path")
+	}
+
+	raw, err := marshalValue(value)
+	if err != nil {
+		return err
+	}
+
+	parent := m.object(keys[:len(keys)-1], true)
+	if parent == nil {
+		return fmt.Errorf("%s is not an object", strings.Join(keys[:len(keys)-1], "."))
+	}
+
+	key := keys[len(keys)-1]
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		child, err := parseJSONObject(raw)
+		if err != nil {
+			return err
+		}
+		parent.set(key, child)
+		return nil
+	}
+	parent.set(key, raw)
+	return nil
+}
+
+// Delete removes the value at the given key path, reporting whether it existed
+func (m *Manifest) Delete(keys ...string) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	parent := m.object(keys[:len(keys)-1], false)
+	if parent == nil {
+		return false
+	}
+	return parent.delete(keys[len(keys)-1])
+}
+
+// Marshal renders the manifest with its original indentation
+func (m *Manifest) Marshal() ([]byte, error) {
+	data, err := m.root.marshal("", m.indent)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Write saves the manifest back to its path
+func (m *Manifest) Write() error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.Path, data, 0644)
+}
+
+// object walks to the object at the given key path, optionally creating it
+func (m *Manifest) object(keys []string, create bool) *jsonObject {
+	obj := m.root
+	for _, key := range keys {
+		value, ok := obj.values[key]
+		if !ok {
+			if !create {
+				return nil
+			}
+			child := newJSONObject()
+			obj.set(key, child)
+			obj = child
+			continue
+		}
+		child, ok := value.(*jsonObject)
+		if !ok {
+			if !create {
+				return nil
+			}
+			child = newJSONObject()
+			obj.set(key, child)
+		}
+		obj = child
+	}
+	return obj
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{})}
+}
+
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) delete(key string) bool {
+	if _, ok := o.values[key]; !ok {
+		return false
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// marshal renders the object, indenting nested lines with prefix+indent
+func (o *jsonObject) marshal(prefix string, indent string) ([]byte, error) {
+	if len(o.keys) == 0 {
+		return []byte("{}"), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, key := range o.keys {
+		keyData, err := marshalValue(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(prefix + indent)
+		buf.Write(keyData)
+		buf.WriteString(": ")
+
+		switch value := o.values[key].(type) {
+		case *jsonObject:
+			data, err := value.marshal(prefix+indent, indent)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(data)
+		case json.RawMessage:
+			var formatted bytes.Buffer
+			if err := json.Indent(&formatted, value, prefix+indent, indent); err != nil {
+				return nil, err
+			}
+			buf.Write(formatted.Bytes())
+		}
+
+		if i < len(o.keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString(prefix + "}")
+	return buf.Bytes(), nil
+}
+
+// parseJSONObject parses a JSON object, keeping key order for nested objects
+func parseJSONObject(data []byte) (*jsonObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+
+	obj := newJSONObject()
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an object key")
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+			child, err := parseJSONObject(raw)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(key, child)
+		} else {
+			obj.set(key, raw)
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// marshalValue encodes a value without escaping HTML characters
+func marshalValue(value interface{}) (json.RawMessage, error) {
+	if raw, ok := value.(json.RawMessage); ok {
+		return raw, nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// detectIndent returns the indentation used by the first indented line
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || len(trimmed) == len(line) {
+			continue
+		}
+		return line[:len(line)-len(trimmed)]
+	}
+	return "  "
+}
@@ -0,0 +1,98 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "package.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestManifestPreservesOrderAndIndent(t *testing.T) {
+	path := writeManifest(t, `{
+    "name": "app",
+    "version": "1.0.0",
+    "scripts": {
+        "test": "vitest",
+        "build": "tsc"
+    },
+    "files": ["dist"]
+}
+`)
+
+	m, err := ReadManifest(path)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if err := m.Set([]string{"packageManager"}, "pnpm@9.1.0"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := m.Set([]string{"version"}, "1.1.0"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := `{
+    "name": "app",
+    "version": "1.1.0",
+    "scripts": {
+        "test": "vitest",
+        "build": "tsc"
+    },
+    "files": [
+        "dist"
+    ],
+    "packageManager": "pnpm@9.1.0"
+}
+`
+	if string(data) != expected {
+		t.Errorf("unexpected output:\n%s", data)
+	}
+}
+
+func TestManifestNestedSetAndDelete(t *testing.T) {
+	path := writeManifest(t, `{"name": "app", "dependencies": {"react": "^18.0.0"}}`)
+
+	m, err := ReadManifest(path)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+
+	if err := m.Set([]string{"pnpm", "overrides", "lodash"}, "4.17.21"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if !m.Delete("dependencies", "react") {
+		t.Error("expected react to be deleted")
+	}
+	if m.Delete("dependencies", "vue") {
+		t.Error("expected deleting a missing key to report false")
+	}
+	if err := m.Write(); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	m, err = ReadManifest(path)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if got, _ := m.GetString("pnpm", "overrides", "lodash"); got != "4.17.21" {
+		t.Errorf("expected override to round-trip, got %q", got)
+	}
+	if keys := m.Keys("dependencies"); len(keys) != 0 {
+		t.Errorf("expected no dependencies, got %v", keys)
+	}
+	if got, _ := m.GetString("name"); got != "app" {
+		t.Errorf("expected name to be kept, got %q", got)
+	}
+}
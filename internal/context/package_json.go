@@ -8,13 +8,35 @@ import (
 
 // PackageJSON represents the relevant fields from package.json
 type PackageJSON struct {
-	Name            string            `json:"name"`
-	Version         string            `json:"version"`
-	PackageManager  string            `json:"packageManager"`
-	Scripts         map[string]string `json:"scripts"`
-	Dependencies    map[string]string `json:"dependencies"`
-	DevDependencies map[string]string `json:"devDependencies"`
-	Workspaces      Workspaces        `json:"workspaces"`
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	PackageManager       string            `json:"packageManager"`
+	Scripts              map[string]string `json:"scripts"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	Workspaces           Workspaces        `json:"workspaces"`
+	DevEngines           DevEngines        `json:"devEngines"`
+	Engines              Engines           `json:"engines"`
+	Volta                Volta             `json:"volta"`
+}
+
+// Engines maps runtime and package manager names to version ranges
+type Engines map[string]string
+
+// UnmarshalJSON ignores the legacy array format instead of failing
+func (e *Engines) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err == nil {
+		*e = m
+	}
+	return nil
+}
+
+// Volta represents the volta field from package.json
+type Volta struct {
+	Node string `json:"node"`
 }
 
 // Workspaces can be either an array of strings or an object with packages field
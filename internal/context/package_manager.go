@@ -0,0 +1,132 @@
+package context
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+// Package manager spec sources
+const (
+	SpecSourcePackageManager = "packageManager"
+	SpecSourceDevEngines     = "devEngines.packageManager"
+)
+
+// PackageManagerSpec describes the package manager version a project pins,
+// either through the packageManager field or devEngines.packageManager.
+type PackageManagerSpec struct {
+	Name    string // npm, pnpm, yarn, bun, deno
+	Version string // exact version or, for devEngines, a range
+	Hash    string // optional "<algorithm>.<hex>" suffix from packageManager
+	OnFail  string // ignore, warn or error
+	Source  string
+}
+
+// String returns the spec in packageManager field format
+func (s PackageManagerSpec) String() string {
+	if s.Version == "" {
+		return s.Name
+	}
+	spec := s.Name + "@" + s.Version
+	if s.Hash != "" {
+		spec += "+" + s.Hash
+	}
+	return spec
+}
+
+// DevEngine is a single devEngines entry
+type DevEngine struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	OnFail  string `json:"onFail"`
+}
+
+// DevEngines represents the devEngines field from package.json
+type DevEngines struct {
+	PackageManager DevEngineList `json:"packageManager"`
+}
+
+// DevEngineList can be either a single object or an array of objects
+type DevEngineList []DevEngine
+
+// UnmarshalJSON handles both object and array formats for devEngines entries
+func (l *DevEngineList) UnmarshalJSON(data []byte) error {
+	var arr []DevEngine
+	if err := json.Unmarshal(data, &arr); err == nil {
+		*l = arr
+		return nil
+	}
+
+	var single DevEngine
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = DevEngineList{single}
+		return nil
+	}
+
+	return nil
+}
+
+// ParsePackageManagerSpec parses a packageManager field value.
+// Format: "pnpm@9.1.0", "yarn@4.1.0+sha512.abc..."
+func ParsePackageManagerSpec(field string) (PackageManagerSpec, bool) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return PackageManagerSpec{}, false
+	}
+
+	name, version, _ := strings.Cut(field, "@")
+	version, hash, _ := strings.Cut(version, "+")
+	if name == "" {
+		return PackageManagerSpec{}, false
+	}
+
+	return PackageManagerSpec{
+		Name:    strings.ToLower(name),
+		Version: version,
+		Hash:    hash,
+		OnFail:  "error",
+		Source:  SpecSourcePackageManager,
+	}, true
+}
+
+// PackageManagerSpecs returns every package manager pin declared in package.json,
+// packageManager first
+func (p *PackageJSON) PackageManagerSpecs() []PackageManagerSpec {
+	var specs []PackageManagerSpec
+
+	if spec, ok := ParsePackageManagerSpec(p.PackageManager); ok {
+		specs = append(specs, spec)
+	}
+
+	for _, engine := range p.DevEngines.PackageManager {
+		if engine.Name == "" {
+			continue
+		}
+		onFail := engine.OnFail
+		if onFail == "" {
+			onFail = "error"
+		}
+		specs = append(specs, PackageManagerSpec{
+			Name:    strings.ToLower(engine.Name),
+			Version: strings.TrimSpace(engine.Version),
+			OnFail:  onFail,
+			Source:  SpecSourceDevEngines,
+		})
+	}
+
+	return specs
+}
+
+// PackageManagerSpecFor returns the pin that applies to the given package manager, if any
+func (p *PackageJSON) PackageManagerSpecFor(pm pmcombo.PackageManager) *PackageManagerSpec {
+	if p == nil {
+		return nil
+	}
+	for _, spec := range p.PackageManagerSpecs() {
+		if spec.Name == pm.Executable() {
+			return &spec
+		}
+	}
+	return nil
+}
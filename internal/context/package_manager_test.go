@@ -0,0 +1,93 @@
+package context
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+func TestParsePackageManagerSpec(t *testing.T) {
+	tests := []struct {
+		field    string
+		expected PackageManagerSpec
+		ok       bool
+	}{
+		{"pnpm@9.1.0", PackageManagerSpec{Name: "pnpm", Version: "9.1.0"}, true},
+		{"yarn@4.1.0+sha512.abc123", PackageManagerSpec{Name: "yarn", Version: "4.1.0", Hash: "sha512.abc123"}, true},
+		{"npm", PackageManagerSpec{Name: "npm"}, true},
+		{"", PackageManagerSpec{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			spec, ok := ParsePackageManagerSpec(tt.field)
+			if ok != tt.ok {
+				t.Fatalf("expected ok=%v, got %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if spec.Name != tt.expected.Name || spec.Version != tt.expected.Version || spec.Hash != tt.expected.Hash {
+				t.Errorf("expected %+v, got %+v", tt.expected, spec)
+			}
+			if spec.String() != tt.field {
+				t.Errorf("expected String() %q, got %q", tt.field, spec.String())
+			}
+		})
+	}
+}
+
+func TestPackageManagerSpecForDevEngines(t *testing.T) {
+	var pkg PackageJSON
+	data := `{"devEngines": {"packageManager": {"name": "pnpm", "version": "10.0.0", "onFail": "warn"}}}`
+	if err := json.Unmarshal([]byte(data), &pkg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	spec := pkg.PackageManagerSpecFor(pmcombo.PNPM)
+	if spec == nil {
+		t.Fatal("expected a pnpm spec")
+	}
+	if spec.Version != "10.0.0" || spec.OnFail != "warn" || spec.Source != SpecSourceDevEngines {
+		t.Errorf("unexpected spec %+v", spec)
+	}
+	if pkg.PackageManagerSpecFor(pmcombo.NPM) != nil {
+		t.Error("expected no spec for npm")
+	}
+}
+
+func TestPackageManagerSpecForPrefersPackageManagerField(t *testing.T) {
+	var pkg PackageJSON
+	data := `{
+		"packageManager": "pnpm@9.1.0",
+		"devEngines": {"packageManager": [{"name": "pnpm", "version": "^9.0.0"}]}
+	}`
+	if err := json.Unmarshal([]byte(data), &pkg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	spec := pkg.PackageManagerSpecFor(pmcombo.PNPM)
+	if spec == nil || spec.Version != "9.1.0" || spec.Source != SpecSourcePackageManager {
+		t.Errorf("expected packageManager field to win, got %+v", spec)
+	}
+}
+
+func TestPackageManagerFromSpec(t *testing.T) {
+	tests := []struct {
+		field    string
+		expected pmcombo.PackageManager
+	}{
+		{"yarn@1.22.19", pmcombo.YarnClassic},
+		{"yarn@4.1.0", pmcombo.Yarn},
+		{"pnpm@9.1.0", pmcombo.PNPM},
+		{"bun@1.1.0", pmcombo.Bun},
+	}
+
+	for _, tt := range tests {
+		spec, _ := ParsePackageManagerSpec(tt.field)
+		if got := packageManagerFromSpec(spec); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.field, tt.expected, got)
+		}
+	}
+}
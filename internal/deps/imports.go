@@ -0,0 +1,205 @@
+package deps
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSourceSize skips bundles and other generated files
+const maxSourceSize = 1 << 20
+
+// sourceExtensions are the files scanned for imports
+var sourceExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".mjs": true, ".cjs": true,
+	".ts": true, ".tsx": true, ".mts": true, ".cts": true,
+	".vue": true, ".svelte": true, ".astro": true,
+}
+
+// skippedDirs hold dependencies or build output rather than source
+var skippedDirs = map[string]bool{
+	"node_modules": true, ".git": true, "dist": true, "build": true, "coverage": true,
+	".next": true, ".nuxt": true, ".output": true, ".svelte-kit": true, ".turbo": true, ".cache": true,
+}
+
+// devDirs hold code that only runs during development
+var devDirs = map[string]bool{
+	"test": true, "tests": true, "__tests__": true, "__mocks__": true, "spec": true, "e2e": true,
+	"fixtures": true, "scripts": true, "stories": true, "examples": true, "bench": true, "benchmarks": true,
+}
+
+var devFilePattern = regexp.MustCompile(`\.(test|spec|stories|story|bench|config|setup)\.[cm]?[jt]sx?$`)
+
+var (
+	// import x from "pkg", import type { X } from "pkg", export * from "pkg"
+	fromPattern = regexp.MustCompile(`(?m)^\s*(?:import|export)(\s+type)?\b[^'"();]*?\bfrom\s*['"]([^'"\n]+)['"]`)
+	// import "pkg"
+	sideEffectPattern = regexp.MustCompile(`(?m)^\s*import\s*['"]([^'"\n]+)['"]`)
+	// require("pkg"), require.resolve("pkg"), import("pkg")
+	callPattern = regexp.MustCompile(`\b(?:require(?:\.resolve)?|import)\s*\(\s*['"]([^'"\n]+)['"]\s*\)`)
+)
+
+// nodeBuiltins are the Node.js core modules importable without "node:"
+var nodeBuiltins = map[string]bool{
+	"assert": true, "async_hooks": true, "buffer": true, "child_process": true, "cluster": true,
+	"console": true, "constants": true, "crypto": true, "dgram": true, "diagnostics_channel": true,
+	"dns": true, "domain": true, "events": true, "fs": true, "http": true, "http2": true, "https": true,
+	"inspector": true, "module": true, "net": true, "os": true, "path": true, "perf_hooks": true,
+	"process": true, "punycode": true, "querystring": true, "readline": true, "repl": true,
+	"stream": true, "string_decoder": true, "sys": true, "timers": true, "tls": true,
+	"trace_events": true, "tty": true, "url": true, "util": true, "v8": true, "vm": true,
+	"wasi": true, "worker_threads": true, "zlib": true,
+}
+
+// sourceImport is a package imported by a source file
+type sourceImport struct {
+	Package  string
+	TypeOnly bool // import type / export type, erased at compile time
+}
+
+// sourceFile is a scanned file of a package
+type sourceFile struct {
+	Path    string // relative to the package, with forward slashes
+	Dev     bool   // tests, configs, scripts and other development-only code
+	Imports []sourceImport
+}
+
+// scanSources reads the imports of every source file in dir, skipping
+// dependencies, build output and the directories in skip (nested
+// workspace packages)
+func scanSources(dir string, skip map[string]bool) ([]sourceFile, error) {
+	var files []sourceFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && (skippedDirs[d.Name()] || skip[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sourceExtensions[filepath.Ext(path)] || strings.HasSuffix(path, ".d.ts") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxSourceSize {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		files = append(files, sourceFile{
+			Path:    rel,
+			Dev:     isDevFile(rel),
+			Imports: parseImports(string(data)),
+		})
+		return nil
+	})
+	return files, err
+}
+
+// isDevFile reports whether a file only runs during development: tests,
+// stories, tool configs and anything in a test or scripts directory
+func isDevFile(rel string) bool {
+	segments := strings.Split(rel, "/")
+	for _, segment := range segments {
+		if devDirs[segment] || strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return devFilePattern.MatchString(segments[len(segments)-1])
+}
+
+// parseImports finds the packages a source file imports. Relative paths,
+// Node.js core modules, URLs and path aliases are left out.
+func parseImports(source string) []sourceImport {
+	source = stripComments(source)
+
+	var imports []sourceImport
+	seen := map[sourceImport]bool{}
+	add := func(specifier string, typeOnly bool) {
+		name := packageName(specifier)
+		if name == "" {
+			return
+		}
+		imp := sourceImport{Package: name, TypeOnly: typeOnly}
+		if !seen[imp] {
+			seen[imp] = true
+			imports = append(imports, imp)
+		}
+	}
+
+	for _, m := range fromPattern.FindAllStringSubmatch(source, -1) {
+		add(m[2], m[1] != "")
+	}
+	for _, m := range sideEffectPattern.FindAllStringSubmatch(source, -1) {
+		add(m[1], false)
+	}
+	for _, m := range callPattern.FindAllStringSubmatch(source, -1) {
+		add(m[1], false)
+	}
+	return imports
+}
+
+// stripComments drops line comments and block comments that start a line,
+// so commented-out imports aren't counted. Comments after code are left
+// alone: telling them apart from strings and regexps needs a real parser.
+func stripComments(source string) string {
+	lines := strings.Split(source, "\n")
+	inBlock := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if inBlock {
+			lines[i] = ""
+			if end := strings.Index(trimmed, "*/"); end >= 0 {
+				inBlock = false
+				lines[i] = trimmed[end+2:]
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "//"):
+			lines[i] = ""
+		case strings.HasPrefix(trimmed, "/*"):
+			if end := strings.Index(trimmed[2:], "*/"); end >= 0 {
+				lines[i] = trimmed[end+4:]
+			} else {
+				lines[i] = ""
+				inBlock = true
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// packageName returns the package an import specifier resolves to:
+// "lodash/fp" imports lodash, "@scope/pkg/sub" imports @scope/pkg.
+// Specifiers that don't name a package return "".
+func packageName(specifier string) string {
+	specifier, _, _ = strings.Cut(specifier, "?")
+	if specifier == "" || strings.ContainsAny(specifier[:1], "./#~$") || strings.Contains(specifier, ":") {
+		// Relative and absolute paths, subpath imports, aliases and
+		// protocols such as node:, virtual: or https:
+		return ""
+	}
+
+	segments := strings.SplitN(specifier, "/", 3)
+	if strings.HasPrefix(specifier, "@") {
+		if len(segments) < 2 || segments[0] == "@" || segments[1] == "" {
+			// "@/components" is a path alias, not a scope
+			return ""
+		}
+		return segments[0] + "/" + segments[1]
+	}
+	return segments[0]
+}
@@ -0,0 +1,170 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	fuzzyfinder "github.com/ktr0731/go-fuzzyfinder"
+
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// SelectTargets shows a multi-select fuzzy finder over upgrade targets
+// (Tab to select). Aborting selects nothing.
+func SelectTargets(targets []Target) ([]Target, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	labels := targetLabels(targets)
+	indices, err := fuzzyfinder.FindMulti(
+		targets,
+		func(i int) string {
+			return labels[i]
+		},
+		fuzzyfinder.WithHeader("Tab to select, Enter to upgrade"),
+		fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
+			if i == -1 {
+				return ""
+			}
+			return targetPreview(targets[i], h)
+		}),
+	)
+	if errors.Is(err, fuzzyfinder.ErrAbort) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]Target, len(indices))
+	for i, idx := range indices {
+		selected[i] = targets[idx]
+	}
+	return selected, nil
+}
+
+// targetLabels renders aligned "bump name current → version (kind) workspace" lines
+func targetLabels(targets []Target) []string {
+	var nameWidth, currentWidth, versionWidth int
+	for _, t := range targets {
+		nameWidth = max(nameWidth, len(t.Name))
+		currentWidth = max(currentWidth, len(orMissing(t.Current)))
+		versionWidth = max(versionWidth, len(t.Version))
+	}
+
+	labels := make([]string, len(targets))
+	for i, t := range targets {
+		bump := t.Bump()
+		if bump == "" {
+			bump = "other"
+		}
+		labels[i] = fmt.Sprintf("%-10s %-*s %*s → %-*s (%s) %s",
+			bump, nameWidth, t.Name, currentWidth, orMissing(t.Current),
+			versionWidth, t.Version, t.Kind, t.Workspace)
+	}
+	return labels
+}
+
+// targetPreview shows publish dates, the releases being skipped over and
+// where to read the changelog
+func targetPreview(t Target, height int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s → %s\n", t.Name, t.Spec, UpdateSpec(t.Spec, t.Version))
+	fmt.Fprintf(&b, "%s of %s (%s)\n\n", t.Type, t.Workspace, t.RelDir)
+
+	p := t.Packument
+	for _, row := range []struct{ label, version string }{
+		{"current", t.Current},
+		{"wanted", t.Wanted},
+		{"latest", t.Latest},
+	} {
+		fmt.Fprintf(&b, "%-8s %-12s %s\n", row.label, orMissing(row.version), publishDate(p, row.version))
+	}
+
+	if p == nil {
+		return b.String()
+	}
+
+	if repo := registry.RepositoryURL(p.Repository); repo != "" {
+		fmt.Fprintf(&b, "\nrepository  %s\n", repo)
+		fmt.Fprintf(&b, "changelog   %s\n", releasesURL(repo))
+	}
+
+	releases := releasesBetween(p, t.Current, t.Version)
+	if len(releases) > 0 {
+		fmt.Fprintf(&b, "\n%d release(s) up to %s:\n", len(releases), t.Version)
+		// Leave room for the lines above
+		limit := max(height-14, 3)
+		for i, version := range releases {
+			if i == limit {
+				fmt.Fprintf(&b, "  … %d more\n", len(releases)-limit)
+				break
+			}
+			line := fmt.Sprintf("  %-12s %s", version, publishDate(p, version))
+			if manifest := p.Versions[version]; manifest != nil && manifest.Deprecated != "" {
+				line += "  deprecated"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// releasesBetween lists versions after from up to and including to, newest
+// first. Prereleases are left out unless the target is one.
+func releasesBetween(p *registry.Packument, from string, to string) []string {
+	target, err := semver.Parse(to)
+	if err != nil {
+		return nil
+	}
+	lower, hasLower := semver.Version{}, false
+	if v, err := semver.Parse(from); err == nil {
+		lower, hasLower = v, true
+	}
+
+	var releases []string
+	versions := p.VersionList()
+	for i := len(versions) - 1; i >= 0; i-- {
+		v, err := semver.Parse(versions[i])
+		if err != nil || target.LessThan(v) {
+			continue
+		}
+		if hasLower && !lower.LessThan(v) {
+			break
+		}
+		if v.IsPrerelease() && !target.IsPrerelease() {
+			continue
+		}
+		releases = append(releases, versions[i])
+	}
+	return releases
+}
+
+func publishDate(p *registry.Packument, version string) string {
+	if p == nil || version == "" {
+		return ""
+	}
+	if published, ok := p.Time[version]; ok {
+		return published.Format("2006-01-02")
+	}
+	return ""
+}
+
+// releasesURL points at GitHub releases, or the repository itself elsewhere
+func releasesURL(repo string) string {
+	if !strings.HasPrefix(repo, "https://github.com/") {
+		return repo
+	}
+	repo, _, _ = strings.Cut(repo, "/tree/")
+	return repo + "/releases"
+}
+
+func orMissing(version string) string {
+	if version == "" {
+		return "missing"
+	}
+	return version
+}
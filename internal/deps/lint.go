@@ -0,0 +1,352 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// Lint problems
+const (
+	// LintUnused is a dependency no source file or script uses
+	LintUnused = "unused"
+	// LintMissing is an imported package the importing package doesn't
+	// declare; it only resolves when hoisted from somewhere else
+	LintMissing = "missing"
+	// LintDevInProd is production code importing a devDependency, which
+	// isn't installed for consumers of the package
+	LintDevInProd = "dev-in-prod"
+)
+
+// maxLintFiles bounds the importing files listed per issue
+const maxLintFiles = 3
+
+// LintIssue is a dependency declaration that doesn't match how the
+// package's source uses it
+type LintIssue struct {
+	Name      string
+	Problem   string
+	Type      string   // field declaring the dependency; for missing ones, the field the fix adds it to
+	Spec      string   // declared specifier; for missing ones, the specifier the fix adds ("" when unknown)
+	Files     []string // importing files relative to the package
+	Workspace string
+	Dir       string
+	RelDir    string
+}
+
+// Changes returns the manifest rewrites fixing the issue; none for a
+// missing dependency without a known specifier
+func (i LintIssue) Changes() []Change {
+	change := Change{
+		Manifest: filepath.Join(i.Dir, "package.json"),
+		RelPath:  path.Join(i.RelDir, "package.json"),
+		Type:     i.Type,
+		Name:     i.Name,
+	}
+
+	switch i.Problem {
+	case LintUnused:
+		change.From = i.Spec
+		return []Change{change}
+	case LintMissing:
+		if i.Spec == "" {
+			return nil
+		}
+		change.To = i.Spec
+		return []Change{change}
+	case LintDevInProd:
+		remove, add := change, change
+		remove.From = i.Spec
+		add.Type, add.To = "dependencies", i.Spec
+		return []Change{remove, add}
+	}
+	return nil
+}
+
+// LintOptions controls which dependencies are linted
+type LintOptions struct {
+	Ignore []string // dependency names or globs to skip
+}
+
+// Lint compares the imports in each workspace package's source files with
+// its manifest. Development files (tests, configs, scripts) may use
+// dependencies declared at the workspace root, the way shared tooling is
+// usually set up; production files must declare what they import.
+func Lint(ctx *context.ProjectContext, opts LintOptions) ([]LintIssue, error) {
+	importers := workspace.Importers(ctx)
+
+	nested := map[string]bool{}
+	for _, importer := range importers[1:] {
+		nested[importer.Dir] = true
+	}
+	specs := knownSpecs(ctx, importers)
+
+	scanned := make([][]sourceFile, len(importers))
+	for i, importer := range importers {
+		if importer.PackageJSON == nil {
+			continue
+		}
+		files, err := scanSources(importer.Dir, nested)
+		if err != nil {
+			return nil, err
+		}
+		scanned[i] = files
+	}
+
+	// Root dependencies used by the development files of other packages
+	// are shared tooling, not unused
+	viaRoot := map[string]bool{}
+	for i, files := range scanned[1:] {
+		pkg := importers[i+1].PackageJSON
+		for _, file := range files {
+			for _, imp := range file.Imports {
+				if file.Dev && !declares(pkg, imp.Package) {
+					viaRoot[imp.Package] = true
+				}
+			}
+		}
+	}
+
+	var issues []LintIssue
+	for i, importer := range importers {
+		if importer.PackageJSON == nil {
+			continue
+		}
+		l := linter{rootDir: ctx.RootDir, importer: importer, opts: opts, specs: specs}
+		if i == 0 {
+			l.usedElsewhere = viaRoot
+		} else {
+			l.root = importers[0].PackageJSON
+		}
+		issues = append(issues, l.lint(scanned[i])...)
+	}
+	return issues, nil
+}
+
+// usage collects the files of one package importing a dependency
+type usage struct {
+	prod      []string
+	dev       []string
+	prodValue bool // imported by production code for more than its types
+}
+
+// linter checks one package
+type linter struct {
+	rootDir       string
+	importer      workspace.Importer
+	root          *context.PackageJSON // workspace root manifest; nil when linting the root
+	usedElsewhere map[string]bool      // dependencies other packages rely on
+	opts          LintOptions
+	specs         map[string]string
+}
+
+func (l linter) lint(files []sourceFile) []LintIssue {
+	pkg := l.importer.PackageJSON
+	label := pkg.Name
+	if label == "" {
+		label = l.importer.RelDir
+	}
+	issue := func(name string, problem string, depType string, spec string, files []string) LintIssue {
+		if len(files) > maxLintFiles {
+			files = files[:maxLintFiles]
+		}
+		return LintIssue{
+			Name: name, Problem: problem, Type: depType, Spec: spec, Files: files,
+			Workspace: label, Dir: l.importer.Dir, RelDir: l.importer.RelDir,
+		}
+	}
+
+	declaredIn := func(name string) string {
+		for _, depType := range workspace.DependencyTypes {
+			if _, ok := workspace.DependencyField(pkg, depType)[name]; ok {
+				return depType
+			}
+		}
+		return ""
+	}
+
+	used := map[string]*usage{}
+	var names []string
+	for _, file := range files {
+		for _, imp := range file.Imports {
+			if imp.Package == pkg.Name || ignored(imp.Package, l.opts.Ignore) {
+				continue
+			}
+			if nodeBuiltins[imp.Package] && declaredIn(imp.Package) == "" {
+				continue
+			}
+			u, ok := used[imp.Package]
+			if !ok {
+				u = &usage{}
+				used[imp.Package] = u
+				names = append(names, imp.Package)
+			}
+			if file.Dev {
+				u.dev = append(u.dev, file.Path)
+			} else {
+				u.prod = append(u.prod, file.Path)
+				u.prodValue = u.prodValue || !imp.TypeOnly
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var issues []LintIssue
+	for _, name := range names {
+		u := used[name]
+		switch declaredIn(name) {
+		case "":
+			spec := l.specs[name]
+			if version := InstalledVersion(l.importer.Dir, l.rootDir, name); spec == "" && version != "" {
+				spec = "^" + version
+			}
+			if len(u.prod) > 0 {
+				issues = append(issues, issue(name, LintMissing, "dependencies", spec, u.prod))
+			} else if l.root == nil || !declares(l.root, name) {
+				issues = append(issues, issue(name, LintMissing, "devDependencies", spec, u.dev))
+			}
+		case "devDependencies":
+			if u.prodValue {
+				issues = append(issues, issue(name, LintDevInProd, "devDependencies", pkg.DevDependencies[name], u.prod))
+			}
+		}
+	}
+
+	scripts := scriptWords(pkg)
+	for _, depType := range []string{"dependencies", "devDependencies"} {
+		declared := workspace.DependencyField(pkg, depType)
+		for _, name := range sortedKeys(declared) {
+			if used[name] != nil || l.usedElsewhere[name] || ignored(name, l.opts.Ignore) {
+				continue
+			}
+			// Types are read by the compiler, and peers duplicated as
+			// devDependencies are there for local development
+			if strings.HasPrefix(name, "@types/") || pkg.PeerDependencies[name] != "" {
+				continue
+			}
+			if usedByScripts(scripts, name, installedBins(l.importer.Dir, l.rootDir, name)) {
+				continue
+			}
+			issues = append(issues, issue(name, LintUnused, depType, declared[name], nil))
+		}
+	}
+	return issues
+}
+
+// declares reports whether pkg declares name in any dependency field
+func declares(pkg *context.PackageJSON, name string) bool {
+	for _, depType := range workspace.DependencyTypes {
+		if _, ok := workspace.DependencyField(pkg, depType)[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// knownSpecs picks the specifier the fix adds for a missing dependency: a
+// workspace reference for local packages, otherwise the range most other
+// packages declare
+func knownSpecs(ctx *context.ProjectContext, importers []workspace.Importer) map[string]string {
+	byName := map[string][]Declared{}
+	for _, d := range CollectDeclared(ctx, Options{}) {
+		byName[d.Name] = append(byName[d.Name], d)
+	}
+	specs := map[string]string{}
+	for name, decls := range byName {
+		specs[name] = mostCommonRange(decls)
+	}
+
+	for _, importer := range importers {
+		pkg := importer.PackageJSON
+		if pkg == nil || pkg.Name == "" || importer.RelDir == "." {
+			continue
+		}
+		switch {
+		case ctx.PackageManager != pmcombo.NPM && ctx.PackageManager != pmcombo.YarnClassic:
+			specs[pkg.Name] = "workspace:^"
+		case pkg.Version != "":
+			specs[pkg.Name] = "^" + pkg.Version
+		default:
+			specs[pkg.Name] = "*"
+		}
+	}
+	return specs
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var scriptWordPattern = regexp.MustCompile(`[^\s"'=;&|()<>]+`)
+
+// scriptWords splits a package's scripts into words, so a dependency run
+// as "eslint src" or "npx tsc" is recognized
+func scriptWords(pkg *context.PackageJSON) map[string]bool {
+	words := map[string]bool{}
+	for _, script := range pkg.Scripts {
+		for _, word := range scriptWordPattern.FindAllString(script, -1) {
+			words[word] = true
+			// Options such as --require=ts-node/register or -r dotenv/config
+			if _, value, ok := strings.Cut(word, "="); ok {
+				words[packageName(value)] = true
+			}
+			words[packageName(word)] = true
+		}
+	}
+	return words
+}
+
+func usedByScripts(words map[string]bool, name string, bins []string) bool {
+	if words[name] {
+		return true
+	}
+	for _, bin := range bins {
+		if words[bin] {
+			return true
+		}
+	}
+	return false
+}
+
+// installedBins reads the executables an installed package provides
+func installedBins(dir string, rootDir string, name string) []string {
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "node_modules", name, "package.json"))
+		if err == nil {
+			var manifest struct {
+				Bin json.RawMessage `json:"bin"`
+			}
+			if json.Unmarshal(data, &manifest) != nil || len(manifest.Bin) == 0 {
+				return nil
+			}
+			var single string
+			if json.Unmarshal(manifest.Bin, &single) == nil {
+				return []string{path.Base(name)}
+			}
+			var bins map[string]string
+			if json.Unmarshal(manifest.Bin, &bins) == nil {
+				return sortedKeys(bins)
+			}
+			return nil
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == rootDir || parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,207 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPackageName(t *testing.T) {
+	tests := map[string]string{
+		"lodash":              "lodash",
+		"lodash/fp":           "lodash",
+		"@babel/core":         "@babel/core",
+		"@babel/core/lib/x":   "@babel/core",
+		"./local":             "",
+		"../up":               "",
+		"/abs":                "",
+		"node:fs":             "",
+		"#internal":           "",
+		"@/components/Button": "",
+		"~/utils":             "",
+		"virtual:pwa":         "",
+		"svg-icon?raw":        "svg-icon",
+	}
+	for specifier, want := range tests {
+		if got := packageName(specifier); got != want {
+			t.Errorf("packageName(%q) = %q, want %q", specifier, got, want)
+		}
+	}
+}
+
+func TestParseImports(t *testing.T) {
+	source := `import React, { useState } from "react";
+import type { Simplify } from 'type-fest';
+import {
+  a,
+  b,
+} from "multi-line";
+import "side-effect/register";
+export * from "re-export";
+export type { Props } from "types-only";
+const chalk = require("chalk");
+const lazy = await import("lazy");
+require.resolve("resolved/package.json");
+// import old from "commented";
+/*
+ * require("block-commented")
+ */
+import local from "./local";
+const text = "not an import from 'strings'";
+`
+	want := []sourceImport{
+		{Package: "react"},
+		{Package: "type-fest", TypeOnly: true},
+		{Package: "multi-line"},
+		{Package: "re-export"},
+		{Package: "types-only", TypeOnly: true},
+		{Package: "side-effect"},
+		{Package: "chalk"},
+		{Package: "lazy"},
+		{Package: "resolved"},
+	}
+	if got := parseImports(source); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseImports =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestIsDevFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		"src/index.ts":             false,
+		"lib/server.js":            false,
+		"src/index.test.ts":        true,
+		"src/button.stories.tsx":   true,
+		"test/setup.js":            true,
+		"src/__tests__/a.js":       true,
+		"vite.config.ts":           true,
+		"eslint.config.mjs":        true,
+		".storybook/main.ts":       true,
+		"scripts/release.mjs":      true,
+		"src/components/config.ts": false,
+	} {
+		if got := isDevFile(path); got != want {
+			t.Errorf("isDevFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func lintFixture(t *testing.T) map[string]string {
+	t.Helper()
+	return map[string]string{
+		"package.json":                         `{"name": "root", "workspaces": ["packages/*"], "scripts": {"build": "tsc -b"}, "devDependencies": {"typescript": "^5.4.0", "vitest": "^1.6.0", "prettier": "^3.0.0"}}`,
+		"package-lock.json":                    `{}`,
+		"node_modules/typescript/package.json": `{"name": "typescript", "version": "5.4.5", "bin": {"tsc": "bin/tsc"}}`,
+		"node_modules/chalk/package.json":      `{"name": "chalk", "version": "5.3.0"}`,
+		"packages/web/package.json": `{
+  "name": "web",
+  "dependencies": {"react": "^18.3.1", "lodash": "^4.17.21"},
+  "devDependencies": {"zod": "^3.23.0", "type-fest": "^4.0.0", "@types/react": "^18.3.0", "eslint": "^9.0.0"},
+  "peerDependencies": {"react-dom": "^18.0.0"},
+  "scripts": {"lint": "eslint ."}
+}`,
+		"packages/web/src/index.tsx": `import React from "react";
+import { z } from "zod";
+import type { Simplify } from "type-fest";
+import { Button } from "ui";
+import fs from "fs";
+import chalk from "chalk";
+`,
+		"packages/web/src/index.test.tsx": `import { test } from "vitest";
+import { render } from "@testing-library/react";
+`,
+		"packages/web/dist/bundle.js": `require("bundled")`,
+		"packages/ui/package.json":    `{"name": "ui", "version": "2.1.0", "dependencies": {"react": "^18.2.0"}}`,
+		"packages/ui/src/button.tsx":  `import * as React from "react";`,
+	}
+}
+
+func TestLint(t *testing.T) {
+	ctx := loadContext(t, lintFixture(t))
+
+	issues, err := Lint(ctx, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Workspace+" "+issue.Problem+" "+issue.Name+" "+issue.Type+" "+issue.Spec)
+	}
+	want := []string{
+		"root unused prettier devDependencies ^3.0.0",
+		"web missing @testing-library/react devDependencies ",
+		"web missing chalk dependencies ^5.3.0",
+		"web missing ui dependencies ^2.1.0",
+		"web dev-in-prod zod devDependencies ^3.23.0",
+		"web unused lodash dependencies ^4.17.21",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint =\n%q\nwant\n%q", got, want)
+	}
+
+	ignoredIssues, _ := Lint(ctx, LintOptions{Ignore: []string{"lodash", "@testing-library/*"}})
+	if len(ignoredIssues) != len(want)-2 {
+		t.Errorf("Lint with Ignore found %d issues, want %d", len(ignoredIssues), len(want)-2)
+	}
+}
+
+func TestLintFix(t *testing.T) {
+	ctx := loadContext(t, lintFixture(t))
+
+	issues, err := Lint(ctx, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	var changes []Change
+	for _, issue := range issues {
+		changes = append(changes, issue.Changes()...)
+	}
+	if err := Apply(changes); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(ctx.RootDir, "packages", "web", "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "name": "web",
+  "dependencies": {
+    "react": "^18.3.1",
+    "chalk": "^5.3.0",
+    "ui": "^2.1.0",
+    "zod": "^3.23.0"
+  },
+  "devDependencies": {
+    "type-fest": "^4.0.0",
+    "@types/react": "^18.3.0",
+    "eslint": "^9.0.0"
+  },
+  "peerDependencies": {
+    "react-dom": "^18.0.0"
+  },
+  "scripts": {
+    "lint": "eslint ."
+  }
+}
+`
+	if string(data) != want {
+		t.Errorf("package.json =\n%s\nwant\n%s", data, want)
+	}
+
+	// Removing the last devDependency removes the field
+	ctx = loadContext(t, map[string]string{
+		"package.json": `{"name": "solo", "devDependencies": {"unused": "^1.0.0"}}`,
+	})
+	issues, _ = Lint(ctx, LintOptions{})
+	if len(issues) != 1 {
+		t.Fatalf("Lint = %+v", issues)
+	}
+	if err := Apply(issues[0].Changes()); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ctx.RootDir, "package.json")); string(data) != "{\n  \"name\": \"solo\"\n}\n" {
+		t.Errorf("package.json = %s", data)
+	}
+}
@@ -0,0 +1,304 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/lockfile"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/semver"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// fetchConcurrency bounds parallel registry requests
+const fetchConcurrency = 8
+
+// Declared is a registry dependency declared in a workspace manifest
+type Declared struct {
+	Name      string // key in the manifest
+	Spec      string // specifier as written, e.g. "^1.2.0" or "npm:other@^1"
+	Package   string // registry package; differs from Name for npm: aliases
+	Range     string // range or dist-tag part of Spec
+	Type      string // dependencies, devDependencies or optionalDependencies
+	Workspace string // name of the declaring package, or its directory when unnamed
+	Dir       string // absolute directory of the declaring package
+	RelDir    string // directory relative to the root, "." for the root
+}
+
+// Outdated is a dependency whose installed version is behind the highest
+// version its range allows, or behind the latest dist-tag
+type Outdated struct {
+	Declared
+	Current string // installed version, "" when missing
+	Wanted  string // highest version satisfying the range, "" when none does
+	Latest  string // the latest dist-tag
+	Bump    string // semver.Diff of current and latest: major, minor, patch, ...
+
+	Packument *registry.Packument
+}
+
+// MarshalJSON flattens the report into the shape `outdated --json` prints
+func (o Outdated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name      string `json:"name"`
+		Current   string `json:"current,omitempty"`
+		Wanted    string `json:"wanted,omitempty"`
+		Latest    string `json:"latest,omitempty"`
+		Range     string `json:"range"`
+		Type      string `json:"type"`
+		Workspace string `json:"workspace"`
+		Location  string `json:"location"`
+		Bump      string `json:"bump,omitempty"`
+	}{o.Name, o.Current, o.Wanted, o.Latest, o.Spec, o.Type, o.Workspace, o.RelDir, o.Bump})
+}
+
+// Options controls which dependencies are checked
+type Options struct {
+	Packages []string // only check these dependency names; all when empty
+	Full     bool     // fetch full packuments, with publish times and repositories
+	Verbose  bool
+}
+
+// CollectDeclared returns the registry dependencies of the root and every
+// workspace package. Workspace packages, workspace:, file:, link:, git and
+// URL specifiers are skipped.
+func CollectDeclared(ctx *context.ProjectContext, opts Options) []Declared {
+	importers := workspace.Importers(ctx)
+
+	local := map[string]bool{}
+	for _, importer := range importers {
+		if importer.PackageJSON != nil && importer.PackageJSON.Name != "" {
+			local[importer.PackageJSON.Name] = true
+		}
+	}
+
+	only := map[string]bool{}
+	for _, name := range opts.Packages {
+		only[name] = true
+	}
+
+	var declared []Declared
+	for _, importer := range importers {
+		pkg := importer.PackageJSON
+		if pkg == nil {
+			continue
+		}
+		label := pkg.Name
+		if label == "" {
+			label = importer.RelDir
+		}
+
+		for _, depType := range workspace.DependencyTypes {
+			// Peers are provided by whoever installs the package
+			if depType == "peerDependencies" {
+				continue
+			}
+			for name, spec := range workspace.DependencyField(pkg, depType) {
+				if local[name] || (len(only) > 0 && !only[name]) {
+					continue
+				}
+				dep := semver.ParseDependency(name, spec)
+				if dep.IsWorkspace() || !isRegistrySpec(dep) {
+					continue
+				}
+				declared = append(declared, Declared{
+					Name:      name,
+					Spec:      spec,
+					Package:   dep.Name,
+					Range:     dep.Range,
+					Type:      depType,
+					Workspace: label,
+					Dir:       importer.Dir,
+					RelDir:    importer.RelDir,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(declared, func(i, j int) bool {
+		if declared[i].Name != declared[j].Name {
+			return declared[i].Name < declared[j].Name
+		}
+		return declared[i].RelDir < declared[j].RelDir
+	})
+	return declared
+}
+
+// isRegistrySpec reports whether a specifier resolves through the registry:
+// a semver range or a dist-tag
+func isRegistrySpec(dep semver.Dependency) bool {
+	if _, ok := dep.SemverRange(); ok {
+		return true
+	}
+	return dep.Range != "" && !strings.ContainsAny(dep.Range, ":/#@ ")
+}
+
+// FindOutdated checks every declared registry dependency against the registry
+func FindOutdated(ctx *context.ProjectContext, client *registry.Client, opts Options) []Outdated {
+	declared := CollectDeclared(ctx, opts)
+	if len(declared) == 0 {
+		return nil
+	}
+
+	lock, err := lockfile.Read(ctx.RootDir, ctx.PackageManager)
+	if err != nil && opts.Verbose {
+		logger.Warn("lockfile not used, reading node_modules: %v", err)
+	}
+
+	var names []string
+	for _, dep := range declared {
+		names = append(names, dep.Package)
+	}
+	packuments := FetchPackuments(client, names, opts.Full)
+
+	var outdated []Outdated
+	for _, dep := range declared {
+		packument, ok := packuments[dep.Package]
+		if !ok {
+			continue
+		}
+
+		result := Outdated{
+			Declared: dep,
+			Current:  currentVersion(lock, ctx.RootDir, dep),
+			Wanted:   wantedVersion(packument, dep.Range),
+			Latest:   packument.DistTags["latest"],
+
+			Packument: packument,
+		}
+		if !isBehind(result) {
+			continue
+		}
+		result.Bump = Bump(result.Current, result.Latest)
+		outdated = append(outdated, result)
+	}
+	return outdated
+}
+
+// FetchPackuments fetches the packuments of the given packages in parallel,
+// abbreviated unless full is set. Packages that can't be fetched are
+// reported and left out.
+func FetchPackuments(client *registry.Client, names []string, full bool) map[string]*registry.Packument {
+	fetch := client.AbbreviatedPackument
+	if full {
+		fetch = client.Packument
+	}
+
+	unique := map[string]bool{}
+	var queue []string
+	for _, name := range names {
+		if !unique[name] {
+			unique[name] = true
+			queue = append(queue, name)
+		}
+	}
+
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		packuments = map[string]*registry.Packument{}
+		failures   = map[string]error{}
+		jobs       = make(chan string)
+	)
+	for i := 0; i < min(fetchConcurrency, len(queue)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				packument, err := fetch(name)
+				mu.Lock()
+				if err != nil {
+					failures[name] = err
+				} else {
+					packuments[name] = packument
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, name := range queue {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, name := range queue {
+		if err, failed := failures[name]; failed {
+			logger.Warn("%s: %v", name, err)
+		}
+	}
+	return packuments
+}
+
+// currentVersion reads the installed version from the lockfile, falling back
+// to node_modules for lockfiles gnpm can't read
+func currentVersion(lock *lockfile.Lockfile, rootDir string, dep Declared) string {
+	if lock != nil {
+		if version := lock.Version(dep.RelDir, dep.Name, dep.Spec); version != "" {
+			return version
+		}
+	}
+	return InstalledVersion(dep.Dir, rootDir, dep.Name)
+}
+
+// InstalledVersion finds name in node_modules, walking up from dir to rootDir
+// the way Node.js resolves packages
+func InstalledVersion(dir string, rootDir string, name string) string {
+	for {
+		pkg, err := context.ReadPackageJSON(filepath.Join(dir, "node_modules", name, "package.json"))
+		if err == nil && pkg.Version != "" {
+			return pkg.Version
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if dir == rootDir || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// wantedVersion is the version a fresh install would pick for the range
+func wantedVersion(packument *registry.Packument, rng string) string {
+	if version, ok := packument.DistTags[rng]; ok {
+		return version
+	}
+	manifest, err := packument.Resolve(rng)
+	if err != nil {
+		return ""
+	}
+	return manifest.Version
+}
+
+func isBehind(o Outdated) bool {
+	if o.Current == "" {
+		return true
+	}
+	for _, target := range []string{o.Wanted, o.Latest} {
+		if target != "" && semver.Valid(o.Current) && semver.Compare(o.Current, target) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Bump classifies the update from one version to another: major, minor,
+// patch or a pre* variant; empty when there is nothing to update or either
+// version is invalid
+func Bump(from string, to string) string {
+	a, errA := semver.Parse(from)
+	b, errB := semver.Parse(to)
+	if errA != nil || errB != nil || !a.LessThan(b) {
+		return ""
+	}
+	return semver.Diff(a, b)
+}
@@ -0,0 +1,185 @@
+package deps
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/registry"
+)
+
+var packuments = map[string]string{
+	"react": `{"name": "react", "dist-tags": {"latest": "19.1.0"}, "versions": {
+		"18.2.0": {"version": "18.2.0"}, "18.3.1": {"version": "18.3.1"}, "19.1.0": {"version": "19.1.0"}}}`,
+	"lodash": `{"name": "lodash", "dist-tags": {"latest": "4.17.21"}, "versions": {"4.17.21": {"version": "4.17.21"}}}`,
+	"typescript": `{"name": "typescript", "dist-tags": {"latest": "5.6.3", "beta": "5.7.0-beta"}, "versions": {
+		"5.6.2": {"version": "5.6.2"}, "5.6.3": {"version": "5.6.3"}, "5.7.0-beta": {"version": "5.7.0-beta"}}}`,
+	"string-width": `{"name": "string-width", "dist-tags": {"latest": "7.2.0"}, "versions": {
+		"4.2.3": {"version": "4.2.3"}, "7.2.0": {"version": "7.2.0"}}}`,
+}
+
+func newClient(t *testing.T) *registry.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc, ok := packuments[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	t.Cleanup(server.Close)
+
+	return registry.New(registry.Options{
+		Registry: server.URL,
+		Config:   registry.Config{"fetch-retries": "0"},
+	})
+}
+
+// loadContext writes the files into a new project and detects it
+func loadContext(t *testing.T, files map[string]string) *context.ProjectContext {
+	t.Helper()
+
+	rootDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(rootDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, err := context.Detect(rootDir)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	return ctx
+}
+
+func TestFindOutdated(t *testing.T) {
+	type row struct{ name, pkg, workspace, current, wanted, latest, bump string }
+	tests := []struct {
+		name  string
+		files map[string]string
+		opts  Options
+		want  []row
+	}{
+		{
+			name: "workspace with lockfile",
+			files: map[string]string{
+				"package.json": `{
+  "name": "root",
+  "workspaces": ["packages/*"],
+  "dependencies": {"react": "^18.2.0", "lodash": "^4.17.0", "local": "file:../local"},
+  "devDependencies": {"typescript": "~5.6.0", "string-width-cjs": "npm:string-width@^4.2.0"}
+}`,
+				"packages/app/package.json": `{
+  "name": "app",
+  "dependencies": {"react": "^19.0.0", "root": "workspace:*", "lib": "^1.0.0"}
+}`,
+				"packages/lib/package.json": `{"name": "lib", "version": "1.0.0", "optionalDependencies": {"typescript": "beta"}}`,
+				"package-lock.json": `{"lockfileVersion": 3, "packages": {
+  "": {},
+  "node_modules/react": {"version": "18.2.0"},
+  "node_modules/lodash": {"version": "4.17.21"},
+  "node_modules/typescript": {"version": "5.6.2"},
+  "node_modules/string-width-cjs": {"name": "string-width", "version": "4.2.3"}
+}}`,
+			},
+			want: []row{
+				{"react", "react", "root", "18.2.0", "18.3.1", "19.1.0", "major"},
+				{"react", "react", "app", "18.2.0", "19.1.0", "19.1.0", "major"},
+				{"string-width-cjs", "string-width", "root", "4.2.3", "4.2.3", "7.2.0", "major"},
+				{"typescript", "typescript", "root", "5.6.2", "5.6.3", "5.6.3", "patch"},
+				{"typescript", "typescript", "lib", "5.6.2", "5.7.0-beta", "5.6.3", "patch"},
+			},
+		},
+		{
+			name: "filtered, read from node_modules",
+			files: map[string]string{
+				"package.json":                      `{"name": "root", "dependencies": {"react": "^18.0.0", "lodash": "^4.0.0", "missing": "^1.0.0"}}`,
+				"bun.lockb":                         "binary",
+				"node_modules/react/package.json":   `{"name": "react", "version": "18.3.1"}`,
+				"node_modules/lodash/package.json":  `{"name": "lodash", "version": "4.17.20"}`,
+				"node_modules/.bin/placeholder.txt": "",
+			},
+			opts: Options{Packages: []string{"react", "lodash"}},
+			want: []row{
+				{"lodash", "lodash", "root", "4.17.20", "4.17.21", "4.17.21", "patch"},
+				{"react", "react", "root", "18.3.1", "18.3.1", "19.1.0", "major"},
+			},
+		},
+		{
+			name:  "not installed",
+			files: map[string]string{"package.json": `{"name": "root", "dependencies": {"lodash": "^4.0.0"}}`},
+			want:  []row{{"lodash", "lodash", "root", "", "4.17.21", "4.17.21", ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := FindOutdated(loadContext(t, tt.files), newClient(t), tt.opts)
+
+			var got []row
+			for _, r := range results {
+				got = append(got, row{r.Name, r.Package, r.Workspace, r.Current, r.Wanted, r.Latest, r.Bump})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindOutdated =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstalledVersionWalksUp(t *testing.T) {
+	rootDir := loadContext(t, map[string]string{
+		"package.json":                                 `{"name": "root"}`,
+		"node_modules/react/package.json":              `{"version": "18.3.1"}`,
+		"packages/app/node_modules/react/package.json": `{"version": "17.0.2"}`,
+		"packages/lib/package.json":                    `{}`,
+	}).RootDir
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{filepath.Join(rootDir, "packages/app"), "17.0.2"},
+		{filepath.Join(rootDir, "packages/lib"), "18.3.1"},
+		{rootDir, "18.3.1"},
+	}
+	for _, tt := range tests {
+		if got := InstalledVersion(tt.dir, rootDir, "react"); got != tt.want {
+			t.Errorf("InstalledVersion(%s) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+	if got := InstalledVersion(rootDir, rootDir, "vue"); got != "" {
+		t.Errorf("InstalledVersion(vue) = %q, want empty", got)
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want string
+	}{
+		{"1.0.0", "2.0.0", "major"},
+		{"1.0.0", "1.1.0", "minor"},
+		{"1.0.0", "1.0.1", "patch"},
+		{"1.0.0", "1.0.0", ""},
+		{"2.0.0", "1.0.0", ""},
+		{"", "1.0.0", ""},
+	}
+	for _, tt := range tests {
+		if got := Bump(tt.from, tt.to); got != tt.want {
+			t.Errorf("Bump(%s, %s) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
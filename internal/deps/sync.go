@@ -0,0 +1,188 @@
+package deps
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// Policy decides which range mismatched dependencies are synced to
+type Policy string
+
+const (
+	// PolicyHighest picks the range with the highest minimum version
+	PolicyHighest Policy = "highest"
+	// PolicyMostCommon picks the range most packages already use
+	PolicyMostCommon Policy = "most-common"
+	// PolicyPinned only syncs dependencies with a pinned range
+	PolicyPinned Policy = "pinned"
+)
+
+// ParsePolicy parses a policy name; empty means PolicyHighest
+func ParsePolicy(name string) (Policy, error) {
+	switch Policy(name) {
+	case "":
+		return PolicyHighest, nil
+	case PolicyHighest, PolicyMostCommon, PolicyPinned:
+		return Policy(name), nil
+	default:
+		return "", fmt.Errorf("unknown policy %q (use highest, most-common or pinned)", name)
+	}
+}
+
+// SyncOptions controls how declarations are compared
+type SyncOptions struct {
+	Policy   Policy
+	Pins     map[string]string // dependency name to required range
+	Ignore   []string          // dependency names or globs to skip
+	Packages []string          // only check these dependency names; all when empty
+}
+
+// Mismatch is an external dependency declared with different ranges across
+// workspace packages, or with a range other than its pin
+type Mismatch struct {
+	Name         string
+	Target       string // range to sync to; empty when the policy can't pick one
+	Pinned       bool
+	Declarations []Declared
+}
+
+// Ranges returns the distinct ranges in use, most common first
+func (m Mismatch) Ranges() []string {
+	counts := map[string]int{}
+	var ranges []string
+	for _, d := range m.Declarations {
+		if counts[d.Spec] == 0 {
+			ranges = append(ranges, d.Spec)
+		}
+		counts[d.Spec]++
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return counts[ranges[i]] > counts[ranges[j]]
+	})
+	return ranges
+}
+
+// Changes returns the manifest rewrites that sync the dependency to Target
+func (m Mismatch) Changes() []Change {
+	if m.Target == "" {
+		return nil
+	}
+	var changes []Change
+	for _, d := range m.Declarations {
+		if d.Spec == m.Target {
+			continue
+		}
+		changes = append(changes, Change{
+			Manifest: filepath.Join(d.Dir, "package.json"),
+			RelPath:  path.Join(d.RelDir, "package.json"),
+			Type:     d.Type,
+			Name:     d.Name,
+			From:     d.Spec,
+			To:       m.Target,
+		})
+	}
+	return changes
+}
+
+// FindMismatches groups the external dependencies of every workspace
+// package by name and reports those declared with more than one range, or
+// with a range that differs from their pin
+func FindMismatches(ctx *context.ProjectContext, opts SyncOptions) []Mismatch {
+	declared := CollectDeclared(ctx, Options{Packages: opts.Packages})
+
+	byName := map[string][]Declared{}
+	var names []string
+	for _, d := range declared {
+		if ignored(d.Name, opts.Ignore) {
+			continue
+		}
+		if _, ok := byName[d.Name]; !ok {
+			names = append(names, d.Name)
+		}
+		byName[d.Name] = append(byName[d.Name], d)
+	}
+	sort.Strings(names)
+
+	var mismatches []Mismatch
+	for _, name := range names {
+		decls := byName[name]
+		m := Mismatch{Name: name, Declarations: decls}
+
+		if pin, ok := opts.Pins[name]; ok {
+			m.Target, m.Pinned = pin, true
+		} else {
+			switch opts.Policy {
+			case PolicyMostCommon:
+				m.Target = mostCommonRange(decls)
+			case PolicyPinned:
+				// Unpinned mismatches are reported without a target
+			default:
+				m.Target = highestRange(m.Ranges())
+			}
+		}
+
+		ranges := m.Ranges()
+		if len(ranges) > 1 || (m.Pinned && ranges[0] != m.Target) {
+			mismatches = append(mismatches, m)
+		}
+	}
+	return mismatches
+}
+
+func ignored(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched || pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// highestRange picks the range with the highest minimum version; ranges
+// that aren't semver (dist-tags) only win when nothing else parses
+func highestRange(ranges []string) string {
+	var best string
+	var bestMin semver.Version
+	for _, spec := range ranges {
+		_, rng := splitNpmAlias(spec)
+		r, err := semver.ParseRange(rng)
+		if err != nil {
+			continue
+		}
+		floor, ok := r.MinVersion()
+		if !ok {
+			continue
+		}
+		if best == "" || bestMin.LessThan(floor) {
+			best, bestMin = spec, floor
+		}
+	}
+	if best == "" && len(ranges) > 0 {
+		return ranges[0]
+	}
+	return best
+}
+
+// mostCommonRange picks the most used range, breaking ties by the highest
+func mostCommonRange(decls []Declared) string {
+	counts := map[string]int{}
+	top := 0
+	for _, d := range decls {
+		counts[d.Spec]++
+		top = max(top, counts[d.Spec])
+	}
+
+	var tied []string
+	for spec, count := range counts {
+		if count == top {
+			tied = append(tied, spec)
+		}
+	}
+	sort.Strings(tied)
+	return highestRange(tied)
+}
@@ -0,0 +1,99 @@
+package deps
+
+import (
+	"reflect"
+	"testing"
+)
+
+func syncFixture(t *testing.T) map[string]string {
+	t.Helper()
+	return map[string]string{
+		"package.json":            `{"name": "root", "workspaces": ["packages/*"], "devDependencies": {"typescript": "~5.6.0", "@types/node": "^20.0.0"}}`,
+		"packages/a/package.json": `{"name": "a", "dependencies": {"react": "^18.2.0", "lodash": "^4.17.0", "b": "^1.0.0"}}`,
+		"packages/b/package.json": `{"name": "b", "version": "1.0.0", "dependencies": {"react": "^18.3.1", "lodash": "^4.17.0"}, "devDependencies": {"@types/node": "^22.0.0"}}`,
+		"packages/c/package.json": `{"name": "c", "dependencies": {"react": "^18.2.0", "lodash": "^4.17.21"}}`,
+	}
+}
+
+func mismatchTargets(mismatches []Mismatch) map[string]string {
+	targets := map[string]string{}
+	for _, m := range mismatches {
+		targets[m.Name] = m.Target
+	}
+	return targets
+}
+
+func TestFindMismatchesHighest(t *testing.T) {
+	ctx := loadContext(t, syncFixture(t))
+
+	got := mismatchTargets(FindMismatches(ctx, SyncOptions{Policy: PolicyHighest}))
+	want := map[string]string{"react": "^18.3.1", "lodash": "^4.17.21", "@types/node": "^22.0.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %v, want %v", got, want)
+	}
+}
+
+func TestFindMismatchesMostCommon(t *testing.T) {
+	ctx := loadContext(t, syncFixture(t))
+
+	mismatches := FindMismatches(ctx, SyncOptions{Policy: PolicyMostCommon, Ignore: []string{"@types/*"}})
+	got := mismatchTargets(mismatches)
+	want := map[string]string{"react": "^18.2.0", "lodash": "^4.17.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %v, want %v", got, want)
+	}
+
+	for _, m := range mismatches {
+		if m.Name != "react" {
+			continue
+		}
+		if ranges := m.Ranges(); !reflect.DeepEqual(ranges, []string{"^18.2.0", "^18.3.1"}) {
+			t.Errorf("Ranges = %v", ranges)
+		}
+		changes := m.Changes()
+		if len(changes) != 1 || changes[0].RelPath != "packages/b/package.json" || changes[0].To != "^18.2.0" {
+			t.Errorf("Changes = %+v", changes)
+		}
+	}
+}
+
+func TestFindMismatchesPinned(t *testing.T) {
+	ctx := loadContext(t, syncFixture(t))
+
+	mismatches := FindMismatches(ctx, SyncOptions{
+		Policy: PolicyPinned,
+		Pins:   map[string]string{"typescript": "~5.6.3", "react": "^18.3.1"},
+	})
+	got := mismatchTargets(mismatches)
+	want := map[string]string{"typescript": "~5.6.3", "react": "^18.3.1", "lodash": "", "@types/node": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %v, want %v", got, want)
+	}
+
+	for _, m := range mismatches {
+		if m.Name == "lodash" && m.Changes() != nil {
+			t.Error("unpinned mismatches should not produce changes")
+		}
+	}
+}
+
+func TestFindMismatchesFiltersPackages(t *testing.T) {
+	ctx := loadContext(t, syncFixture(t))
+
+	got := mismatchTargets(FindMismatches(ctx, SyncOptions{Packages: []string{"react"}}))
+	if !reflect.DeepEqual(got, map[string]string{"react": "^18.3.1"}) {
+		t.Errorf("targets = %v", got)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if policy, err := ParsePolicy(""); err != nil || policy != PolicyHighest {
+		t.Errorf("ParsePolicy(\"\") = %q, %v", policy, err)
+	}
+	if policy, err := ParsePolicy("most-common"); err != nil || policy != PolicyMostCommon {
+		t.Errorf("ParsePolicy(most-common) = %q, %v", policy, err)
+	}
+	if _, err := ParsePolicy("newest"); err == nil {
+		t.Error("ParsePolicy(newest) should fail")
+	}
+}
@@ -0,0 +1,212 @@
+package deps
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// Target kinds: the highest version the declared range allows, or the latest dist-tag
+const (
+	TargetWanted = "wanted"
+	TargetLatest = "latest"
+)
+
+// Target is an outdated dependency together with a version to move it to
+type Target struct {
+	Outdated
+	Kind    string // TargetWanted or TargetLatest
+	Version string
+}
+
+// Bump classifies the target against the current version
+func (t Target) Bump() string {
+	return Bump(t.Current, t.Version)
+}
+
+// Change is a rewrite of one dependency specifier in one manifest
+type Change struct {
+	Manifest string // absolute package.json path
+	RelPath  string // package.json path relative to the root
+	Type     string
+	Name     string
+	From     string
+	To       string // "" removes the dependency
+}
+
+// bumpOrder groups targets: breaking updates first, then features and fixes
+var bumpOrder = map[string]int{
+	"major":      0,
+	"premajor":   1,
+	"minor":      2,
+	"preminor":   3,
+	"patch":      4,
+	"prepatch":   5,
+	"prerelease": 6,
+}
+
+// Targets lists the upgrade choices for outdated dependencies: the wanted
+// version and, when higher, the latest one. With latestOnly only the latest
+// is offered. Targets are grouped by bump type and sorted by name within a
+// group. Dependencies declared with a dist-tag or a wildcard are skipped,
+// since there is no range to rewrite.
+func Targets(outdated []Outdated, latestOnly bool) []Target {
+	var targets []Target
+	for _, o := range outdated {
+		if !rewritable(o.Spec) {
+			continue
+		}
+
+		add := func(kind string, version string) {
+			if version == "" || version == o.Current {
+				return
+			}
+			if o.Current != "" && semver.Valid(o.Current) && semver.Compare(version, o.Current) <= 0 {
+				return
+			}
+			targets = append(targets, Target{Outdated: o, Kind: kind, Version: version})
+		}
+
+		if !latestOnly {
+			add(TargetWanted, o.Wanted)
+		}
+		if latestOnly || o.Wanted == "" || semver.Compare(o.Latest, o.Wanted) > 0 {
+			add(TargetLatest, o.Latest)
+		}
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		bi, bj := bumpRank(targets[i].Bump()), bumpRank(targets[j].Bump())
+		if bi != bj {
+			return bi < bj
+		}
+		if targets[i].Name != targets[j].Name {
+			return targets[i].Name < targets[j].Name
+		}
+		return targets[i].RelDir < targets[j].RelDir
+	})
+	return targets
+}
+
+func bumpRank(bump string) int {
+	if rank, ok := bumpOrder[bump]; ok {
+		return rank
+	}
+	return len(bumpOrder)
+}
+
+// rewritable reports whether a specifier is a range UpdateSpec can move
+func rewritable(spec string) bool {
+	_, rng := splitNpmAlias(spec)
+	switch strings.TrimSpace(rng) {
+	case "", "*", "x", "X", "latest":
+		return false
+	}
+	return semver.ValidRange(rng)
+}
+
+var simpleRangePattern = regexp.MustCompile(`^(\^|~|>=|>|=)?v?\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]*)?$`)
+
+// UpdateSpec rewrites a specifier to require version while keeping its
+// style: "^1.2.0" becomes "^2.0.0", "~1.2.0" "~1.3.0", "1.2.0" "2.0.0" and
+// "npm:pkg@^1.0.0" "npm:pkg@^2.0.0". Other ranges become "^version";
+// dist-tags and wildcards are returned unchanged.
+func UpdateSpec(spec string, version string) string {
+	if !rewritable(spec) {
+		return spec
+	}
+
+	prefix, rng := splitNpmAlias(spec)
+	operator := "^"
+	if match := simpleRangePattern.FindStringSubmatch(strings.TrimSpace(rng)); match != nil {
+		operator = match[1]
+	}
+	return prefix + operator + version
+}
+
+// splitNpmAlias splits "npm:pkg@^1.0.0" into "npm:pkg@" and "^1.0.0"
+func splitNpmAlias(spec string) (string, string) {
+	if !strings.HasPrefix(spec, "npm:") {
+		return "", spec
+	}
+	dep := semver.ParseDependency("", spec)
+	return "npm:" + dep.Name + "@", dep.Range
+}
+
+// Plan turns selected targets into manifest changes. When several targets
+// pick the same dependency of the same manifest, the highest version wins.
+func Plan(targets []Target) []Change {
+	type key struct{ dir, depType, name string }
+
+	best := map[key]Target{}
+	var order []key
+	for _, t := range targets {
+		k := key{t.Dir, t.Type, t.Name}
+		current, seen := best[k]
+		if !seen {
+			order = append(order, k)
+		}
+		if !seen || semver.Compare(t.Version, current.Version) > 0 {
+			best[k] = t
+		}
+	}
+
+	var changes []Change
+	for _, k := range order {
+		t := best[k]
+		to := UpdateSpec(t.Spec, t.Version)
+		if to == t.Spec {
+			continue
+		}
+		changes = append(changes, Change{
+			Manifest: filepath.Join(t.Dir, "package.json"),
+			RelPath:  filepath.ToSlash(filepath.Join(t.RelDir, "package.json")),
+			Type:     t.Type,
+			Name:     t.Name,
+			From:     t.Spec,
+			To:       to,
+		})
+	}
+	return changes
+}
+
+// Apply writes changes to their manifests, preserving key order and
+// indentation. Each manifest is written once. Removing the last dependency
+// of a field removes the field.
+func Apply(changes []Change) error {
+	byManifest := map[string][]Change{}
+	var paths []string
+	for _, change := range changes {
+		if _, ok := byManifest[change.Manifest]; !ok {
+			paths = append(paths, change.Manifest)
+		}
+		byManifest[change.Manifest] = append(byManifest[change.Manifest], change)
+	}
+
+	for _, path := range paths {
+		manifest, err := context.ReadManifest(path)
+		if err != nil {
+			return err
+		}
+		for _, change := range byManifest[path] {
+			if change.To == "" {
+				manifest.Delete(change.Type, change.Name)
+				if len(manifest.Keys(change.Type)) == 0 {
+					manifest.Delete(change.Type)
+				}
+				continue
+			}
+			if err := manifest.Set([]string{change.Type, change.Name}, change.To); err != nil {
+				return err
+			}
+		}
+		if err := manifest.Write(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
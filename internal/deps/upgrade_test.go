@@ -0,0 +1,140 @@
+package deps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/registry"
+)
+
+func TestUpdateSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    string
+	}{
+		{"^1.2.0", "2.0.0", "^2.0.0"},
+		{"~1.2.0", "1.3.1", "~1.3.1"},
+		{"1.2.0", "2.0.0", "2.0.0"},
+		{">=1.2.0", "2.0.0", ">=2.0.0"},
+		{"v1.2.0", "1.2.1", "1.2.1"},
+		{"^1.0.0-beta.1", "1.0.0", "^1.0.0"},
+		{"1.x", "2.1.0", "^2.1.0"},
+		{">=1 <3", "3.0.0", "^3.0.0"},
+		{"npm:string-width@^4.2.0", "7.2.0", "npm:string-width@^7.2.0"},
+		{"*", "2.0.0", "*"},
+		{"latest", "2.0.0", "latest"},
+		{"next", "2.0.0", "next"},
+	}
+	for _, tt := range tests {
+		if got := UpdateSpec(tt.spec, tt.version); got != tt.want {
+			t.Errorf("UpdateSpec(%q, %q) = %q, want %q", tt.spec, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestTargets(t *testing.T) {
+	outdated := []Outdated{
+		{Declared: Declared{Name: "react", Spec: "^18.2.0", RelDir: "."}, Current: "18.2.0", Wanted: "18.3.1", Latest: "19.1.0"},
+		{Declared: Declared{Name: "lodash", Spec: "^4.17.0", RelDir: "."}, Current: "4.17.20", Wanted: "4.17.21", Latest: "4.17.21"},
+		{Declared: Declared{Name: "vite", Spec: "latest", RelDir: "."}, Current: "5.0.0", Wanted: "6.0.0", Latest: "6.0.0"},
+		{Declared: Declared{Name: "zod", Spec: "^3.0.0", RelDir: "."}, Current: "", Wanted: "3.23.8", Latest: "3.23.8"},
+	}
+
+	type row struct{ name, kind, version, bump string }
+	collect := func(targets []Target) []row {
+		var rows []row
+		for _, target := range targets {
+			rows = append(rows, row{target.Name, target.Kind, target.Version, target.Bump()})
+		}
+		return rows
+	}
+
+	got := collect(Targets(outdated, false))
+	want := []row{
+		{"react", TargetLatest, "19.1.0", "major"},
+		{"react", TargetWanted, "18.3.1", "minor"},
+		{"lodash", TargetWanted, "4.17.21", "patch"},
+		{"zod", TargetWanted, "3.23.8", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Targets = %+v, want %+v", got, want)
+	}
+
+	got = collect(Targets(outdated, true))
+	want = []row{
+		{"react", TargetLatest, "19.1.0", "major"},
+		{"lodash", TargetLatest, "4.17.21", "patch"},
+		{"zod", TargetLatest, "3.23.8", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Targets(latestOnly) = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	rootDir := loadContext(t, map[string]string{
+		"package.json":              "{\n    \"name\": \"root\",\n    \"dependencies\": {\n        \"react\": \"^18.2.0\",\n        \"lodash\": \"~4.17.0\"\n    }\n}\n",
+		"packages/app/package.json": `{"name": "app", "devDependencies": {"react": "^18.0.0"}}`,
+	}).RootDir
+
+	root := Declared{Type: "dependencies", Dir: rootDir, RelDir: "."}
+	app := Declared{Type: "devDependencies", Dir: filepath.Join(rootDir, "packages/app"), RelDir: "packages/app"}
+	withName := func(d Declared, name string, spec string) Declared {
+		d.Name, d.Spec = name, spec
+		return d
+	}
+
+	changes := Plan([]Target{
+		{Outdated: Outdated{Declared: withName(root, "react", "^18.2.0")}, Kind: TargetWanted, Version: "18.3.1"},
+		{Outdated: Outdated{Declared: withName(root, "react", "^18.2.0")}, Kind: TargetLatest, Version: "19.1.0"},
+		{Outdated: Outdated{Declared: withName(root, "lodash", "~4.17.0")}, Kind: TargetWanted, Version: "4.17.21"},
+		{Outdated: Outdated{Declared: withName(app, "react", "^18.0.0")}, Kind: TargetWanted, Version: "18.3.1"},
+	})
+
+	if len(changes) != 3 {
+		t.Fatalf("Plan returned %d changes, want 3: %+v", len(changes), changes)
+	}
+	if changes[0].To != "^19.1.0" || changes[2].RelPath != "packages/app/package.json" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+
+	if err := Apply(changes); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(rootDir, "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoot := "{\n    \"name\": \"root\",\n    \"dependencies\": {\n        \"react\": \"^19.1.0\",\n        \"lodash\": \"~4.17.21\"\n    }\n}\n"
+	if string(data) != wantRoot {
+		t.Errorf("root package.json =\n%s\nwant\n%s", data, wantRoot)
+	}
+
+	var appPkg struct {
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	data, _ = os.ReadFile(filepath.Join(rootDir, "packages/app/package.json"))
+	if err := json.Unmarshal(data, &appPkg); err != nil || appPkg.DevDependencies["react"] != "^18.3.1" {
+		t.Errorf("app package.json = %s", data)
+	}
+}
+
+func TestReleasesBetween(t *testing.T) {
+	var p registry.Packument
+	if err := json.Unmarshal([]byte(packuments["react"]), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Versions["19.0.0-rc.1"] = &registry.Manifest{Version: "19.0.0-rc.1"}
+
+	if got, want := releasesBetween(&p, "18.2.0", "19.1.0"), []string{"19.1.0", "18.3.1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("releasesBetween = %v, want %v", got, want)
+	}
+	if got, want := releasesBetween(&p, "", "18.3.1"), []string{"18.3.1", "18.2.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("releasesBetween(missing) = %v, want %v", got, want)
+	}
+}
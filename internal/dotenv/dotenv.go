@@ -0,0 +1,274 @@
+package dotenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Files returns the .env files loaded for mode, lowest precedence first:
+// .env, .env.local, .env.<mode> and .env.<mode>.local. Like Bun and Vite,
+// .env.local is skipped in test mode so tests don't depend on local setups.
+func Files(mode string) []string {
+	files := []string{".env"}
+	if mode != "test" {
+		files = append(files, ".env.local")
+	}
+	if mode != "" {
+		files = append(files, ".env."+mode, ".env."+mode+".local")
+	}
+	return files
+}
+
+// Mode returns the configured mode, falling back to NODE_ENV. It is empty
+// when neither is set, so no .env.<mode> files are picked: assuming
+// development would leak development values into production builds.
+func Mode(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	return os.Getenv("NODE_ENV")
+}
+
+// Env is the result of loading .env files
+type Env struct {
+	Vars  map[string]string
+	Files []string // files that were read, in load order
+}
+
+// Load reads the files in order, later files overriding earlier ones.
+// References are expanded against the process environment first, since it
+// takes precedence over .env files, and then against the variables loaded
+// so far. Missing files are skipped unless required is set.
+func Load(paths []string, required bool) (*Env, error) {
+	env := &Env{Vars: map[string]string{}}
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := env.Vars[key]
+		return value, ok
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) && !required {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := parse(string(data), lookup, func(key, value string) {
+			env.Vars[key] = value
+		}); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		env.Files = append(env.Files, path)
+	}
+	return env, nil
+}
+
+// Environ returns the loaded variables as sorted KEY=value pairs, leaving
+// out those already set in the process environment
+func (e *Env) Environ() []string {
+	var environ []string
+	for key, value := range e.Vars {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		environ = append(environ, key+"="+value)
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+// Paths joins the file names onto every directory, in order, skipping
+// repeated directories
+func Paths(dirs []string, files []string) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		for _, file := range files {
+			paths = append(paths, filepath.Join(dir, file))
+		}
+	}
+	return paths
+}
+
+// Parse reads the variables from .env file contents. References to
+// variables are expanded against earlier lines and then the process
+// environment.
+func Parse(data string) (map[string]string, error) {
+	vars := map[string]string{}
+	err := parse(data, func(key string) (string, bool) {
+		if value, ok := vars[key]; ok {
+			return value, true
+		}
+		return os.LookupEnv(key)
+	}, func(key, value string) {
+		vars[key] = value
+	})
+	return vars, err
+}
+
+// parse calls set for every KEY=value line in data. It understands
+// comments, `export` prefixes, single quotes (literal), double quotes and
+// backticks (with escapes, which may span lines) and trailing comments on
+// unquoted values.
+func parse(data string, lookup func(string) (string, bool), set func(key, value string)) error {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	line := 0
+	for len(data) > 0 {
+		var current string
+		current, data, _ = strings.Cut(data, "\n")
+		line++
+
+		trimmed := strings.TrimSpace(current)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		trimmed = strings.TrimPrefix(trimmed, "export ")
+
+		key, rest, ok := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !validKey(key) {
+			return fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		if rest != "" && strings.ContainsRune(`"'`+"`", rune(rest[0])) {
+			quote := rest[0]
+			// A quoted value may continue over the following lines
+			body := rest[1:]
+			for {
+				if end := closingQuote(body, quote); end >= 0 {
+					value = body[:end]
+					break
+				}
+				if data == "" {
+					return fmt.Errorf("line %d: unterminated %c quote", line, quote)
+				}
+				var next string
+				next, data, _ = strings.Cut(data, "\n")
+				line++
+				body += "\n" + next
+			}
+			if quote != '\'' {
+				value = expand(unescape(value), lookup)
+			}
+		} else {
+			if i := strings.Index(rest, " #"); i >= 0 {
+				rest = rest[:i]
+			}
+			value = expand(strings.TrimSpace(rest), lookup)
+		}
+		set(key, value)
+	}
+	return nil
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		if c != '_' && !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') && !(i > 0 && c >= '0' && c <= '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// closingQuote returns the index of the unescaped closing quote, or -1
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && quote != '\'' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescape handles the escapes allowed in double quotes. Escaped dollar
+// signs are kept escaped so expand leaves them alone.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '$':
+			b.WriteString(`\$`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// expand replaces $VAR, ${VAR} and ${VAR:-default} references. Unset
+// variables expand to an empty string and \$ is a literal dollar sign.
+func expand(s string, lookup func(string) (string, bool)) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteByte('$')
+			i++
+		case s[i] != '$' || i+1 == len(s):
+			b.WriteByte(s[i])
+		case s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			name, fallback, hasFallback := strings.Cut(s[i+2:i+end], ":-")
+			if value, ok := lookup(name); ok && value != "" {
+				b.WriteString(value)
+			} else if hasFallback {
+				b.WriteString(expand(fallback, lookup))
+			}
+			i += end
+		default:
+			end := i + 1
+			for end < len(s) && (s[end] == '_' || s[end] >= 'A' && s[end] <= 'Z' || s[end] >= 'a' && s[end] <= 'z' || s[end] >= '0' && s[end] <= '9') {
+				end++
+			}
+			if end == i+1 {
+				b.WriteByte('$')
+				continue
+			}
+			value, _ := lookup(s[i+1 : end])
+			b.WriteString(value)
+			i = end - 1
+		}
+	}
+	return b.String()
+}
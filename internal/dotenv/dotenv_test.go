@@ -0,0 +1,124 @@
+package dotenv
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("DOTENV_TEST_HOME", "/home/me")
+
+	vars, err := Parse(`# comment
+export NAME=app
+PORT = 3000 # trailing comment
+HASH=a#b
+EMPTY=
+SINGLE='literal $NAME\n'
+DOUBLE="line1\nline2 $NAME"
+MULTI="first
+second"
+BACKTICK=` + "`it's \"quoted\"`" + `
+URL=http://localhost:${PORT}/api
+DIR=$DOTENV_TEST_HOME/app
+DEFAULT=${MISSING:-fallback-$NAME}
+ESCAPED="cost \$5"
+`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := map[string]string{
+		"NAME":     "app",
+		"PORT":     "3000",
+		"HASH":     "a#b",
+		"EMPTY":    "",
+		"SINGLE":   `literal $NAME\n`,
+		"DOUBLE":   "line1\nline2 app",
+		"MULTI":    "first\nsecond",
+		"BACKTICK": `it's "quoted"`,
+		"URL":      "http://localhost:3000/api",
+		"DIR":      "/home/me/app",
+		"DEFAULT":  "fallback-app",
+		"ESCAPED":  "cost $5",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("Parse =\n%q\nwant\n%q", vars, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{"NOT A LINE", "1KEY=x", `KEY="unterminated`} {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Parse(%q) should fail", data)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	if got := Files("production"); !reflect.DeepEqual(got, []string{".env", ".env.local", ".env.production", ".env.production.local"}) {
+		t.Errorf("Files(production) = %v", got)
+	}
+	if got := Files("test"); !reflect.DeepEqual(got, []string{".env", ".env.test", ".env.test.local"}) {
+		t.Errorf("Files(test) = %v", got)
+	}
+
+	if got := Files(""); !reflect.DeepEqual(got, []string{".env", ".env.local"}) {
+		t.Errorf("Files() = %v", got)
+	}
+
+	t.Setenv("NODE_ENV", "")
+	if got := Mode(""); got != "" {
+		t.Errorf("Mode = %q, want none", got)
+	}
+	t.Setenv("NODE_ENV", "production")
+	if got := Mode(""); got != "production" {
+		t.Errorf("Mode = %q, want NODE_ENV", got)
+	}
+	if got := Mode("staging"); got != "staging" {
+		t.Errorf("Mode = %q, want the configured mode", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("DOTENV_TEST_SHELL", "from-shell")
+
+	root := t.TempDir()
+	pkg := filepath.Join(root, "packages", "app")
+	writeFile(t, filepath.Join(root, ".env"), "API=https://api\nLEVEL=root\nDOTENV_TEST_SHELL=from-file\n")
+	writeFile(t, filepath.Join(root, ".env.local"), "LEVEL=root-local\n")
+	writeFile(t, filepath.Join(pkg, ".env.development"), "LEVEL=pkg-dev\nURL=${API}/v1\nSHELL_REF=$DOTENV_TEST_SHELL\n")
+
+	paths := Paths([]string{root, pkg, root}, Files("development"))
+	if len(paths) != 8 {
+		t.Fatalf("Paths = %v", paths)
+	}
+
+	env, err := Load(paths, false)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(env.Files) != 3 {
+		t.Errorf("Files = %v, want the 3 existing files", env.Files)
+	}
+
+	want := []string{"API=https://api", "LEVEL=pkg-dev", "SHELL_REF=from-shell", "URL=https://api/v1"}
+	if got := env.Environ(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Environ = %v, want %v", got, want)
+	}
+
+	if _, err := Load([]string{filepath.Join(root, ".env.missing")}, true); err == nil {
+		t.Error("Load should fail on a missing required file")
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,294 @@
+package engines
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/lockfile"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/semver"
+	"github.com/AkaraChen/gnpm/internal/toolchain"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// Options controls the engines and platform check
+type Options struct {
+	Strict        bool // fail instead of warning
+	CheckPlatform bool // also check os/cpu/libc of direct dependencies
+	Verbose       bool
+}
+
+// Problem is a single engines or platform incompatibility
+type Problem struct {
+	Manifest string // package.json path relative to the project root
+	Package  string // package name, or dependency name@version for platform problems
+	Field    string // engines.node, engines.pnpm, os, cpu or libc
+	Wanted   string
+	Actual   string
+}
+
+// String formats the problem for display
+func (p Problem) String() string {
+	if strings.HasPrefix(p.Field, "engines.") {
+		return fmt.Sprintf("%s (%s): %s %s required, found %s", p.Package, p.Manifest, p.Field, p.Wanted, p.Actual)
+	}
+	return fmt.Sprintf("%s (%s): %s %s does not include %s", p.Package, p.Manifest, p.Field, p.Wanted, p.Actual)
+}
+
+// Report is the result of an engines check
+type Report struct {
+	NodeVersion string
+	PMVersion   string
+	Problems    []Problem
+}
+
+// versions are the runtime and package manager versions a check compares against
+type versions struct {
+	Node string
+	PM   string
+}
+
+// platform describes the machine dependencies are installed on, using npm's names
+type platform struct {
+	OS   string
+	CPU  string
+	Libc string
+}
+
+var detectVersions = detectVersionsFromSystem
+var currentPlatform = detectPlatform
+
+// Run checks engines (and optionally platforms), printing a report.
+// It returns an error only in strict mode when problems were found.
+func Run(ctx *context.ProjectContext, opts Options) error {
+	if ctx == nil || ctx.PackageJSON == nil {
+		return nil
+	}
+
+	report := Check(ctx, opts)
+	if len(report.Problems) == 0 {
+		return nil
+	}
+
+	logger.Warn("engines check found %d problem(s):", len(report.Problems))
+	for _, problem := range report.Problems {
+		logger.List(problem.String())
+	}
+
+	if opts.Strict {
+		return fmt.Errorf("engine-strict: %d incompatible engines or platforms", len(report.Problems))
+	}
+	return nil
+}
+
+// constraint is an engines entry for Node.js or the package manager
+type constraint struct {
+	importer workspace.Importer
+	engine   string
+	wanted   string
+}
+
+// Check compares the engines fields of the root and workspace manifests against
+// the detected Node.js and package manager versions. Versions are only
+// detected for the engines some manifest declares.
+func Check(ctx *context.ProjectContext, opts Options) Report {
+	var report Report
+
+	manifests := workspace.Importers(ctx)
+	pmName := ctx.PackageManager.Executable()
+
+	var constraints []constraint
+	declared := map[string]bool{}
+	for _, m := range manifests {
+		for _, engine := range []string{"node", pmName} {
+			if wanted := strings.TrimSpace(m.PackageJSON.Engines[engine]); wanted != "" {
+				constraints = append(constraints, constraint{importer: m, engine: engine, wanted: wanted})
+				declared[engine] = true
+			}
+		}
+	}
+
+	if len(constraints) > 0 {
+		detected := detectVersions(ctx, declared["node"], declared[pmName])
+		report.NodeVersion, report.PMVersion = detected.Node, detected.PM
+		actual := map[string]string{"node": detected.Node, pmName: detected.PM}
+
+		for _, c := range constraints {
+			version := actual[c.engine]
+			if version == "" || satisfiesEngine(version, c.wanted) {
+				continue
+			}
+			report.Problems = append(report.Problems, Problem{
+				Manifest: c.importer.ManifestPath(),
+				Package:  packageLabel(c.importer.PackageJSON),
+				Field:    "engines." + c.engine,
+				Wanted:   c.wanted,
+				Actual:   version,
+			})
+		}
+	}
+
+	if opts.CheckPlatform {
+		report.Problems = append(report.Problems, checkPlatforms(ctx, manifests, opts)...)
+	}
+
+	return report
+}
+
+// checkPlatforms checks os/cpu/libc of direct dependencies recorded in the lockfile
+func checkPlatforms(ctx *context.ProjectContext, manifests []workspace.Importer, opts Options) []Problem {
+	lock, err := lockfile.Read(ctx.RootDir, ctx.PackageManager)
+	if err != nil {
+		if opts.Verbose {
+			logger.Warn("platform check skipped: %v", err)
+		}
+		return nil
+	}
+
+	current := currentPlatform()
+	var problems []Problem
+
+	for _, m := range manifests {
+		for _, deps := range []map[string]string{m.PackageJSON.Dependencies, m.PackageJSON.DevDependencies} {
+			names := make([]string, 0, len(deps))
+			for name := range deps {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				version := lock.Version(m.RelDir, name, deps[name])
+				locked, ok := lock.Find(name, version)
+				if !ok {
+					continue
+				}
+
+				for _, field := range []struct {
+					name   string
+					wanted []string
+					actual string
+				}{
+					{"os", locked.OS, current.OS},
+					{"cpu", locked.CPU, current.CPU},
+					{"libc", locked.Libc, current.Libc},
+				} {
+					if field.actual == "" || matchesPlatform(field.wanted, field.actual) {
+						continue
+					}
+					problems = append(problems, Problem{
+						Manifest: m.ManifestPath(),
+						Package:  name + "@" + version,
+						Field:    field.name,
+						Wanted:   "[" + strings.Join(field.wanted, ", ") + "]",
+						Actual:   field.actual,
+					})
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// matchesPlatform applies npm's os/cpu/libc semantics, including "!value" negations
+func matchesPlatform(wanted []string, actual string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+
+	allowed := false
+	hasPositive := false
+	for _, value := range wanted {
+		if negated := strings.TrimPrefix(value, "!"); negated != value {
+			if negated == actual {
+				return false
+			}
+			continue
+		}
+		hasPositive = true
+		if value == actual {
+			allowed = true
+		}
+	}
+	return allowed || !hasPositive
+}
+
+// satisfiesEngine checks a version against an engines range. Like npm,
+// prerelease runtimes (e.g. Node.js nightlies) are treated as releases.
+// Ranges that don't parse are not enforced.
+func satisfiesEngine(version string, rng string) bool {
+	v, err := semver.Parse(version)
+	if err != nil {
+		return true
+	}
+	r, err := semver.ParseRange(rng)
+	if err != nil {
+		return true
+	}
+	return r.ContainsPrerelease(v)
+}
+
+func packageLabel(pkg *context.PackageJSON) string {
+	if pkg.Name == "" {
+		return "(unnamed)"
+	}
+	return pkg.Name
+}
+
+// detectVersionsFromSystem finds the Node.js version scripts run with and the
+// package manager version that will run the install, when asked for
+func detectVersionsFromSystem(ctx *context.ProjectContext, node bool, pm bool) versions {
+	var detected versions
+
+	if node {
+		if req, ok := toolchain.DetectNodeRequirement(ctx.RootDir); ok {
+			if installation, err := toolchain.ResolveNode(req, ctx.RootDir); err == nil {
+				detected.Node = installation.Version
+			}
+		}
+		if detected.Node == "" {
+			detected.Node, _ = toolchain.ProbeVersion("node", ctx.RootDir)
+		}
+	}
+
+	if !pm {
+		return detected
+	}
+	if spec := ctx.PackageManagerSpec; spec != nil && semver.Valid(spec.Version) {
+		detected.PM = spec.Version
+	} else {
+		detected.PM, _ = toolchain.ProbeVersion(ctx.PackageManager.Executable(), ctx.RootDir)
+	}
+
+	return detected
+}
+
+// detectPlatform returns the current platform using Node.js names
+func detectPlatform() platform {
+	current := platform{OS: runtime.GOOS, CPU: runtime.GOARCH}
+
+	switch runtime.GOOS {
+	case "windows":
+		current.OS = "win32"
+	case "linux":
+		current.Libc = "glibc"
+		if matches, _ := filepath.Glob("/lib/ld-musl-*"); len(matches) > 0 {
+			current.Libc = "musl"
+		}
+	}
+
+	switch runtime.GOARCH {
+	case "amd64":
+		current.CPU = "x64"
+	case "386":
+		current.CPU = "ia32"
+	case "ppc64le":
+		current.CPU = "ppc64"
+	}
+
+	return current
+}
@@ -0,0 +1,158 @@
+package engines
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       pmcombo.PackageManager
+		files    map[string]string
+		detected *versions // nil when nothing should be detected
+		platform platform
+		opts     Options
+		expected []string
+	}{
+		{
+			name: "incompatible engines",
+			pm:   pmcombo.PNPM,
+			files: map[string]string{
+				"package.json":              `{"name": "root", "workspaces": ["packages/*"], "engines": {"node": ">=20", "pnpm": "^10.0.0"}}`,
+				"packages/app/package.json": `{"name": "app", "engines": {"node": "^18 || ^22"}}`,
+			},
+			detected: &versions{Node: "20.11.0", PM: "9.15.0"},
+			expected: []string{
+				"app (packages/app/package.json): engines.node ^18 || ^22 required, found 20.11.0",
+				"root (package.json): engines.pnpm ^10.0.0 required, found 9.15.0",
+			},
+		},
+		{
+			name:     "other package managers and unknown versions",
+			pm:       pmcombo.NPM,
+			files:    map[string]string{"package.json": `{"name": "root", "engines": {"node": ">=22", "yarn": ">=4", "npm": ">=10"}}`},
+			detected: &versions{Node: "", PM: "10.8.0"},
+		},
+		{
+			name:  "no node or package manager engines",
+			pm:    pmcombo.NPM,
+			files: map[string]string{"package.json": `{"name": "root", "engines": {"yarn": ">=4"}}`},
+		},
+		{
+			name: "platforms from the lockfile",
+			pm:   pmcombo.NPM,
+			files: map[string]string{
+				"package.json": `{
+  "name": "root",
+  "dependencies": {"fsevents": "^2.3.0", "left-pad": "^1.3.0"},
+  "devDependencies": {"@esbuild/linux-x64": "0.20.0", "no-musl": "1.0.0"}
+}`,
+				"package-lock.json": `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "root"},
+    "node_modules/fsevents": {"version": "2.3.3", "os": ["darwin"]},
+    "node_modules/left-pad": {"version": "1.3.0"},
+    "node_modules/@esbuild/linux-x64": {"version": "0.20.0", "os": ["linux"], "cpu": ["x64"]},
+    "node_modules/no-musl": {"version": "1.0.0", "libc": ["!musl"]}
+  }
+}`,
+			},
+			platform: platform{OS: "linux", CPU: "arm64", Libc: "musl"},
+			opts:     Options{CheckPlatform: true},
+			expected: []string{
+				"@esbuild/linux-x64@0.20.0 (package.json): cpu [x64] does not include arm64",
+				"fsevents@2.3.3 (package.json): os [darwin] does not include linux",
+				"no-musl@1.0.0 (package.json): libc [!musl] does not include musl",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalVersions, originalPlatform := detectVersions, currentPlatform
+			t.Cleanup(func() { detectVersions, currentPlatform = originalVersions, originalPlatform })
+			detectVersions = func(*context.ProjectContext, bool, bool) versions {
+				if tt.detected == nil {
+					t.Error("versions detected without node or package manager engines")
+					return versions{}
+				}
+				return *tt.detected
+			}
+			currentPlatform = func() platform { return tt.platform }
+
+			var got []string
+			for _, problem := range Check(loadContext(t, tt.pm, tt.files), tt.opts).Problems {
+				got = append(got, problem.String())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("problems =\n%q\nexpected\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunFailsInStrictMode(t *testing.T) {
+	original := detectVersions
+	t.Cleanup(func() { detectVersions = original })
+	detectVersions = func(*context.ProjectContext, bool, bool) versions { return versions{Node: "18.20.0"} }
+
+	ctx := loadContext(t, pmcombo.NPM, map[string]string{"package.json": `{"name": "root", "engines": {"node": ">=22"}}`})
+	if err := Run(ctx, Options{}); err != nil {
+		t.Fatalf("expected a warning only, got %v", err)
+	}
+	if err := Run(ctx, Options{Strict: true}); err == nil {
+		t.Fatal("expected strict mode to fail")
+	}
+}
+
+func TestMatchesPlatform(t *testing.T) {
+	tests := []struct {
+		wanted []string
+		actual string
+		want   bool
+	}{
+		{nil, "linux", true},
+		{[]string{"linux", "darwin"}, "linux", true},
+		{[]string{"darwin"}, "linux", false},
+		{[]string{"!win32"}, "linux", true},
+		{[]string{"!win32"}, "win32", false},
+		{[]string{"linux", "!linux"}, "linux", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesPlatform(tt.wanted, tt.actual); got != tt.want {
+			t.Errorf("matchesPlatform(%v, %q) = %v, want %v", tt.wanted, tt.actual, got, tt.want)
+		}
+	}
+}
+
+// loadContext writes the files into a new project using pm
+func loadContext(t *testing.T, pm pmcombo.PackageManager, files map[string]string) *context.ProjectContext {
+	t.Helper()
+
+	rootDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(rootDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pkg, err := context.ReadPackageJSON(filepath.Join(rootDir, "package.json"))
+	if err != nil {
+		t.Fatalf("read package.json: %v", err)
+	}
+	return &context.ProjectContext{RootDir: rootDir, PackageManager: pm, PackageJSON: pkg}
+}
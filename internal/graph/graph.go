@@ -0,0 +1,381 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// Edge is a dependency of one workspace package on another
+type Edge struct {
+	From string
+	To   string
+	Type string
+	Spec string
+}
+
+// Dev reports whether the edge is a devDependency
+func (e Edge) Dev() bool {
+	return e.Type == "devDependencies"
+}
+
+// Graph is the dependency graph between the packages of a workspace
+type Graph struct {
+	RootDir  string
+	Packages []workspace.Package // sorted by name
+	Edges    []Edge              // sorted by From, then To
+}
+
+// Load builds the graph of the workspace at rootDir
+func Load(rootDir string) (*Graph, error) {
+	packages, err := workspace.FindPackages(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("no workspace packages found in %s", rootDir)
+	}
+	return New(rootDir, packages), nil
+}
+
+// New builds a graph from workspace packages. Packages without a name
+// can't be depended on and are left out, as are npm: aliases that only
+// share a name with a workspace package.
+func New(rootDir string, packages []workspace.Package) *Graph {
+	g := &Graph{RootDir: rootDir}
+	names := map[string]bool{}
+	for _, pkg := range packages {
+		if pkg.Name != "" && !names[pkg.Name] {
+			names[pkg.Name] = true
+			g.Packages = append(g.Packages, pkg)
+		}
+	}
+	sort.Slice(g.Packages, func(i, j int) bool { return g.Packages[i].Name < g.Packages[j].Name })
+
+	for _, pkg := range g.Packages {
+		edges := map[string]Edge{}
+		for _, dep := range pkg.Dependencies {
+			if !names[dep.Name] || strings.HasPrefix(dep.Spec, "npm:") {
+				continue
+			}
+			// A package declared twice keeps its strongest type
+			if existing, ok := edges[dep.Name]; ok && slices.Index(workspace.DependencyTypes, existing.Type) <= slices.Index(workspace.DependencyTypes, dep.Type) {
+				continue
+			}
+			edges[dep.Name] = Edge{From: pkg.Name, To: dep.Name, Type: dep.Type, Spec: dep.Spec}
+		}
+		for _, edge := range edges {
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+	g.sortEdges()
+	return g
+}
+
+func (g *Graph) sortEdges() {
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
+
+// Package returns the package with the given name
+func (g *Graph) Package(name string) (workspace.Package, bool) {
+	for _, pkg := range g.Packages {
+		if pkg.Name == name {
+			return pkg, true
+		}
+	}
+	return workspace.Package{}, false
+}
+
+// WithoutDev returns the graph without devDependencies edges
+func (g *Graph) WithoutDev() *Graph {
+	return g.filter(func(e Edge) bool { return !e.Dev() }, nil)
+}
+
+// Focus returns the part of the graph around one package: everything it
+// depends on, directly or not, and everything that depends on it
+func (g *Graph) Focus(name string) (*Graph, error) {
+	if _, ok := g.Package(name); !ok {
+		return nil, fmt.Errorf("no workspace package named %q", name)
+	}
+
+	upstream := g.reachable(name, func(e Edge) (string, string) { return e.From, e.To })
+	downstream := g.reachable(name, func(e Edge) (string, string) { return e.To, e.From })
+
+	keep := func(e Edge) bool {
+		return ((e.From == name || upstream[e.From]) && upstream[e.To]) ||
+			(downstream[e.From] && (e.To == name || downstream[e.To]))
+	}
+	nodes := map[string]bool{name: true}
+	for n := range upstream {
+		nodes[n] = true
+	}
+	for n := range downstream {
+		nodes[n] = true
+	}
+	return g.filter(keep, nodes), nil
+}
+
+// Dependencies returns the packages name depends on, directly or not,
+// sorted by name
+func (g *Graph) Dependencies(name string) []workspace.Package {
+	return g.packagesIn(g.reachable(name, func(e Edge) (string, string) { return e.From, e.To }), name)
+}
+
+// Dependents returns the packages depending on name, directly or not,
+// sorted by name
+func (g *Graph) Dependents(name string) []workspace.Package {
+	return g.packagesIn(g.reachable(name, func(e Edge) (string, string) { return e.To, e.From }), name)
+}
+
+// Order sorts packages so each comes after those of them it depends on,
+// falling back to name order where dependencies form a cycle
+func (g *Graph) Order(packages []workspace.Package) []workspace.Package {
+	remaining := append([]workspace.Package(nil), packages...)
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i].Name < remaining[j].Name })
+
+	pending := map[string]bool{}
+	for _, pkg := range remaining {
+		pending[pkg.Name] = true
+	}
+	forward := func(e Edge) (string, string) { return e.From, e.To }
+	// ready reports whether name's pending dependencies are all done or,
+	// with inCycle, all on a cycle through name
+	ready := func(name string, inCycle bool) bool {
+		for _, e := range g.Edges {
+			if e.From != name || e.To == name || !pending[e.To] {
+				continue
+			}
+			if !inCycle || !g.reachable(e.To, forward)[name] {
+				return false
+			}
+		}
+		return true
+	}
+
+	ordered := make([]workspace.Package, 0, len(remaining))
+	for len(remaining) > 0 {
+		next := -1
+		for _, inCycle := range []bool{false, true} {
+			for i, pkg := range remaining {
+				if ready(pkg.Name, inCycle) {
+					next = i
+					break
+				}
+			}
+			if next >= 0 {
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+		}
+		ordered = append(ordered, remaining[next])
+		delete(pending, remaining[next].Name)
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return ordered
+}
+
+// packagesIn returns the packages in set other than exclude
+func (g *Graph) packagesIn(set map[string]bool, exclude string) []workspace.Package {
+	var packages []workspace.Package
+	for _, pkg := range g.Packages {
+		if set[pkg.Name] && pkg.Name != exclude {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// reachable returns the packages reachable from start, following edges in
+// the direction given by ends; start itself is only included when it lies
+// on a cycle
+func (g *Graph) reachable(start string, ends func(Edge) (string, string)) map[string]bool {
+	next := map[string][]string{}
+	for _, e := range g.Edges {
+		from, to := ends(e)
+		next[from] = append(next[from], to)
+	}
+
+	seen := map[string]bool{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, n := range next[current] {
+			if !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return seen
+}
+
+// filter keeps the edges accepted by keep and, when nodes is set, only
+// those packages
+func (g *Graph) filter(keep func(Edge) bool, nodes map[string]bool) *Graph {
+	filtered := &Graph{RootDir: g.RootDir}
+	for _, pkg := range g.Packages {
+		if nodes == nil || nodes[pkg.Name] {
+			filtered.Packages = append(filtered.Packages, pkg)
+		}
+	}
+	for _, e := range g.Edges {
+		if keep(e) && (nodes == nil || (nodes[e.From] && nodes[e.To])) {
+			filtered.Edges = append(filtered.Edges, e)
+		}
+	}
+	return filtered
+}
+
+// Cycles finds the dependency cycles of the graph: one per strongly
+// connected component, as the shortest path from its first package back to
+// itself. A cycle [a b] means a depends on b and b on a.
+func (g *Graph) Cycles() [][]string {
+	next := map[string][]string{}
+	for _, e := range g.Edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+
+	var cycles [][]string
+	for _, component := range g.components(next) {
+		if len(component) == 1 && !contains(next[component[0]], component[0]) {
+			continue
+		}
+		cycles = append(cycles, shortestCycle(component, next))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// components returns the strongly connected components of the graph with
+// Tarjan's algorithm, each sorted by name
+func (g *Graph) components(next map[string][]string) [][]string {
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var components [][]string
+
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		lowLink[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, m := range next[n] {
+			if _, visited := index[m]; !visited {
+				visit(m)
+				lowLink[n] = min(lowLink[n], lowLink[m])
+			} else if onStack[m] {
+				lowLink[n] = min(lowLink[n], index[m])
+			}
+		}
+
+		if lowLink[n] == index[n] {
+			var component []string
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				component = append(component, m)
+				if m == n {
+					break
+				}
+			}
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, pkg := range g.Packages {
+		if _, visited := index[pkg.Name]; !visited {
+			visit(pkg.Name)
+		}
+	}
+	return components
+}
+
+// shortestCycle finds the shortest path from the first package of a
+// strongly connected component back to itself
+func shortestCycle(component []string, next map[string][]string) []string {
+	start := component[0]
+	inComponent := map[string]bool{}
+	for _, n := range component {
+		inComponent[n] = true
+	}
+
+	previous := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, n := range next[current] {
+			if n == start {
+				path := []string{current}
+				for path[0] != start {
+					path = append([]string{previous[path[0]]}, path...)
+				}
+				return path
+			}
+			if _, seen := previous[n]; !seen && inComponent[n] {
+				previous[n] = current
+				queue = append(queue, n)
+			}
+		}
+	}
+	return component
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatCycle renders a cycle as "a → b → a"
+func FormatCycle(cycle []string) string {
+	return strings.Join(append(append([]string{}, cycle...), cycle[0]), " → ")
+}
+
+// cycleEdges returns the edges and packages lying on a cycle, for
+// highlighting
+func (g *Graph) cycleEdges() (map[[2]string]bool, map[string]bool) {
+	next := map[string][]string{}
+	for _, e := range g.Edges {
+		next[e.From] = append(next[e.From], e.To)
+	}
+
+	componentOf := map[string]int{}
+	nodes := map[string]bool{}
+	for i, component := range g.components(next) {
+		for _, n := range component {
+			componentOf[n] = i
+			if len(component) > 1 {
+				nodes[n] = true
+			}
+		}
+	}
+
+	edges := map[[2]string]bool{}
+	for _, e := range g.Edges {
+		if e.From == e.To || (nodes[e.From] && componentOf[e.From] == componentOf[e.To]) {
+			edges[[2]string{e.From, e.To}] = true
+			nodes[e.From] = true
+		}
+	}
+	return edges, nodes
+}
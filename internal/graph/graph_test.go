@@ -0,0 +1,198 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// pkg builds a package depending on others; a "dev:" prefix makes a
+// devDependency
+func pkg(name string, deps ...string) workspace.Package {
+	p := workspace.Package{Name: name, Dir: "/repo/packages/" + name}
+	for _, dep := range deps {
+		depType := "dependencies"
+		if trimmed, ok := strings.CutPrefix(dep, "dev:"); ok {
+			dep, depType = trimmed, "devDependencies"
+		}
+		p.Dependencies = append(p.Dependencies, workspace.Dependency{Name: dep, Spec: "workspace:*", Type: depType})
+	}
+	return p
+}
+
+func edgeList(g *Graph) []string {
+	var edges []string
+	for _, e := range g.Edges {
+		edges = append(edges, e.From+">"+e.To)
+	}
+	return edges
+}
+
+func TestNew(t *testing.T) {
+	g := New("/repo", []workspace.Package{
+		pkg("web", "ui", "react", "dev:utils"),
+		pkg("ui", "utils"),
+		pkg("utils"),
+		{Name: "aliased", Dependencies: []workspace.Dependency{{Name: "ui", Spec: "npm:ui@1", Type: "dependencies"}}},
+		{Name: "", Dir: "/repo/unnamed"},
+	})
+
+	if got := edgeList(g); !reflect.DeepEqual(got, []string{"ui>utils", "web>ui", "web>utils"}) {
+		t.Errorf("Edges = %v", got)
+	}
+	if len(g.Packages) != 4 {
+		t.Errorf("Packages = %d, want 4 named packages", len(g.Packages))
+	}
+	if got := edgeList(g.WithoutDev()); !reflect.DeepEqual(got, []string{"ui>utils", "web>ui"}) {
+		t.Errorf("WithoutDev edges = %v", got)
+	}
+
+	// A package declared twice keeps its strongest type
+	twice := New("/repo", []workspace.Package{
+		{Name: "a", Dependencies: []workspace.Dependency{
+			{Name: "b", Spec: "*", Type: "devDependencies"},
+			{Name: "b", Spec: "^1.0.0", Type: "peerDependencies"},
+		}},
+		pkg("b"),
+	})
+	if e := twice.Edges[0]; e.Type != "peerDependencies" || e.Spec != "^1.0.0" {
+		t.Errorf("edge = %+v, want the peer dependency", e)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	g := New("/repo", []workspace.Package{
+		pkg("a", "b"),
+		pkg("b", "c"),
+		pkg("c", "a", "d"),
+		pkg("d"),
+		pkg("self", "self"),
+		pkg("x", "y"),
+		pkg("y", "x"),
+	})
+
+	want := [][]string{{"a", "b", "c"}, {"self"}, {"x", "y"}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cycles = %v, want %v", got, want)
+	}
+	if got := FormatCycle(want[0]); got != "a → b → c → a" {
+		t.Errorf("FormatCycle = %q", got)
+	}
+
+	if cycles := New("/repo", []workspace.Package{pkg("a", "b"), pkg("b")}).Cycles(); len(cycles) != 0 {
+		t.Errorf("Cycles of an acyclic graph = %v", cycles)
+	}
+}
+
+func TestFocus(t *testing.T) {
+	g := New("/repo", []workspace.Package{
+		pkg("app", "ui"),
+		pkg("docs", "ui"),
+		pkg("ui", "tokens"),
+		pkg("tokens"),
+		pkg("cli", "utils"),
+		pkg("utils"),
+	})
+
+	focused, err := g.Focus("ui")
+	if err != nil {
+		t.Fatalf("Focus failed: %v", err)
+	}
+	var names []string
+	for _, p := range focused.Packages {
+		names = append(names, p.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"app", "docs", "tokens", "ui"}) {
+		t.Errorf("Packages = %v", names)
+	}
+	if got := edgeList(focused); !reflect.DeepEqual(got, []string{"app>ui", "docs>ui", "ui>tokens"}) {
+		t.Errorf("Edges = %v", got)
+	}
+
+	if _, err := g.Focus("missing"); err == nil {
+		t.Error("Focus on an unknown package should fail")
+	}
+}
+
+func TestDependenciesAndDependents(t *testing.T) {
+	g := New("/repo", []workspace.Package{
+		pkg("app", "ui", "dev:utils"),
+		pkg("ui", "tokens"),
+		pkg("tokens", "ui"),
+		pkg("utils"),
+	})
+
+	names := func(packages []workspace.Package) []string {
+		var list []string
+		for _, p := range packages {
+			list = append(list, p.Name)
+		}
+		return list
+	}
+	if got := names(g.Dependencies("app")); !reflect.DeepEqual(got, []string{"tokens", "ui", "utils"}) {
+		t.Errorf("Dependencies(app) = %v", got)
+	}
+	// A cycle doesn't make a package its own dependency
+	if got := names(g.Dependencies("ui")); !reflect.DeepEqual(got, []string{"tokens"}) {
+		t.Errorf("Dependencies(ui) = %v", got)
+	}
+	if got := names(g.Dependents("utils")); !reflect.DeepEqual(got, []string{"app"}) {
+		t.Errorf("Dependents(utils) = %v", got)
+	}
+	if got := g.Dependents("app"); len(got) != 0 {
+		t.Errorf("Dependents(app) = %v", names(got))
+	}
+
+	// app comes last; the ui/tokens cycle falls back to name order
+	order := g.Order([]workspace.Package{pkg("app"), pkg("ui"), pkg("utils"), pkg("tokens")})
+	if got := names(order); !reflect.DeepEqual(got, []string{"utils", "tokens", "ui", "app"}) {
+		t.Errorf("Order = %v", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := New("/repo", []workspace.Package{
+		pkg("a", "b"),
+		pkg("b", "dev:a"),
+		pkg("c", "a"),
+	})
+
+	dot, _ := g.Render(FormatDOT)
+	for _, line := range []string{`"a" [color=red];`, `"a" -> "b" [color=red];`, `"b" -> "a" [style=dashed, color=red];`, `"c" -> "a";`} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT is missing %s:\n%s", line, dot)
+		}
+	}
+
+	mermaid, _ := g.Render(FormatMermaid)
+	for _, line := range []string{`n0["a"]`, "n0 --> n1", "n1 -.-> n0", "class n0,n1 cycle", "linkStyle 0,1 stroke"} {
+		if !strings.Contains(mermaid, line) {
+			t.Errorf("Mermaid is missing %s:\n%s", line, mermaid)
+		}
+	}
+
+	text, _ := g.Render(FormatText)
+	if !strings.Contains(text, "b (packages/b)\n  → a (devDependencies) [cycle]\n") {
+		t.Errorf("Text =\n%s", text)
+	}
+
+	data, _ := g.Render(FormatJSON)
+	var parsed struct {
+		Packages []struct{ Name, Path string }
+		Edges    []struct{ From, To, Type string }
+		Cycles   [][]string
+	}
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(parsed.Packages) != 3 || parsed.Packages[0].Path != "packages/a" || len(parsed.Edges) != 3 || len(parsed.Cycles) != 1 {
+		t.Errorf("JSON = %s", data)
+	}
+
+	if _, err := g.Render("svg"); err == nil {
+		t.Error("Render should reject unknown formats")
+	}
+}
@@ -0,0 +1,184 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Formats supported by Render
+const (
+	FormatText    = "text"
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// Render renders the graph in one of the formats. Packages and edges on a
+// dependency cycle are highlighted, and devDependencies drawn dashed.
+func (g *Graph) Render(format string) (string, error) {
+	switch format {
+	case FormatText, "":
+		return g.Text(), nil
+	case FormatDOT:
+		return g.DOT(), nil
+	case FormatMermaid:
+		return g.Mermaid(), nil
+	case FormatJSON:
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(data) + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown format %q (use text, dot, mermaid or json)", format)
+	}
+}
+
+// Text lists every package with the workspace packages it depends on
+func (g *Graph) Text() string {
+	cycleEdges, _ := g.cycleEdges()
+	byFrom := map[string][]Edge{}
+	for _, e := range g.Edges {
+		byFrom[e.From] = append(byFrom[e.From], e)
+	}
+
+	var b strings.Builder
+	for _, pkg := range g.Packages {
+		b.WriteString(pkg.Name)
+		if pkg.Version != "" {
+			b.WriteString("@" + pkg.Version)
+		}
+		if rel := g.relDir(pkg.Dir); rel != "" {
+			fmt.Fprintf(&b, " (%s)", rel)
+		}
+		b.WriteString("\n")
+
+		for _, e := range byFrom[pkg.Name] {
+			fmt.Fprintf(&b, "  → %s", e.To)
+			if e.Type != "dependencies" {
+				fmt.Fprintf(&b, " (%s)", e.Type)
+			}
+			if cycleEdges[[2]string{e.From, e.To}] {
+				b.WriteString(" [cycle]")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// DOT renders the graph for Graphviz
+func (g *Graph) DOT() string {
+	cycleEdges, cycleNodes := g.cycleEdges()
+
+	var b strings.Builder
+	b.WriteString("digraph workspace {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, pkg := range g.Packages {
+		fmt.Fprintf(&b, "  %q", pkg.Name)
+		if cycleNodes[pkg.Name] {
+			b.WriteString(" [color=red]")
+		}
+		b.WriteString(";\n")
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Dev() {
+			attrs = append(attrs, "style=dashed")
+		}
+		if cycleEdges[[2]string{e.From, e.To}] {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(&b, "  %q -> %q", e.From, e.To)
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart
+func (g *Graph) Mermaid() string {
+	cycleEdges, cycleNodes := g.cycleEdges()
+	ids := map[string]string{}
+
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	var cycleIDs []string
+	for i, pkg := range g.Packages {
+		id := fmt.Sprintf("n%d", i)
+		ids[pkg.Name] = id
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, pkg.Name)
+		if cycleNodes[pkg.Name] {
+			cycleIDs = append(cycleIDs, id)
+		}
+	}
+
+	var cycleLinks []string
+	for i, e := range g.Edges {
+		arrow := "-->"
+		if e.Dev() {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+		if cycleEdges[[2]string{e.From, e.To}] {
+			cycleLinks = append(cycleLinks, fmt.Sprint(i))
+		}
+	}
+
+	if len(cycleIDs) > 0 {
+		b.WriteString("  classDef cycle stroke:#e11d48,stroke-width:2px\n")
+		fmt.Fprintf(&b, "  class %s cycle\n", strings.Join(cycleIDs, ","))
+		fmt.Fprintf(&b, "  linkStyle %s stroke:#e11d48\n", strings.Join(cycleLinks, ","))
+	}
+	return b.String()
+}
+
+// MarshalJSON renders packages with paths relative to the root, edges and
+// cycles
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	type jsonPackage struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+		Path    string `json:"path"`
+	}
+	type jsonEdge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Type string `json:"type"`
+		Spec string `json:"spec"`
+	}
+
+	packages := []jsonPackage{}
+	for _, pkg := range g.Packages {
+		packages = append(packages, jsonPackage{pkg.Name, pkg.Version, g.relDir(pkg.Dir)})
+	}
+	edges := []jsonEdge{}
+	for _, e := range g.Edges {
+		edges = append(edges, jsonEdge{e.From, e.To, e.Type, e.Spec})
+	}
+	cycles := g.Cycles()
+	if cycles == nil {
+		cycles = [][]string{}
+	}
+
+	return json.Marshal(struct {
+		Packages []jsonPackage `json:"packages"`
+		Edges    []jsonEdge    `json:"edges"`
+		Cycles   [][]string    `json:"cycles"`
+	}{packages, edges, cycles})
+}
+
+func (g *Graph) relDir(dir string) string {
+	if g.RootDir == "" || dir == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(g.RootDir, dir)
+	if err != nil {
+		return dir
+	}
+	return filepath.ToSlash(rel)
+}
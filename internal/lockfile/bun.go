@@ -0,0 +1,153 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// bunLockfile is the subset of the text bun.lock gnpm reads
+type bunLockfile struct {
+	Workspaces map[string]struct {
+		Name                 string            `json:"name"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	} `json:"workspaces"`
+	// Packages maps a hoisting path ("react", or "app/react" when a workspace
+	// needs its own copy) to ["name@version", registry, metadata, integrity]
+	Packages map[string][]json.RawMessage `json:"packages"`
+}
+
+// bunMetadata is the metadata element of a bun.lock package entry
+type bunMetadata struct {
+	OS   stringList `json:"os"`
+	CPU  stringList `json:"cpu"`
+	Libc stringList `json:"libc"`
+}
+
+// stringList accepts a string or an array of strings
+type stringList []string
+
+// UnmarshalJSON handles both forms and ignores anything else
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		*l = list
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil && single != "" {
+		*l = stringList{single}
+	}
+	return nil
+}
+
+func readBun(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw bunLockfile
+	if err := json.Unmarshal(stripTrailingCommas(data), &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	lock := &Lockfile{Path: path, Importers: map[string]map[string]string{}}
+	versions := map[string]string{}
+
+	for key, entry := range raw.Packages {
+		if len(entry) == 0 {
+			continue
+		}
+		var ident string
+		if err := json.Unmarshal(entry[0], &ident); err != nil {
+			continue
+		}
+		name, version := splitBunIdent(ident)
+		if name == "" || strings.Contains(version, ":") {
+			// workspace:, file: and link: entries aren't registry packages
+			continue
+		}
+		versions[key] = version
+
+		pkg := Package{Name: name, Version: version}
+		if len(entry) > 2 {
+			var meta bunMetadata
+			if err := json.Unmarshal(entry[2], &meta); err == nil {
+				pkg.OS, pkg.CPU, pkg.Libc = meta.OS, meta.CPU, meta.Libc
+			}
+		}
+		lock.Packages = append(lock.Packages, pkg)
+	}
+
+	for dir, ws := range raw.Workspaces {
+		importer := dir
+		if importer == "" {
+			importer = "."
+		}
+		deps := map[string]string{}
+		for _, group := range []map[string]string{ws.Dependencies, ws.DevDependencies, ws.OptionalDependencies} {
+			for name := range group {
+				// Workspace-local copies take precedence over hoisted ones
+				if version, ok := versions[ws.Name+"/"+name]; ok && ws.Name != "" {
+					deps[name] = version
+				} else if version, ok := versions[name]; ok {
+					deps[name] = version
+				}
+			}
+		}
+		lock.Importers[importer] = deps
+	}
+
+	return lock, nil
+}
+
+// splitBunIdent splits "name@1.0.0" or "@scope/name@1.0.0"
+func splitBunIdent(ident string) (string, string) {
+	if at := strings.LastIndex(ident, "@"); at > 0 {
+		return ident[:at], ident[at+1:]
+	}
+	return "", ""
+}
+
+// stripTrailingCommas removes the trailing commas bun.lock allows before
+// closing brackets, leaving string contents untouched
+func stripTrailingCommas(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case ',':
+			j := i + 1
+			for j < len(data) && strings.ContainsRune(" \t\r\n", rune(data[j])) {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
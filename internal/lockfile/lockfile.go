@@ -0,0 +1,264 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+// Package is a resolved package recorded in a lockfile
+type Package struct {
+	Name    string
+	Version string
+	OS      []string
+	CPU     []string
+	Libc    []string
+}
+
+// Lockfile is the package-manager independent view of a lockfile
+type Lockfile struct {
+	Path     string
+	Packages []Package
+	// Importers maps a workspace directory (relative to the root, "." for the
+	// root) to the versions its direct dependencies resolved to
+	Importers map[string]map[string]string
+	// Descriptors maps "name@range" requests to the version they resolved to,
+	// for lockfiles that don't record importers (yarn)
+	Descriptors map[string]string
+}
+
+// Read parses the lockfile for the given package manager in rootDir
+func Read(rootDir string, pm pmcombo.PackageManager) (*Lockfile, error) {
+	switch pm {
+	case pmcombo.NPM:
+		for _, name := range []string{"package-lock.json", "npm-shrinkwrap.json"} {
+			path := filepath.Join(rootDir, name)
+			if _, err := os.Stat(path); err == nil {
+				return readNpm(path)
+			}
+		}
+		return nil, os.ErrNotExist
+	case pmcombo.PNPM:
+		return readPnpm(filepath.Join(rootDir, "pnpm-lock.yaml"))
+	case pmcombo.Yarn, pmcombo.YarnClassic:
+		return readYarn(filepath.Join(rootDir, "yarn.lock"))
+	case pmcombo.Bun:
+		path := filepath.Join(rootDir, "bun.lock")
+		if _, err := os.Stat(path); err != nil {
+			if _, binErr := os.Stat(filepath.Join(rootDir, "bun.lockb")); binErr == nil {
+				return nil, fmt.Errorf("bun.lockb is binary; run `bun install --save-text-lockfile` to migrate to bun.lock")
+			}
+			return nil, err
+		}
+		return readBun(path)
+	default:
+		return nil, fmt.Errorf("reading %s lockfiles is not supported", pm)
+	}
+}
+
+// Find returns the locked package with the given name and version
+func (l *Lockfile) Find(name string, version string) (Package, bool) {
+	for _, pkg := range l.Packages {
+		if pkg.Name == name && pkg.Version == version {
+			return pkg, true
+		}
+	}
+	return Package{}, false
+}
+
+// Version returns the version a dependency declared as name: spec in the
+// importer resolved to, or "" when the lockfile doesn't record it.
+// Dependencies npm hoisted to the root are found through the root importer.
+func (l *Lockfile) Version(importer string, name string, spec string) string {
+	if version := l.Importers[importer][name]; version != "" {
+		return version
+	}
+	for _, descriptor := range []string{name + "@" + spec, name + "@npm:" + spec} {
+		if version := l.Descriptors[descriptor]; version != "" {
+			return version
+		}
+	}
+	return l.Importers["."][name]
+}
+
+// npmLockfile is the subset of package-lock.json (v2/v3) gnpm reads
+type npmLockfile struct {
+	Packages map[string]struct {
+		Name    string   `json:"name"`
+		Version string   `json:"version"`
+		Link    bool     `json:"link"`
+		OS      []string `json:"os"`
+		CPU     []string `json:"cpu"`
+		Libc    []string `json:"libc"`
+	} `json:"packages"`
+}
+
+func readNpm(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw npmLockfile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	lock := &Lockfile{Path: path, Importers: map[string]map[string]string{}}
+	for key, entry := range raw.Packages {
+		idx := strings.LastIndex(key, "node_modules/")
+		if idx == -1 || entry.Link {
+			continue
+		}
+
+		// The directory name is the alias for npm: aliases; entry.Name is the real package
+		alias := key[idx+len("node_modules/"):]
+		name := alias
+		if entry.Name != "" {
+			name = entry.Name
+		}
+		lock.Packages = append(lock.Packages, Package{
+			Name:    name,
+			Version: entry.Version,
+			OS:      entry.OS,
+			CPU:     entry.CPU,
+			Libc:    entry.Libc,
+		})
+
+		// Direct dependencies live directly under an importer's node_modules
+		importer := strings.TrimSuffix(key[:idx], "/")
+		if strings.Contains(importer, "node_modules") {
+			continue
+		}
+		if importer == "" {
+			importer = "."
+		}
+		if lock.Importers[importer] == nil {
+			lock.Importers[importer] = map[string]string{}
+		}
+		lock.Importers[importer][alias] = entry.Version
+	}
+
+	return lock, nil
+}
+
+// pnpmLockfile is the subset of pnpm-lock.yaml (v6 and v9) gnpm reads
+type pnpmLockfile struct {
+	Importers map[string]map[string]map[string]pnpmDependency `yaml:"importers"`
+	// Single-package lockfiles keep the root importer at the top level
+	Dependencies         map[string]pnpmDependency `yaml:"dependencies"`
+	DevDependencies      map[string]pnpmDependency `yaml:"devDependencies"`
+	OptionalDependencies map[string]pnpmDependency `yaml:"optionalDependencies"`
+	Packages             map[string]struct {
+		OS   []string `yaml:"os"`
+		CPU  []string `yaml:"cpu"`
+		Libc []string `yaml:"libc"`
+	} `yaml:"packages"`
+}
+
+// pnpmDependency is either a version string (v5) or {specifier, version} (v6+)
+type pnpmDependency struct {
+	Version string
+}
+
+// UnmarshalYAML handles both dependency formats
+func (d *pnpmDependency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		d.Version = node.Value
+		return nil
+	}
+	var obj struct {
+		Version string `yaml:"version"`
+	}
+	if err := node.Decode(&obj); err != nil {
+		return err
+	}
+	d.Version = obj.Version
+	return nil
+}
+
+func readPnpm(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw pnpmLockfile
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	lock := &Lockfile{Path: path, Importers: map[string]map[string]string{}}
+
+	for key, entry := range raw.Packages {
+		name, version := splitPnpmKey(key)
+		if name == "" {
+			continue
+		}
+		lock.Packages = append(lock.Packages, Package{
+			Name:    name,
+			Version: version,
+			OS:      entry.OS,
+			CPU:     entry.CPU,
+			Libc:    entry.Libc,
+		})
+	}
+
+	importers := raw.Importers
+	if importers == nil {
+		importers = map[string]map[string]map[string]pnpmDependency{
+			".": {
+				"dependencies":         raw.Dependencies,
+				"devDependencies":      raw.DevDependencies,
+				"optionalDependencies": raw.OptionalDependencies,
+			},
+		}
+	}
+	for importer, groups := range importers {
+		deps := map[string]string{}
+		for _, group := range []string{"dependencies", "devDependencies", "optionalDependencies"} {
+			for name, dep := range groups[group] {
+				deps[name] = pnpmVersion(dep.Version)
+			}
+		}
+		lock.Importers[importer] = deps
+	}
+
+	return lock, nil
+}
+
+// splitPnpmKey splits "/name@1.0.0", "name@1.0.0(peer@2.0.0)" or "/name/1.0.0" keys
+func splitPnpmKey(key string) (string, string) {
+	key = strings.TrimPrefix(key, "/")
+	if idx := strings.Index(key, "("); idx != -1 {
+		key = key[:idx]
+	}
+
+	// pnpm v5 format: name/version, where the version may carry a peer suffix
+	if slash := strings.LastIndex(key, "/"); slash > 0 && slash+1 < len(key) && isDigit(key[slash+1]) {
+		return key[:slash], pnpmVersion(key[slash+1:])
+	}
+
+	if at := strings.LastIndex(key, "@"); at > 0 {
+		return key[:at], pnpmVersion(key[at+1:])
+	}
+	return "", ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// pnpmVersion strips peer suffixes such as "1.0.0(react@18.0.0)" or "1.0.0_react@18.0.0"
+func pnpmVersion(version string) string {
+	if idx := strings.IndexAny(version, "(_"); idx != -1 {
+		return version[:idx]
+	}
+	return version
+}
@@ -0,0 +1,307 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+func TestRead(t *testing.T) {
+	// lookup is a dependency version Read should find: through the importer
+	// when spec is empty, through Lockfile.Version otherwise
+	type lookup struct{ importer, name, spec, version string }
+	// locked is a package Find should return, or not when missing
+	type locked struct {
+		name, version string
+		os, cpu       []string
+		missing       bool
+	}
+
+	tests := []struct {
+		name     string
+		pm       pmcombo.PackageManager
+		file     string
+		content  string
+		lookups  []lookup
+		packages []locked
+	}{
+		{
+			name: "npm",
+			pm:   pmcombo.NPM,
+			file: "package-lock.json",
+			content: `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "root"},
+    "node_modules/react": {"version": "18.3.1"},
+    "node_modules/fsevents": {"version": "2.3.3", "os": ["darwin"]},
+    "node_modules/react/node_modules/loose-envify": {"version": "1.4.0"},
+    "node_modules/app": {"resolved": "packages/app", "link": true},
+    "packages/app": {"name": "app", "version": "1.0.0"},
+    "packages/app/node_modules/react": {"version": "17.0.2"}
+  }
+}`,
+			lookups: []lookup{
+				{".", "react", "", "18.3.1"},
+				{".", "fsevents", "", "2.3.3"},
+				{"packages/app", "react", "", "17.0.2"},
+				// Nested dependencies aren't direct ones
+				{".", "loose-envify", "", ""},
+			},
+			packages: []locked{
+				{name: "fsevents", version: "2.3.3", os: []string{"darwin"}},
+				// Workspace links aren't packages
+				{name: "app", missing: true},
+			},
+		},
+		{
+			name: "pnpm",
+			pm:   pmcombo.PNPM,
+			file: "pnpm-lock.yaml",
+			content: `lockfileVersion: '9.0'
+importers:
+  .:
+    dependencies:
+      react-dom:
+        specifier: ^18.0.0
+        version: 18.3.1(react@18.3.1)
+    devDependencies:
+      '@esbuild/linux-x64':
+        specifier: 0.20.0
+        version: 0.20.0
+    dependenciesMeta:
+      react-dom:
+        injected: true
+  packages/app:
+    dependencies:
+      react:
+        specifier: ^18.0.0
+        version: 18.3.1
+packages:
+  react@18.3.1: {}
+  react-dom@18.3.1:
+    resolution: {integrity: sha512-x}
+  '@esbuild/linux-x64@0.20.0':
+    os: [linux]
+    cpu: [x64]
+`,
+			lookups: []lookup{
+				{".", "react-dom", "", "18.3.1"},
+				{".", "@esbuild/linux-x64", "", "0.20.0"},
+				{"packages/app", "react", "", "18.3.1"},
+			},
+			packages: []locked{
+				{name: "@esbuild/linux-x64", version: "0.20.0", os: []string{"linux"}, cpu: []string{"x64"}},
+			},
+		},
+		{
+			name: "yarn classic",
+			pm:   pmcombo.YarnClassic,
+			file: "yarn.lock",
+			content: `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/code-frame@^7.0.0", "@babel/code-frame@^7.22.0":
+  version "7.24.2"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.24.2.tgz"
+  dependencies:
+    "@babel/highlight" "^7.24.2"
+
+react@^18.0.0:
+  version "18.3.1"
+
+string-width-cjs@npm:string-width@^4.2.0:
+  version "4.2.3"
+`,
+			lookups: []lookup{
+				{".", "@babel/code-frame", "^7.22.0", "7.24.2"},
+				{"packages/app", "react", "^18.0.0", "18.3.1"},
+				{".", "string-width-cjs", "npm:string-width@^4.2.0", "4.2.3"},
+				{".", "react", "^17.0.0", ""},
+			},
+			packages: []locked{
+				{name: "@babel/code-frame", version: "7.24.2"},
+			},
+		},
+		{
+			name: "yarn berry",
+			pm:   pmcombo.Yarn,
+			file: "yarn.lock",
+			content: `# This file is generated by running "yarn install" inside your project.
+
+__metadata:
+  version: 8
+  cacheKey: 10c0
+
+"@esbuild/linux-x64@npm:0.20.0":
+  version: 0.20.0
+  resolution: "@esbuild/linux-x64@npm:0.20.0"
+  conditions: os=linux & cpu=x64
+  languageName: node
+  linkType: hard
+
+"app@workspace:packages/app":
+  version: 0.0.0-use.local
+  resolution: "app@workspace:packages/app"
+  languageName: unknown
+  linkType: soft
+
+"react@npm:^18.0.0, react@npm:^18.2.0":
+  version: 18.3.1
+  resolution: "react@npm:18.3.1"
+  languageName: node
+  linkType: hard
+`,
+			lookups: []lookup{
+				{".", "react", "^18.2.0", "18.3.1"},
+				{".", "react", "npm:^18.0.0", "18.3.1"},
+				{".", "app", "workspace:*", ""},
+			},
+			packages: []locked{
+				{name: "@esbuild/linux-x64", version: "0.20.0", os: []string{"linux"}, cpu: []string{"x64"}},
+			},
+		},
+		{
+			name: "bun",
+			pm:   pmcombo.Bun,
+			file: "bun.lock",
+			content: `{
+  "lockfileVersion": 1,
+  "workspaces": {
+    "": {
+      "name": "root",
+      "dependencies": {
+        "react": "^18.0.0",
+      },
+      "devDependencies": {
+        "@esbuild/darwin-arm64": "0.20.0",
+      },
+    },
+    "packages/app": {
+      "name": "app",
+      "dependencies": {
+        "react": "^17.0.0",
+        "root": "workspace:*",
+      },
+    },
+  },
+  "packages": {
+    "@esbuild/darwin-arm64": ["@esbuild/darwin-arm64@0.20.0", "", { "os": "darwin", "cpu": "arm64" }, "sha512-a,b"],
+    "app": ["app@workspace:packages/app"],
+    "react": ["react@18.3.1", "", {}, "sha512-x"],
+    "app/react": ["react@17.0.2", "", {}, "sha512-y"],
+  }
+}
+`,
+			lookups: []lookup{
+				{".", "react", "", "18.3.1"},
+				{"packages/app", "react", "", "17.0.2"},
+				{"packages/app", "root", "", ""},
+			},
+			packages: []locked{
+				{name: "@esbuild/darwin-arm64", version: "0.20.0", os: []string{"darwin"}, cpu: []string{"arm64"}},
+				// Workspace entries aren't packages
+				{name: "app", version: "workspace:packages/app", missing: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rootDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(rootDir, tt.file), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			lock, err := Read(rootDir, tt.pm)
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+
+			for _, l := range tt.lookups {
+				got := lock.Importers[l.importer][l.name]
+				if l.spec != "" {
+					got = lock.Version(l.importer, l.name, l.spec)
+				}
+				if got != l.version {
+					t.Errorf("%s: %s %s = %q, want %q", l.importer, l.name, l.spec, got, l.version)
+				}
+			}
+			for _, want := range tt.packages {
+				pkg, ok := lock.Find(want.name, want.version)
+				if ok == want.missing {
+					t.Errorf("Find(%s, %s) found = %v", want.name, want.version, ok)
+					continue
+				}
+				if ok && (!reflect.DeepEqual(pkg.OS, want.os) || !reflect.DeepEqual(pkg.CPU, want.cpu)) {
+					t.Errorf("Find(%s) = %+v", want.name, pkg)
+				}
+			}
+		})
+	}
+}
+
+func TestReadBunBinary(t *testing.T) {
+	rootDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rootDir, "bun.lockb"), []byte("binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(rootDir, pmcombo.Bun); err == nil || !strings.Contains(err.Error(), "--save-text-lockfile") {
+		t.Fatalf("Read(bun.lockb) error = %v, want a migration hint", err)
+	}
+}
+
+func TestVersionFallsBackToHoistedRoot(t *testing.T) {
+	lock := &Lockfile{Importers: map[string]map[string]string{
+		".":            {"react": "18.3.1"},
+		"packages/app": {"lodash": "4.17.21"},
+	}}
+
+	tests := []struct {
+		name    string
+		spec    string
+		version string
+	}{
+		{"lodash", "^4.0.0", "4.17.21"},
+		{"react", "^18.0.0", "18.3.1"},
+		{"vue", "^3.0.0", ""},
+	}
+	for _, tt := range tests {
+		if got := lock.Version("packages/app", tt.name, tt.spec); got != tt.version {
+			t.Errorf("Version(%s, %s) = %q, want %q", tt.name, tt.spec, got, tt.version)
+		}
+	}
+}
+
+func TestReadUnsupported(t *testing.T) {
+	if _, err := Read(t.TempDir(), pmcombo.Deno); err == nil {
+		t.Fatal("expected an error for an unsupported package manager")
+	}
+}
+
+func TestSplitPnpmKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		name    string
+		version string
+	}{
+		{"/react/18.2.0", "react", "18.2.0"},
+		{"/@types/node/20.1.0", "@types/node", "20.1.0"},
+		{"/react-dom/18.2.0_react@18.2.0", "react-dom", "18.2.0"},
+		{"/@scope/pkg@1.0.0", "@scope/pkg", "1.0.0"},
+		{"react-dom@18.3.1(react@18.3.1)", "react-dom", "18.3.1"},
+		{"lodash_es@4.17.21", "lodash_es", "4.17.21"},
+	}
+
+	for _, tt := range tests {
+		name, version := splitPnpmKey(tt.key)
+		if name != tt.name || version != tt.version {
+			t.Errorf("splitPnpmKey(%q) = %q, %q; want %q, %q", tt.key, name, version, tt.name, tt.version)
+		}
+	}
+}
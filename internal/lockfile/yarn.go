@@ -0,0 +1,141 @@
+package lockfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yarnBerryEntry is the subset of a Yarn Berry lockfile entry gnpm reads
+type yarnBerryEntry struct {
+	Version    string `yaml:"version"`
+	Resolution string `yaml:"resolution"`
+	Conditions string `yaml:"conditions"`
+}
+
+// readYarn parses yarn.lock in either the classic v1 format or the YAML
+// format Yarn Berry writes
+func readYarn(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lockfile{Path: path, Importers: map[string]map[string]string{}, Descriptors: map[string]string{}}
+	if bytes.Contains(data, []byte("\n__metadata:")) {
+		err = readYarnBerry(data, lock)
+	} else {
+		err = readYarnClassic(data, lock)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+func readYarnBerry(data []byte, lock *Lockfile) error {
+	var raw map[string]yarnBerryEntry
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for key, entry := range raw {
+		if key == "__metadata" || strings.Contains(entry.Resolution, "@workspace:") {
+			continue
+		}
+
+		descriptors := splitYarnKey(key)
+		for _, descriptor := range descriptors {
+			lock.Descriptors[descriptor] = entry.Version
+		}
+
+		name := yarnDescriptorName(entry.Resolution)
+		if name == "" && len(descriptors) > 0 {
+			name = yarnDescriptorName(descriptors[0])
+		}
+		pkg := Package{Name: name, Version: entry.Version}
+		for _, condition := range strings.Split(entry.Conditions, "&") {
+			field, value, ok := strings.Cut(strings.TrimSpace(condition), "=")
+			if !ok {
+				continue
+			}
+			switch field {
+			case "os":
+				pkg.OS = append(pkg.OS, value)
+			case "cpu":
+				pkg.CPU = append(pkg.CPU, value)
+			case "libc":
+				pkg.Libc = append(pkg.Libc, value)
+			}
+		}
+		lock.Packages = append(lock.Packages, pkg)
+	}
+	return nil
+}
+
+// readYarnClassic parses the v1 format:
+//
+//	"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+//	  version "7.24.0"
+func readYarnClassic(data []byte, lock *Lockfile) error {
+	var descriptors []string
+	flush := func(version string) {
+		if len(descriptors) == 0 || version == "" {
+			return
+		}
+		for _, descriptor := range descriptors {
+			lock.Descriptors[descriptor] = version
+		}
+		lock.Packages = append(lock.Packages, Package{Name: yarnDescriptorName(descriptors[0]), Version: version})
+		descriptors = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") {
+			if !strings.HasSuffix(line, ":") {
+				return fmt.Errorf("unexpected line %q", line)
+			}
+			descriptors = splitYarnKey(strings.TrimSuffix(line, ":"))
+			continue
+		}
+
+		if strings.HasPrefix(line, "  version ") {
+			flush(strings.Trim(strings.TrimPrefix(line, "  version "), `"`))
+		}
+	}
+	return scanner.Err()
+}
+
+// splitYarnKey splits `"a@^1.0.0", a@^1.1.0` into its descriptors
+func splitYarnKey(key string) []string {
+	var descriptors []string
+	for _, part := range strings.Split(key, ",") {
+		part = strings.Trim(strings.TrimSpace(part), `"`)
+		if part != "" {
+			descriptors = append(descriptors, part)
+		}
+	}
+	return descriptors
+}
+
+// yarnDescriptorName returns the package name of "name@range" or "@scope/name@npm:1.0.0"
+func yarnDescriptorName(descriptor string) string {
+	if descriptor == "" {
+		return ""
+	}
+	// Skip the first byte so a scope's "@" isn't taken as the separator
+	if at := strings.Index(descriptor[1:], "@"); at != -1 {
+		return descriptor[:at+1]
+	}
+	return descriptor
+}
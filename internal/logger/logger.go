@@ -20,6 +20,16 @@ var (
 	green  = color.New(color.FgGreen).SprintFunc()
 )
 
+// SetOutput redirects what is printed to stdout and stderr, such as into a
+// buffer in tests, and returns a function restoring the previous writers
+func SetOutput(out io.Writer, errOut io.Writer) func() {
+	prevOut, prevErr := stdout, stderr
+	stdout, stderr = out, errOut
+	return func() {
+		stdout, stderr = prevOut, prevErr
+	}
+}
+
 // Command prints a command that will be executed (to stderr)
 func Command(cmd string) {
 	fmt.Fprintf(stderr, "%s %s\n", dim("$"), cmd)
@@ -266,3 +266,26 @@ func writeNpmrcDelete(path string, originalLines []string, deleteKey string) err
 
 	return os.WriteFile(path, []byte(content), 0644)
 }
+
+// GetConfig returns a config value from the merged project and user .npmrc
+func GetConfig(dir string, key string) (string, bool) {
+	configs := mergeConfigs(dir)
+	value, ok := configs[key]
+	return value, ok
+}
+
+// LoadNpmrc returns the merged .npmrc settings for dir: ~/.npmrc overridden by
+// every .npmrc from the filesystem root down to dir
+func LoadNpmrc(dir string) map[string]string {
+	configs := make(map[string]string)
+
+	paths := getNpmrcPaths(dir, false)
+	for i := len(paths) - 1; i >= 0; i-- {
+		fileConfigs, _ := readNpmrc(paths[i])
+		for k, v := range fileConfigs {
+			configs[k] = v
+		}
+	}
+
+	return configs
+}
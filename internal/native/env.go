@@ -0,0 +1,82 @@
+package native
+
+import (
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+)
+
+// configEnvName matches .npmrc keys that can be exported as npm_config_*.
+// Registry-scoped keys ("//host/:_authToken") and private keys ("_auth") are
+// left out so credentials don't leak into scripts.
+var configEnvName = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+// packageEnv returns the environment npm gives every lifecycle script of
+// pkg: PATH with node_modules/.bin, npm_package_*, npm_config_* from the
+// merged .npmrc, npm_execpath, npm_node_execpath and INIT_CWD, on top of
+// the process environment and the extra variables. Resolving the required
+// Node.js may run node, so Run builds it once for all its steps.
+func packageEnv(pkg *context.PackageJSON, pkgPath string, pkgDir string, extra []string) []string {
+	pathEnv := BuildNodeBinPath(pkgDir)
+	env := append(commandEnv(extra), "PATH="+pathEnv)
+
+	env = append(env, configEnv(LoadNpmrc(pkgDir))...)
+
+	env = append(env,
+		"npm_package_name="+pkg.Name,
+		"npm_package_version="+pkg.Version,
+		"npm_package_json="+pkgPath,
+	)
+
+	if execPath, err := os.Executable(); err == nil {
+		env = append(env, "npm_execpath="+execPath)
+	}
+
+	nodeName := "node"
+	if runtime.GOOS == "windows" {
+		nodeName = "node.exe"
+	}
+	if nodePath, ok := lookPathIn(nodeName, pathEnv); ok {
+		env = append(env, "npm_node_execpath="+nodePath)
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		env = append(env, "INIT_CWD="+cwd)
+	}
+
+	return env
+}
+
+// scriptEnv returns the package environment with the npm_lifecycle_*
+// variables of step
+func scriptEnv(env []string, step scriptStep) []string {
+	return append(env[:len(env):len(env)],
+		"npm_lifecycle_event="+step.name,
+		"npm_lifecycle_script="+step.script,
+	)
+}
+
+// commandEnv returns the process environment followed by the extra
+// variables
+func commandEnv(extra []string) []string {
+	return append(os.Environ(), extra...)
+}
+
+// configEnv converts .npmrc settings to npm_config_* variables the way npm
+// names them: lowercased, with dashes turned into underscores
+func configEnv(npmrc map[string]string) []string {
+	var env []string
+	for key, value := range npmrc {
+		name := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		if !configEnvName.MatchString(name) {
+			continue
+		}
+		env = append(env, "npm_config_"+name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
@@ -0,0 +1,105 @@
+package native
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+)
+
+// envValue returns the value a process would see for key: the last one
+func envValue(env []string, key string) (string, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if name, value, ok := strings.Cut(env[i], "="); ok && name == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func TestScriptEnvLifecycle(t *testing.T) {
+	dir := scriptProject(t, `{}`, "")
+	pkgPath := filepath.Join(dir, "package.json")
+	pkg := &context.PackageJSON{Name: "@acme/app", Version: "1.2.3"}
+
+	tests := []struct {
+		step       scriptStep
+		wantEvent  string
+		wantScript string
+	}{
+		{scriptStep{name: "prebuild", script: "tsc", command: "tsc", hook: true}, "prebuild", "tsc"},
+		// The script as written, without the extra args
+		{scriptStep{name: "build", script: "vite build", command: "vite build --watch"}, "build", "vite build"},
+		{scriptStep{name: "postbuild", script: "size-limit", command: "size-limit", hook: true}, "postbuild", "size-limit"},
+	}
+	for _, tt := range tests {
+		env := scriptEnv(packageEnv(pkg, pkgPath, dir, []string{"FROM_DOTENV=1"}), tt.step)
+		want := map[string]string{
+			"npm_lifecycle_event":  tt.wantEvent,
+			"npm_lifecycle_script": tt.wantScript,
+			"npm_package_name":     "@acme/app",
+			"npm_package_version":  "1.2.3",
+			"npm_package_json":     pkgPath,
+			"FROM_DOTENV":          "1",
+		}
+		for key, value := range want {
+			if got, _ := envValue(env, key); got != value {
+				t.Errorf("%s: %s = %q, want %q", tt.step.name, key, got, value)
+			}
+		}
+	}
+}
+
+func TestRunSetsLifecycleEvent(t *testing.T) {
+	dir := scriptProject(t, `{
+		"pretest": "echo $npm_lifecycle_event >> ran.log",
+		"test": "echo $npm_lifecycle_event $npm_package_name >> ran.log",
+		"posttest": "echo $npm_lifecycle_event >> ran.log"
+	}`, "")
+
+	if err := Run(RunOptions{Dir: dir, Script: "test"}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := []string{"pretest", "test app", "posttest"}
+	if got := ranSteps(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
+
+func TestConfigEnv(t *testing.T) {
+	got := configEnv(map[string]string{
+		"registry":                           "https://registry.example.com/",
+		"Save-Exact":                         "true",
+		"fetch-retry-mintimeout":             "2000",
+		"_auth":                              "dXNlcjpwYXNz",
+		"_authToken":                         "secret-token",
+		"//registry.example.com/:_authToken": "scoped-token",
+		"//registry.example.com/:_password":  "cGFzcw==",
+		"@acme:registry":                     "https://npm.acme.dev/",
+	})
+	want := []string{
+		"npm_config_fetch_retry_mintimeout=2000",
+		"npm_config_registry=https://registry.example.com/",
+		"npm_config_save_exact=true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("configEnv = %q, want %q", got, want)
+	}
+}
+
+func TestScriptEnvLeavesOutCredentials(t *testing.T) {
+	dir := scriptProject(t, `{}`, "registry=https://registry.example.com/\n_authToken=secret-token\n//registry.example.com/:_authToken=scoped-token\n")
+	pkg := &context.PackageJSON{Name: "app"}
+
+	env := packageEnv(pkg, filepath.Join(dir, "package.json"), dir, nil)
+	for _, entry := range env {
+		if strings.Contains(entry, "secret-token") || strings.Contains(entry, "scoped-token") {
+			t.Errorf("credential leaked into the script environment: %s", entry)
+		}
+	}
+	if got, _ := envValue(env, "npm_config_registry"); got != "https://registry.example.com/" {
+		t.Errorf("npm_config_registry = %q", got)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/process"
+	"github.com/AkaraChen/gnpm/internal/shell"
 )
 
 // ExecOptions for executing binaries
@@ -18,6 +20,10 @@ type ExecOptions struct {
 	Args    []string
 	Verbose bool
 	DryRun  bool
+
+	// Env holds extra KEY=value variables, such as those from .env files.
+	// They are added to the process environment without overriding it.
+	Env []string
 }
 
 // Exec executes a binary from node_modules/.bin
@@ -27,9 +33,9 @@ func Exec(opts ExecOptions) error {
 		return err
 	}
 
-	cmdStr := binPath
+	cmdStr := shell.Quote(binPath)
 	if len(opts.Args) > 0 {
-		cmdStr += " " + strings.Join(opts.Args, " ")
+		cmdStr += " " + shell.Join(opts.Args)
 	}
 
 	if opts.DryRun {
@@ -46,9 +52,9 @@ func Exec(opts ExecOptions) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	cmd.Env = append(os.Environ(), "PATH="+BuildNodeBinPath(opts.Dir))
+	cmd.Env = append(commandEnv(opts.Env), "PATH="+BuildNodeBinPath(opts.Dir))
 
-	return cmd.Run()
+	return process.Run(cmd)
 }
 
 // FindBinary searches for a binary in node_modules/.bin, walking up the directory tree
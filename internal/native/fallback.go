@@ -7,6 +7,8 @@ import (
 
 	"github.com/AkaraChen/gnpm/internal/context"
 	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/process"
+	"github.com/AkaraChen/gnpm/internal/shell"
 )
 
 // FallbackOptions for running unknown commands
@@ -16,6 +18,13 @@ type FallbackOptions struct {
 	Args    []string
 	Verbose bool
 	DryRun  bool
+
+	// Env holds extra KEY=value variables, such as those from .env files.
+	// They are added to the process environment without overriding it.
+	Env []string
+
+	// Shell runs scripts, as in RunOptions
+	Shell string
 }
 
 // FallbackResult indicates what type of command was executed
@@ -38,6 +47,8 @@ func Fallback(opts FallbackOptions) (FallbackResult, error) {
 			Args:    opts.Args,
 			Verbose: opts.Verbose,
 			DryRun:  opts.DryRun,
+			Env:     opts.Env,
+			Shell:   opts.Shell,
 		})
 		return FallbackScript, err
 	}
@@ -50,6 +61,7 @@ func Fallback(opts FallbackOptions) (FallbackResult, error) {
 			Args:    opts.Args,
 			Verbose: opts.Verbose,
 			DryRun:  opts.DryRun,
+			Env:     opts.Env,
 		})
 		_ = binPath // unused but checked for existence
 		return FallbackBinary, err
@@ -86,10 +98,7 @@ func hasSystemCommand(name string) bool {
 
 // runSystemCommand executes a system command
 func runSystemCommand(opts FallbackOptions) error {
-	cmdStr := opts.Command
-	for _, arg := range opts.Args {
-		cmdStr += " " + arg
-	}
+	cmdStr := shell.Join(append([]string{opts.Command}, opts.Args...))
 
 	if opts.DryRun {
 		logger.DryRun(cmdStr, opts.Dir)
@@ -100,19 +109,26 @@ func runSystemCommand(opts FallbackOptions) error {
 		logger.Command(cmdStr)
 	}
 
+	pathEnv := BuildNodeBinPath(opts.Dir)
+
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
 		args := append([]string{"/C", opts.Command}, opts.Args...)
 		cmd = exec.Command("cmd", args...)
 	} else {
-		cmd = exec.Command(opts.Command, opts.Args...)
+		// Resolve against the project PATH so the required node version wins
+		command := opts.Command
+		if path, ok := lookPathIn(command, pathEnv); ok {
+			command = path
+		}
+		cmd = exec.Command(command, opts.Args...)
 	}
 
 	cmd.Dir = opts.Dir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	cmd.Env = append(os.Environ(), "PATH="+BuildNodeBinPath(opts.Dir))
+	cmd.Env = append(commandEnv(opts.Env), "PATH="+pathEnv)
 
-	return cmd.Run()
+	return process.Run(cmd)
 }
@@ -4,9 +4,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/toolchain"
 )
 
-// BuildNodeBinPath builds PATH with all node_modules/.bin directories up the tree
+// BuildNodeBinPath builds PATH with all node_modules/.bin directories up the tree,
+// followed by the bin directory of the Node.js version the project requires
 func BuildNodeBinPath(dir string) string {
 	var binDirs []string
 	current := dir
@@ -24,6 +28,10 @@ func BuildNodeBinPath(dir string) string {
 		current = parent
 	}
 
+	if nodeBinDir := resolveNodeBinDir(dir); nodeBinDir != "" {
+		binDirs = append(binDirs, nodeBinDir)
+	}
+
 	// Append original PATH
 	pathEnv := os.Getenv("PATH")
 	if len(binDirs) > 0 {
@@ -31,3 +39,38 @@ func BuildNodeBinPath(dir string) string {
 	}
 	return pathEnv
 }
+
+// resolveNodeBinDir returns the bin directory of the Node.js version required by
+// .nvmrc, .node-version, .tool-versions, volta.node or engines.node. It returns
+// an empty string when nothing is required or the node on PATH already matches.
+func resolveNodeBinDir(dir string) string {
+	req, ok := toolchain.DetectNodeRequirement(dir)
+	if !ok {
+		return ""
+	}
+
+	installation, err := toolchain.ResolveNode(req, dir)
+	if err != nil {
+		logger.Warn("%v; using node from PATH", err)
+		return ""
+	}
+	return installation.BinDir
+}
+
+// lookPathIn searches for an executable in the given PATH value
+func lookPathIn(name string, pathEnv string) (string, bool) {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		return name, true
+	}
+
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -1,14 +1,20 @@
 package native
 
 import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 
 	"github.com/AkaraChen/gnpm/internal/context"
 	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/process"
+	"github.com/AkaraChen/gnpm/internal/shell"
+	"github.com/AkaraChen/gnpm/internal/taskcache"
 )
 
 // RunOptions for running scripts
@@ -18,8 +24,35 @@ type RunOptions struct {
 	Args    []string
 	Verbose bool
 	DryRun  bool
+
+	// IgnoreScripts skips the pre<script> and post<script> hooks
+	IgnoreScripts bool
+
+	// Shell is ShellSystem (the default) or ShellBuiltin
+	Shell string
+
+	// Env holds extra KEY=value variables, such as those from .env files.
+	// They are added to the process environment without overriding it.
+	Env []string
+
+	// Task caches the script's result in Cache: when its inputs are
+	// unchanged the outputs are restored and the logs replayed instead of
+	// running it. Nil runs the script as usual.
+	Task  *taskcache.Task
+	Cache *taskcache.Cache
+
+	// Context stops the script, with everything it started, when done.
+	// Such scripts run in a process group of their own, without access to
+	// the terminal's input.
+	Context stdcontext.Context
 }
 
+// Shells scripts can run with
+const (
+	ShellSystem  = "system"  // sh on Unix, cmd.exe on Windows
+	ShellBuiltin = "builtin" // gnpm's portable shell, the same on every platform
+)
+
 // Run executes a script from package.json
 func Run(opts RunOptions) error {
 	// Find package.json by walking up
@@ -39,33 +72,154 @@ func Run(opts RunOptions) error {
 	if !ok {
 		if len(pkg.Scripts) > 0 {
 			logger.Header("Available scripts:")
-			for name := range pkg.Scripts {
-				logger.List(name)
+			for _, script := range SortScripts(pkg.Scripts) {
+				logger.List(script.Name)
 			}
 		}
-		logger.Error("script %q not found", opts.Script)
-		return nil
+		return fmt.Errorf("script %q not found", opts.Script)
 	}
 
-	// Append extra args
+	// Append extra args, quoted so the shell passes them through literally
+	main := scriptStep{name: opts.Script, script: scriptCmd, command: scriptCmd}
 	if len(opts.Args) > 0 {
-		scriptCmd = scriptCmd + " " + strings.Join(opts.Args, " ")
+		main.command = scriptCmd + " " + joinArgs(opts.Args, opts.Shell)
+	}
+
+	// Run pre<script> and post<script> around the script, like npm does.
+	// Extra args only go to the script itself.
+	steps := []scriptStep{main}
+	if hooksEnabled(opts, pkgDir) {
+		if pre, ok := pkg.Scripts["pre"+opts.Script]; ok {
+			steps = append([]scriptStep{{name: "pre" + opts.Script, script: pre, command: pre, hook: true}}, steps...)
+		}
+		if post, ok := pkg.Scripts["post"+opts.Script]; ok {
+			steps = append(steps, scriptStep{name: "post" + opts.Script, script: post, command: post, hook: true})
+		}
 	}
 
 	if opts.DryRun {
-		logger.DryRun(scriptCmd, pkgDir)
+		for _, step := range steps {
+			logger.DryRun(step.command, pkgDir)
+		}
 		return nil
 	}
 
-	if opts.Verbose {
-		logger.Command(scriptCmd)
+	env := packageEnv(pkg, pkgPath, pkgDir, opts.Env)
+	key, log := cachedRun(opts, steps, env)
+	if log != nil {
+		logger.Info("Cache hit for %s, replaying output (%s)", opts.Script, key[:12])
+		return log.Replay(os.Stdout, os.Stderr)
 	}
 
-	return executeScript(scriptCmd, pkgDir)
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if key != "" {
+		log = &taskcache.Log{}
+		stdout, stderr = log.Tee(os.Stdout, os.Stderr)
+	}
+
+	for _, step := range steps {
+		if opts.Verbose {
+			logger.Command(step.command)
+		}
+
+		// A failing step stops the rest, so a failed pre hook skips the script
+		if err := executeScript(opts.Context, step.command, pkgDir, scriptEnv(env, step), opts.Shell, stdout, stderr); err != nil {
+			if step.hook {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+			return err
+		}
+	}
+
+	if key != "" {
+		if err := opts.Cache.Save(key, opts.Task, log); err != nil {
+			logger.Warn("could not cache %s: %v", opts.Script, err)
+		} else if opts.Verbose {
+			logger.Dim("Cached %s (%s)", opts.Script, key[:12])
+		}
+	}
+	return nil
 }
 
-// executeScript runs a shell command with node_modules/.bin in PATH
-func executeScript(script string, dir string) error {
+// cachedRun looks the script up in the task cache. It returns the key to
+// save the result under and, on a hit, the replayable log after restoring
+// the outputs. Cache failures are reported and the script runs normally.
+func cachedRun(opts RunOptions, steps []scriptStep, env []string) (string, *taskcache.Log) {
+	if opts.Task == nil || opts.Cache == nil {
+		return "", nil
+	}
+
+	commands := make([]string, 0, len(steps))
+	for _, step := range steps {
+		commands = append(commands, step.name+"\x00"+step.command)
+	}
+	key, err := opts.Task.Key(commands, env)
+	if err != nil {
+		logger.Warn("task cache disabled for %s: %v", opts.Script, err)
+		return "", nil
+	}
+
+	log, err := opts.Cache.Restore(key, opts.Task)
+	if err != nil {
+		logger.Warn("could not restore %s from the cache: %v", opts.Script, err)
+		return key, nil
+	}
+	return key, log
+}
+
+// joinArgs quotes args for the shell the script runs in: the built-in
+// shell parses POSIX quoting on every platform, while the system shell is
+// cmd.exe on Windows
+func joinArgs(args []string, shellName string) string {
+	if shellName == ShellBuiltin {
+		return shell.JoinPOSIX(args)
+	}
+	return shell.Join(args)
+}
+
+// scriptStep is one script run by Run
+type scriptStep struct {
+	name    string
+	script  string // the script as written in package.json
+	command string // the script with extra args appended
+	hook    bool
+}
+
+// hooksEnabled reports whether pre/post hooks should run. They are on by
+// default, as in npm, and turned off by the flag, ignore-scripts=true or
+// enable-pre-post-scripts=false in .npmrc.
+func hooksEnabled(opts RunOptions, dir string) bool {
+	if opts.IgnoreScripts {
+		return false
+	}
+	npmrc := LoadNpmrc(dir)
+	return npmrc["ignore-scripts"] != "true" && npmrc["enable-pre-post-scripts"] != "false"
+}
+
+// executeScript runs a shell command with the given environment. A non-nil
+// ctx stops it when done.
+func executeScript(ctx stdcontext.Context, script string, dir string, env []string, shellName string, stdout io.Writer, stderr io.Writer) error {
+	var stdin io.Reader = os.Stdin
+	if ctx != nil {
+		stdin = nil
+	}
+
+	if shellName == ShellBuiltin {
+		err := shell.Run(script, shell.Options{
+			Dir:     dir,
+			Env:     env,
+			Stdin:   stdin,
+			Stdout:  stdout,
+			Stderr:  stderr,
+			Context: ctx,
+		})
+		var exitErr *shell.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			return fmt.Errorf("built-in shell: %w", err)
+		}
+		return err
+	}
+
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
 		cmd = exec.Command("cmd", "/C", script)
@@ -74,23 +228,23 @@ func executeScript(script string, dir string) error {
 	}
 
 	cmd.Dir = dir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	cmd.Env = append(os.Environ(), "PATH="+BuildNodeBinPath(dir))
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Stdin = stdin
+	cmd.Env = env
 
-	return cmd.Run()
-}
-
-// ListScripts returns all available scripts
-func ListScripts(dir string) (map[string]string, error) {
-	pkgPath, err := context.FindPackageJSON(dir)
-	if err != nil {
-		return nil, err
+	if ctx == nil {
+		return process.Run(cmd)
 	}
-	pkg, err := context.ReadPackageJSON(pkgPath)
+	p, err := process.Start(cmd)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	select {
+	case <-p.Done():
+		return p.Wait()
+	case <-ctx.Done():
+		p.Stop(process.Grace)
+		return ctx.Err()
 	}
-	return pkg.Scripts, nil
 }
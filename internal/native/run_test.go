@@ -0,0 +1,127 @@
+package native
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/process"
+)
+
+// scriptProject creates a package with the given scripts and .npmrc, with
+// HOME pointing elsewhere so the user's .npmrc doesn't apply
+func scriptProject(t *testing.T, scripts string, npmrc string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts use sh")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	files := map[string]string{"package.json": `{"name": "app", "version": "1.0.0", "scripts": ` + scripts + `}`}
+	if npmrc != "" {
+		files[".npmrc"] = npmrc
+	}
+	writeFiles(t, dir, files)
+	return dir
+}
+
+// ranSteps returns the lines the scripts appended to ran.log
+func ranSteps(t *testing.T, dir string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "ran.log"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+const hookScripts = `{
+	"prebuild": "echo prebuild >> ran.log",
+	"build": "echo build >> ran.log",
+	"postbuild": "echo postbuild >> ran.log"
+}`
+
+func TestRunHooks(t *testing.T) {
+	dir := scriptProject(t, hookScripts, "")
+
+	if err := Run(RunOptions{Dir: dir, Script: "build", Args: []string{"--mode", "two words"}}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// Extra args only go to the script itself
+	want := []string{"prebuild", "build --mode two words", "postbuild"}
+	if got := ranSteps(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
+
+func TestRunFailingPreHook(t *testing.T) {
+	dir := scriptProject(t, `{
+		"prebuild": "echo prebuild >> ran.log; exit 3",
+		"build": "echo build >> ran.log",
+		"postbuild": "echo postbuild >> ran.log"
+	}`, "")
+
+	err := Run(RunOptions{Dir: dir, Script: "build"})
+	if err == nil || !strings.Contains(err.Error(), "prebuild") {
+		t.Fatalf("Run error = %v, want the prebuild failure", err)
+	}
+	if code, ok := process.ExitCode(err); !ok || code != 3 {
+		t.Errorf("exit code = %d, %v, want 3", code, ok)
+	}
+	if got := ranSteps(t, dir); !reflect.DeepEqual(got, []string{"prebuild"}) {
+		t.Errorf("ran %q, want only prebuild", got)
+	}
+}
+
+func TestRunSkipsHooks(t *testing.T) {
+	tests := []struct {
+		name          string
+		npmrc         string
+		ignoreScripts bool
+	}{
+		{"--ignore-scripts", "", true},
+		{"ignore-scripts=true", "ignore-scripts=true\n", false},
+		{"enable-pre-post-scripts=false", "enable-pre-post-scripts=false\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := scriptProject(t, hookScripts, tt.npmrc)
+			if err := Run(RunOptions{Dir: dir, Script: "build", IgnoreScripts: tt.ignoreScripts}); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+			if got := ranSteps(t, dir); !reflect.DeepEqual(got, []string{"build"}) {
+				t.Errorf("ran %q, want only build", got)
+			}
+		})
+	}
+}
+
+func TestRunMissingScript(t *testing.T) {
+	dir := scriptProject(t, hookScripts, "")
+	if err := Run(RunOptions{Dir: dir, Script: "deploy"}); err == nil || !strings.Contains(err.Error(), "deploy") {
+		t.Errorf("Run error = %v, want a missing script error", err)
+	}
+}
+
+func TestRunBuiltinShellArgs(t *testing.T) {
+	// Runs on Windows too, where the system shell would quote for cmd.exe
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"package.json": `{"name": "app", "scripts": {"say": "echo >> ran.log"}}`})
+
+	args := []string{"hello  world", `it's "quoted"`, "100%", "a&b"}
+	if err := Run(RunOptions{Dir: dir, Script: "say", Args: args, Shell: ShellBuiltin}); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	want := []string{strings.Join(args, " ")}
+	if got := ranSteps(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+}
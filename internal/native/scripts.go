@@ -0,0 +1,207 @@
+package native
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	fuzzyfinder "github.com/ktr0731/go-fuzzyfinder"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/workspace"
+)
+
+// Script is a package.json script
+type Script struct {
+	Name    string
+	Command string
+}
+
+// ScriptGroup is the scripts of one package
+type ScriptGroup struct {
+	Name    string // package name, or its directory when unnamed
+	Dir     string
+	RelDir  string // Dir relative to the workspace root
+	Scripts []Script
+}
+
+// ListScripts returns the scripts of the package holding dir, sorted by name
+func ListScripts(dir string) ([]Script, error) {
+	pkgPath, err := context.FindPackageJSON(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := context.ReadPackageJSON(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	return SortScripts(pkg.Scripts), nil
+}
+
+// ScriptGroups returns the scripts of the package holding dir and, when
+// that package is the workspace root, those of every workspace package.
+// Workspaces declared in package.json and in pnpm-workspace.yaml both count.
+func ScriptGroups(dir string) ([]ScriptGroup, error) {
+	pkgPath, err := context.FindPackageJSON(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkgDir := filepath.Dir(pkgPath)
+	pkg, err := context.ReadPackageJSON(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := pkg.Name
+	if name == "" {
+		name = filepath.Base(pkgDir)
+	}
+	groups := []ScriptGroup{{Name: name, Dir: pkgDir, Scripts: SortScripts(pkg.Scripts)}}
+	if rootDir, err := context.FindWorkspaceRoot(pkgDir); err != nil || rootDir != pkgDir {
+		return groups, nil
+	}
+
+	packages, err := workspace.FindPackages(pkgDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packages {
+		if p.Dir == pkgDir {
+			continue
+		}
+		scripts, err := ListScripts(p.Dir)
+		if err != nil {
+			return nil, err
+		}
+		relDir, err := filepath.Rel(pkgDir, p.Dir)
+		if err != nil {
+			relDir = p.Dir
+		}
+		name := p.Name
+		if name == "" {
+			name = filepath.ToSlash(relDir)
+		}
+		groups = append(groups, ScriptGroup{Name: name, Dir: p.Dir, RelDir: relDir, Scripts: scripts})
+	}
+	return groups, nil
+}
+
+// SortScripts returns the scripts sorted by name
+func SortScripts(scripts map[string]string) []Script {
+	sorted := make([]Script, 0, len(scripts))
+	for name, command := range scripts {
+		sorted = append(sorted, Script{Name: name, Command: command})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// PrintScripts lists each group's scripts with their commands, names
+// aligned across groups. A single group is printed without a heading.
+func PrintScripts(groups []ScriptGroup) {
+	width := 0
+	for _, group := range groups {
+		for _, script := range group.Scripts {
+			width = max(width, len(script.Name))
+		}
+	}
+
+	printed := 0
+	for _, group := range groups {
+		if len(group.Scripts) == 0 {
+			continue
+		}
+		indent := ""
+		if len(groups) > 1 {
+			if printed > 0 {
+				logger.Plainln("")
+			}
+			logger.Plainln("%s", groupLabel(group))
+			indent = "  "
+		}
+		for _, script := range group.Scripts {
+			logger.Plainln("%s%-*s  %s", indent, width, script.Name, script.Command)
+		}
+		printed++
+	}
+}
+
+// FuzzySelectScript shows a fuzzy finder over the scripts of the groups,
+// previewing the command, and returns the picked group and script
+func FuzzySelectScript(groups []ScriptGroup) (*ScriptGroup, *Script, error) {
+	type entry struct {
+		group  *ScriptGroup
+		script *Script
+	}
+	var entries []entry
+	for i := range groups {
+		for j := range groups[i].Scripts {
+			entries = append(entries, entry{group: &groups[i], script: &groups[i].Scripts[j]})
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil, fmt.Errorf("no scripts found")
+	}
+
+	idx, err := fuzzyfinder.Find(
+		entries,
+		func(i int) string {
+			if len(groups) > 1 {
+				return entries[i].group.Name + " › " + entries[i].script.Name
+			}
+			return entries[i].script.Name
+		},
+		fuzzyfinder.WithPreviewWindow(func(i, w, h int) string {
+			if i == -1 {
+				return ""
+			}
+			e := entries[i]
+			return fmt.Sprintf("Script: %s\nPackage: %s\n\n%s", e.script.Name, groupLabel(*e.group), wrapCommand(e.script.Command, w-2))
+		}),
+	)
+	if errors.Is(err, fuzzyfinder.ErrAbort) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries[idx].group, entries[idx].script, nil
+}
+
+// groupLabel renders "name (path)", leaving out the path at the root
+func groupLabel(group ScriptGroup) string {
+	if group.RelDir == "" || group.RelDir == "." {
+		return group.Name
+	}
+	return fmt.Sprintf("%s (%s)", group.Name, filepath.ToSlash(group.RelDir))
+}
+
+// minWrapWidth keeps hard-wrapping making progress on narrow previews,
+// as continuation lines are indented
+const minWrapWidth = 8
+
+// wrapCommand breaks a long command at && and || so the preview stays
+// readable, then hard-wraps lines wider than width runes
+func wrapCommand(command string, width int) string {
+	command = strings.NewReplacer(" && ", "\n  && ", " || ", "\n  || ").Replace(command)
+	if width <= 0 {
+		return command
+	}
+	width = max(width, minWrapWidth)
+
+	var lines []string
+	for _, line := range strings.Split(command, "\n") {
+		runes := []rune(line)
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = append([]rune("    "), runes[width:]...)
+		}
+		lines = append(lines, string(runes))
+	}
+	return strings.Join(lines, "\n")
+}
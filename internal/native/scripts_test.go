@@ -0,0 +1,145 @@
+package native
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/AkaraChen/gnpm/internal/logger"
+)
+
+// writeFiles creates files under dir, making parent directories as needed
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// groupNames returns "name:script" pairs for comparing groups
+func groupNames(groups []ScriptGroup) []string {
+	var names []string
+	for _, group := range groups {
+		for _, script := range group.Scripts {
+			names = append(names, group.Name+":"+script.Name)
+		}
+	}
+	return names
+}
+
+func TestScriptGroups(t *testing.T) {
+	workspaces := map[string]map[string]string{
+		"package.json workspaces": {
+			"package.json": `{"name": "root", "workspaces": ["packages/*"], "scripts": {"lint": "eslint ."}}`,
+		},
+		"pnpm-workspace.yaml": {
+			"package.json":        `{"name": "root", "scripts": {"lint": "eslint ."}}`,
+			"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n",
+		},
+	}
+	for name, files := range workspaces {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, files)
+			writeFiles(t, root, map[string]string{
+				"packages/web/package.json": `{"name": "web", "scripts": {"dev": "vite", "build": "vite build"}}`,
+				"packages/api/package.json": `{"name": "api", "scripts": {"start": "node ."}}`,
+			})
+
+			groups, err := ScriptGroups(root)
+			if err != nil {
+				t.Fatalf("ScriptGroups failed: %v", err)
+			}
+			want := []string{"root:lint", "api:start", "web:build", "web:dev"}
+			if got := groupNames(groups); !reflect.DeepEqual(got, want) {
+				t.Errorf("ScriptGroups = %v, want %v", got, want)
+			}
+			if groups[1].RelDir != filepath.Join("packages", "api") {
+				t.Errorf("RelDir = %q", groups[1].RelDir)
+			}
+
+			// A workspace package only lists its own scripts
+			groups, err = ScriptGroups(filepath.Join(root, "packages", "web"))
+			if err != nil {
+				t.Fatalf("ScriptGroups failed: %v", err)
+			}
+			if got := groupNames(groups); !reflect.DeepEqual(got, []string{"web:build", "web:dev"}) {
+				t.Errorf("ScriptGroups in a package = %v", got)
+			}
+		})
+	}
+}
+
+func TestSortScripts(t *testing.T) {
+	got := SortScripts(map[string]string{"test": "vitest", "build": "vite build", "dev": "vite", "build:types": "tsc"})
+	want := []Script{
+		{Name: "build", Command: "vite build"},
+		{Name: "build:types", Command: "tsc"},
+		{Name: "dev", Command: "vite"},
+		{Name: "test", Command: "vitest"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SortScripts = %v, want %v", got, want)
+	}
+}
+
+func TestPrintScripts(t *testing.T) {
+	var out bytes.Buffer
+	defer logger.SetOutput(&out, io.Discard)()
+
+	web := ScriptGroup{Name: "web", RelDir: filepath.Join("packages", "web"), Scripts: []Script{{Name: "build", Command: "vite build"}, {Name: "dev", Command: "vite"}}}
+	PrintScripts([]ScriptGroup{web})
+	want := "build  vite build\n" +
+		"dev    vite\n"
+	if out.String() != want {
+		t.Errorf("single group:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// Names line up across groups, and groups without scripts are skipped
+	out.Reset()
+	root := ScriptGroup{Name: "root", Scripts: []Script{{Name: "lint", Command: "eslint ."}}}
+	empty := ScriptGroup{Name: "docs", RelDir: "docs"}
+	PrintScripts([]ScriptGroup{root, empty, web})
+	want = "root\n" +
+		"  lint   eslint .\n" +
+		"\n" +
+		"web (packages/web)\n" +
+		"  build  vite build\n" +
+		"  dev    vite\n"
+	if out.String() != want {
+		t.Errorf("groups:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWrapCommand(t *testing.T) {
+	if got := wrapCommand("tsc && vite build || exit 1", 0); got != "tsc\n  && vite build\n  || exit 1" {
+		t.Errorf("wrapCommand split = %q", got)
+	}
+	if got := wrapCommand("echo abcdefghij", 10); got != "echo abcde\n    fghij" {
+		t.Errorf("wrapCommand wrap = %q", got)
+	}
+
+	// Narrow previews must still terminate, and runes stay whole
+	for width := 1; width <= 8; width++ {
+		got := wrapCommand("echo 日本語のテキストをここに書く", width)
+		for _, line := range strings.Split(got, "\n") {
+			if !utf8.ValidString(line) {
+				t.Errorf("wrapCommand(width %d) split a rune: %q", width, line)
+			}
+			if n := utf8.RuneCountInString(line); n > max(width, minWrapWidth) {
+				t.Errorf("wrapCommand(width %d) line %q has %d runes", width, line, n)
+			}
+		}
+	}
+}
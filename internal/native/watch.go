@@ -0,0 +1,118 @@
+package native
+
+import (
+	stdcontext "context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/watch"
+)
+
+// WatchOptions for re-running a script when files change
+type WatchOptions struct {
+	Run RunOptions
+
+	// Dependents run after the script succeeds, in order: the same script
+	// in the workspace packages depending on this one
+	Dependents []RunOptions
+
+	// Include and Ignore pick the watched files with globs relative to the
+	// package, on top of .gitignore
+	Include []string
+	Ignore  []string
+}
+
+// Watch runs the script and its dependents, then runs them again whenever
+// a file in the package changes, stopping a run still in progress first.
+// It returns when gnpm is interrupted.
+func Watch(opts WatchOptions) error {
+	pkgPath, err := context.FindPackageJSON(opts.Run.Dir)
+	if err != nil {
+		return err
+	}
+	pkgDir := filepath.Dir(pkgPath)
+
+	ctx, stop := signal.NotifyContext(stdcontext.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+	changes := watch.Watch(ctx, pkgDir, watch.Options{Include: opts.Include, Ignore: opts.Ignore})
+
+	var cancel stdcontext.CancelFunc
+	var done chan struct{}
+	start := func() {
+		var runCtx stdcontext.Context
+		runCtx, cancel = stdcontext.WithCancel(ctx)
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			runWatched(runCtx, opts)
+		}()
+	}
+
+	start()
+	for {
+		select {
+		case <-ctx.Done():
+			cancel()
+			<-done
+			return nil
+		case files, ok := <-changes:
+			if !ok {
+				continue
+			}
+			logger.Info("%s changed, re-running %s", describeChanges(files), opts.Run.Script)
+			cancel()
+			<-done
+			start()
+		}
+	}
+}
+
+// runWatched runs the script and then each dependent, stopping at the
+// first failure
+func runWatched(ctx stdcontext.Context, opts WatchOptions) {
+	runs := append([]RunOptions{opts.Run}, opts.Dependents...)
+	for i, run := range runs {
+		run.Context = ctx
+		if i > 0 {
+			logger.Info("Running %s in %s", run.Script, packageName(run.Dir))
+		}
+		err := Run(run)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logger.Error("%s failed: %v", run.Script, err)
+			break
+		}
+	}
+	logger.Dim("Waiting for changes...")
+}
+
+// describeChanges names the first changed file and how many others changed
+func describeChanges(files []string) string {
+	switch len(files) {
+	case 1:
+		return files[0]
+	case 2:
+		return strings.Join(files, " and ")
+	default:
+		return fmt.Sprintf("%s and %d more files", files[0], len(files)-1)
+	}
+}
+
+// packageName returns the name of the package holding dir, or the
+// directory's name when it has none
+func packageName(dir string) string {
+	if pkgPath, err := context.FindPackageJSON(dir); err == nil {
+		if pkg, err := context.ReadPackageJSON(pkgPath); err == nil && pkg.Name != "" {
+			return pkg.Name
+		}
+	}
+	return filepath.Base(dir)
+}
@@ -0,0 +1,44 @@
+package overrides
+
+import (
+	"sort"
+
+	"github.com/AkaraChen/gnpm/internal/lockfile"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// Match is a locked version of an overridden package
+type Match struct {
+	Version string
+	Count   int  // lockfile entries resolved to this version
+	Forced  bool // already the version the override forces
+}
+
+// Explain lists the locked versions of the override's package it applies to,
+// lowest first. Versions the override already forced are included and
+// marked, so an applied override still shows what it pins.
+func Explain(lock *lockfile.Lockfile, o Override) []Match {
+	counts := map[string]int{}
+	for _, pkg := range lock.Packages {
+		if pkg.Name != o.Name {
+			continue
+		}
+		if o.Range == "" || semver.Satisfies(pkg.Version, o.Range) || forces(o, pkg.Version) {
+			counts[pkg.Version]++
+		}
+	}
+
+	var matches []Match
+	for version, count := range counts {
+		matches = append(matches, Match{Version: version, Count: count, Forced: forces(o, version)})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return semver.Compare(matches[i].Version, matches[j].Version) < 0
+	})
+	return matches
+}
+
+// forces reports whether version is what the override resolves to
+func forces(o Override, version string) bool {
+	return version == o.Version || (semver.ValidRange(o.Version) && semver.Satisfies(version, o.Version))
+}
@@ -0,0 +1,150 @@
+package overrides
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// Override forces the version of a package wherever it appears in the
+// dependency tree
+type Override struct {
+	Name    string // package the override applies to
+	Range   string // only versions in this range; empty for every version
+	Parent  string // only below this package; empty for anywhere
+	Version string // version or specifier used instead
+	Key     string // key as written in the file; npm nests the parent instead
+}
+
+// String renders the override target in gnpm's syntax, "parent>name@range"
+func (o Override) String() string {
+	target := o.Name
+	if o.Range != "" {
+		target += "@" + o.Range
+	}
+	if o.Parent != "" {
+		target = o.Parent + ">" + target
+	}
+	return target
+}
+
+// ParseTarget parses "<pkg>[@range]", optionally below a parent as
+// "<parent>><pkg>[@range]"
+func ParseTarget(arg string) (Override, error) {
+	var o Override
+	var target string
+	o.Parent, target = splitParent(strings.TrimSpace(arg))
+
+	o.Name, o.Range = splitNameRange(target)
+	if o.Name == "" {
+		return Override{}, fmt.Errorf("invalid override target %q", arg)
+	}
+	if o.Range != "" && !semver.ValidRange(o.Range) {
+		return Override{}, fmt.Errorf("invalid version range %q in %q", o.Range, arg)
+	}
+	return o, nil
+}
+
+// FormatKey renders the key pm expects for an override target. npm keys
+// don't include the parent, which nests the key instead.
+func FormatKey(pm pmcombo.PackageManager, o Override) (string, error) {
+	switch pm {
+	case pmcombo.NPM:
+		return joinNameRange(o.Name, o.Range), nil
+	case pmcombo.PNPM:
+		key := joinNameRange(o.Name, o.Range)
+		if o.Parent != "" {
+			key = o.Parent + ">" + key
+		}
+		return key, nil
+	case pmcombo.Yarn, pmcombo.YarnClassic:
+		key := o.Name
+		if o.Range != "" {
+			if pm == pmcombo.YarnClassic {
+				return "", fmt.Errorf("yarn classic resolutions apply to every version of a package; drop @%s", o.Range)
+			}
+			key += "@npm:" + o.Range
+		}
+		if o.Parent != "" {
+			key = o.Parent + "/" + key
+		}
+		return key, nil
+	case pmcombo.Bun:
+		if o.Range != "" || o.Parent != "" {
+			return "", fmt.Errorf("bun overrides apply to every version of a package everywhere; use %s without a range or parent", o.Name)
+		}
+		return o.Name, nil
+	default:
+		return "", fmt.Errorf("%s does not support overrides", pm)
+	}
+}
+
+// ParseKey parses a key as written for pm; npm parents come from nesting
+// and are not part of the key
+func ParseKey(pm pmcombo.PackageManager, key string) Override {
+	o := Override{Key: key}
+	switch pm {
+	case pmcombo.PNPM:
+		var target string
+		o.Parent, target = splitParent(key)
+		o.Name, o.Range = splitNameRange(target)
+	case pmcombo.Yarn, pmcombo.YarnClassic:
+		// "**/name" matches anywhere, "parent/name" only below parent;
+		// scoped names span two segments
+		var names []string
+		segments := strings.Split(key, "/")
+		for i := 0; i < len(segments); i++ {
+			segment := segments[i]
+			if strings.HasPrefix(segment, "@") && !strings.Contains(segment, "@npm:") && i+1 < len(segments) {
+				segment += "/" + segments[i+1]
+				i++
+			}
+			if segment != "**" {
+				names = append(names, segment)
+			}
+		}
+		if len(names) == 0 {
+			return o
+		}
+		target := names[len(names)-1]
+		o.Parent = strings.Join(names[:len(names)-1], ">")
+		if name, rng, ok := strings.Cut(target[1:], "@npm:"); ok {
+			o.Name, o.Range = target[:1]+name, rng
+		} else {
+			o.Name = target
+		}
+	default:
+		o.Name, o.Range = splitNameRange(key)
+	}
+	return o
+}
+
+// splitParent splits "parent>name@range" at the last ">" separating
+// packages. A ">" right after "@", a space or another comparator belongs to
+// a range such as ">=1.2.0".
+func splitParent(value string) (string, string) {
+	for i := len(value) - 1; i > 0; i-- {
+		if value[i] == '>' && !strings.ContainsRune("@ |<>=", rune(value[i-1])) {
+			return value[:i], value[i+1:]
+		}
+	}
+	return "", value
+}
+
+// splitNameRange splits "name@range" or "@scope/name@range"
+func splitNameRange(value string) (string, string) {
+	at := strings.LastIndex(value, "@")
+	if at <= 0 {
+		return value, ""
+	}
+	return value[:at], value[at+1:]
+}
+
+func joinNameRange(name string, rng string) string {
+	if rng == "" {
+		return name
+	}
+	return name + "@" + rng
+}
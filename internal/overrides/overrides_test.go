@@ -0,0 +1,108 @@
+package overrides
+
+import (
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		arg    string
+		name   string
+		rng    string
+		parent string
+	}{
+		{"lodash", "lodash", "", ""},
+		{"lodash@<4.17.21", "lodash", "<4.17.21", ""},
+		{"@babel/core@^7.0.0", "@babel/core", "^7.0.0", ""},
+		{"lodash@>=4.0.0 <4.17.21", "lodash", ">=4.0.0 <4.17.21", ""},
+		{"webpack>lodash", "lodash", "", "webpack"},
+		{"webpack@5>@types/node@>=18", "@types/node", ">=18", "webpack@5"},
+	}
+	for _, tt := range tests {
+		o, err := ParseTarget(tt.arg)
+		if err != nil {
+			t.Errorf("ParseTarget(%q) failed: %v", tt.arg, err)
+			continue
+		}
+		if o.Name != tt.name || o.Range != tt.rng || o.Parent != tt.parent {
+			t.Errorf("ParseTarget(%q) = %+v", tt.arg, o)
+		}
+		if o.String() != tt.arg {
+			t.Errorf("String() = %q, want %q", o.String(), tt.arg)
+		}
+	}
+
+	for _, arg := range []string{"", "webpack>", "lodash@not a range"} {
+		if _, err := ParseTarget(arg); err == nil {
+			t.Errorf("ParseTarget(%q) should fail", arg)
+		}
+	}
+}
+
+func TestFormatKey(t *testing.T) {
+	ranged := Override{Name: "lodash", Range: "<4.17.21"}
+	nested := Override{Name: "@types/node", Parent: "webpack"}
+
+	tests := []struct {
+		pm   pmcombo.PackageManager
+		o    Override
+		want string
+	}{
+		{pmcombo.NPM, ranged, "lodash@<4.17.21"},
+		{pmcombo.NPM, nested, "@types/node"},
+		{pmcombo.PNPM, ranged, "lodash@<4.17.21"},
+		{pmcombo.PNPM, nested, "webpack>@types/node"},
+		{pmcombo.Yarn, ranged, "lodash@npm:<4.17.21"},
+		{pmcombo.Yarn, nested, "webpack/@types/node"},
+		{pmcombo.YarnClassic, nested, "webpack/@types/node"},
+		{pmcombo.Bun, Override{Name: "lodash"}, "lodash"},
+	}
+	for _, tt := range tests {
+		got, err := FormatKey(tt.pm, tt.o)
+		if err != nil {
+			t.Errorf("FormatKey(%s, %s) failed: %v", tt.pm, tt.o, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("FormatKey(%s, %s) = %q, want %q", tt.pm, tt.o, got, tt.want)
+		}
+		if tt.pm == pmcombo.NPM {
+			continue
+		}
+		if parsed := ParseKey(tt.pm, got); parsed.String() != tt.o.String() {
+			t.Errorf("ParseKey(%s, %q) = %s, want %s", tt.pm, got, parsed, tt.o)
+		}
+	}
+
+	for _, tt := range []struct {
+		pm pmcombo.PackageManager
+		o  Override
+	}{
+		{pmcombo.Bun, ranged},
+		{pmcombo.Bun, nested},
+		{pmcombo.YarnClassic, ranged},
+		{pmcombo.Deno, Override{Name: "lodash"}},
+	} {
+		if _, err := FormatKey(tt.pm, tt.o); err == nil {
+			t.Errorf("FormatKey(%s, %s) should fail", tt.pm, tt.o)
+		}
+	}
+}
+
+func TestParseYarnKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"**/lodash", "lodash"},
+		{"@scope/pkg@npm:^1.0.0", "@scope/pkg@^1.0.0"},
+		{"@scope/parent/**/left-pad", "@scope/parent>left-pad"},
+	}
+	for _, tt := range tests {
+		if got := ParseKey(pmcombo.Yarn, tt.key).String(); got != tt.want {
+			t.Errorf("ParseKey(yarn, %q) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
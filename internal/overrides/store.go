@@ -0,0 +1,226 @@
+package overrides
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/yamldoc"
+)
+
+const pnpmWorkspaceFile = "pnpm-workspace.yaml"
+
+// Store reads and writes the overrides of a workspace root in the place its
+// package manager looks: overrides for npm and bun, resolutions for yarn,
+// and pnpm.overrides or pnpm-workspace.yaml overrides for pnpm
+type Store struct {
+	PM    pmcombo.PackageManager
+	Path  string   // file holding the overrides
+	Field []string // key path of the overrides object in the file
+
+	manifest *context.Manifest
+	doc      *yaml.Node
+}
+
+// Open locates the overrides of the workspace at rootDir for pm
+func Open(rootDir string, pm pmcombo.PackageManager) (*Store, error) {
+	manifestPath := filepath.Join(rootDir, "package.json")
+	store := &Store{PM: pm, Path: manifestPath}
+
+	switch pm {
+	case pmcombo.NPM:
+		store.Field = []string{"overrides"}
+	case pmcombo.Yarn, pmcombo.YarnClassic:
+		store.Field = []string{"resolutions"}
+	case pmcombo.Bun:
+		store.Field = []string{"overrides"}
+	case pmcombo.PNPM:
+		store.Field = []string{"pnpm", "overrides"}
+	default:
+		return nil, fmt.Errorf("%s does not support overrides", pm)
+	}
+
+	manifest, err := context.ReadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	store.manifest = manifest
+
+	switch pm {
+	case pmcombo.Bun:
+		// bun also reads yarn's resolutions field
+		_, hasOverrides := manifest.Get("overrides")
+		if _, hasResolutions := manifest.Get("resolutions"); hasResolutions && !hasOverrides {
+			store.Field = []string{"resolutions"}
+		}
+	case pmcombo.PNPM:
+		// pnpm 10 reads overrides from pnpm-workspace.yaml; package.json
+		// is only used when it already holds them or there is no yaml
+		workspacePath := filepath.Join(rootDir, pnpmWorkspaceFile)
+		_, inManifest := manifest.Get("pnpm", "overrides")
+		if _, err := os.Stat(workspacePath); err == nil && !inManifest {
+			doc, err := yamldoc.Read(workspacePath)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", workspacePath, err)
+			}
+			store.Path, store.Field, store.doc = workspacePath, []string{"overrides"}, doc
+			store.manifest = nil
+		}
+	}
+	return store, nil
+}
+
+// Location describes where the overrides live, e.g. "package.json (pnpm.overrides)"
+func (s *Store) Location() string {
+	return fmt.Sprintf("%s (%s)", filepath.Base(s.Path), strings.Join(s.Field, "."))
+}
+
+// List returns the overrides in file order
+func (s *Store) List() []Override {
+	if s.doc != nil {
+		mapping := yamldoc.Get(yamldoc.Root(s.doc), s.Field[0])
+		if mapping == nil || mapping.Kind != yaml.MappingNode {
+			return nil
+		}
+		var list []Override
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			o := ParseKey(s.PM, mapping.Content[i].Value)
+			o.Version = mapping.Content[i+1].Value
+			list = append(list, o)
+		}
+		return list
+	}
+
+	if s.PM == pmcombo.NPM {
+		return s.listNested(s.Field, nil)
+	}
+
+	var list []Override
+	for _, key := range s.manifest.Keys(s.Field...) {
+		o := ParseKey(s.PM, key)
+		o.Version, _ = s.manifest.GetString(append(s.Field, key)...)
+		list = append(list, o)
+	}
+	return list
+}
+
+// listNested flattens npm's nested overrides; "." inside an object
+// overrides the package the object is keyed by
+func (s *Store) listNested(keys []string, parents []string) []Override {
+	var list []Override
+	for _, key := range s.manifest.Keys(keys...) {
+		path := append(append([]string{}, keys...), key)
+		if version, ok := s.manifest.GetString(path...); ok {
+			o := ParseKey(s.PM, key)
+			o.Parent = strings.Join(parents, ">")
+			if key == "." && len(parents) > 0 {
+				o = ParseKey(s.PM, parents[len(parents)-1])
+				o.Key = key
+				o.Parent = strings.Join(parents[:len(parents)-1], ">")
+			}
+			o.Version = version
+			list = append(list, o)
+			continue
+		}
+		list = append(list, s.listNested(path, append(append([]string{}, parents...), key))...)
+	}
+	return list
+}
+
+// Set adds or replaces an override
+func (s *Store) Set(o Override) error {
+	key, err := FormatKey(s.PM, o)
+	if err != nil {
+		return err
+	}
+
+	if s.doc != nil {
+		root := yamldoc.Root(s.doc)
+		mapping := yamldoc.Get(root, s.Field[0])
+		if mapping == nil || mapping.Kind != yaml.MappingNode {
+			mapping = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			yamldoc.Set(root, s.Field[0], mapping)
+		}
+		mapping.Style = 0
+		yamldoc.Set(mapping, key, yamldoc.String(o.Version))
+		return nil
+	}
+
+	path := append(append([]string{}, s.Field...), s.nestedParents(o)...)
+	if s.PM == pmcombo.NPM && len(s.manifest.Keys(append(path, key)...)) > 0 {
+		// The package already has nested overrides; its own version goes under "."
+		return s.manifest.Set(append(path, key, "."), o.Version)
+	}
+	if s.PM == pmcombo.NPM && o.Parent != "" {
+		// A parent overridden by a plain string becomes {".": version}
+		for i := len(s.Field) + 1; i <= len(path); i++ {
+			if version, ok := s.manifest.GetString(path[:i]...); ok {
+				if err := s.manifest.Set(path[:i], map[string]string{".": version}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return s.manifest.Set(append(path, key), o.Version)
+}
+
+// Remove deletes an override and reports whether it existed. Objects left
+// empty are removed too.
+func (s *Store) Remove(o Override) (bool, error) {
+	key, err := FormatKey(s.PM, o)
+	if err != nil {
+		return false, err
+	}
+
+	if s.doc != nil {
+		root := yamldoc.Root(s.doc)
+		mapping := yamldoc.Get(root, s.Field[0])
+		if mapping == nil || !yamldoc.Delete(mapping, key) {
+			return false, nil
+		}
+		if len(mapping.Content) == 0 {
+			yamldoc.Delete(root, s.Field[0])
+		}
+		return true, nil
+	}
+
+	path := append(append([]string{}, s.Field...), s.nestedParents(o)...)
+	path = append(path, key)
+	if _, ok := s.manifest.GetString(path...); !ok {
+		// An npm parent with nested overrides keeps its own version under "."
+		path = append(path, ".")
+		if _, ok := s.manifest.GetString(path...); !ok {
+			return false, nil
+		}
+	}
+	s.manifest.Delete(path...)
+
+	for i := len(path) - 1; i > 0; i-- {
+		if len(s.manifest.Keys(path[:i]...)) > 0 {
+			break
+		}
+		s.manifest.Delete(path[:i]...)
+	}
+	return true, nil
+}
+
+// Save writes the overrides back to their file
+func (s *Store) Save() error {
+	if s.doc != nil {
+		return yamldoc.Write(s.Path, s.doc)
+	}
+	return s.manifest.Write()
+}
+
+// nestedParents returns the parent keys npm nests an override under
+func (s *Store) nestedParents(o Override) []string {
+	if s.PM != pmcombo.NPM || o.Parent == "" {
+		return nil
+	}
+	return strings.Split(o.Parent, ">")
+}
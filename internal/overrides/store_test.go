@@ -0,0 +1,195 @@
+package overrides
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/lockfile"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+func TestStoreLocation(t *testing.T) {
+	tests := []struct {
+		pm    pmcombo.PackageManager
+		files map[string]string
+		want  string
+	}{
+		{pmcombo.NPM, nil, "package.json (overrides)"},
+		{pmcombo.Bun, nil, "package.json (overrides)"},
+		{pmcombo.Bun, map[string]string{"package.json": `{"resolutions": {"a": "1.0.0"}}`}, "package.json (resolutions)"},
+		{pmcombo.Yarn, nil, "package.json (resolutions)"},
+		{pmcombo.PNPM, nil, "package.json (pnpm.overrides)"},
+		{pmcombo.PNPM, map[string]string{"pnpm-workspace.yaml": "packages:\n  - packages/*\n"}, "pnpm-workspace.yaml (overrides)"},
+		{pmcombo.PNPM, map[string]string{
+			"package.json":        `{"pnpm": {"overrides": {"a": "1.0.0"}}}`,
+			"pnpm-workspace.yaml": "packages:\n  - packages/*\n",
+		}, "package.json (pnpm.overrides)"},
+	}
+	for _, tt := range tests {
+		store := openStore(t, tt.pm, tt.files)
+		if got := store.Location(); got != tt.want {
+			t.Errorf("%s %v: Location = %q, want %q", tt.pm, tt.files, got, tt.want)
+		}
+	}
+
+	if _, err := Open(t.TempDir(), pmcombo.Deno); err == nil {
+		t.Error("Open(deno) should fail")
+	}
+}
+
+func TestStoreSetAndRemove(t *testing.T) {
+	tests := []struct {
+		name    string
+		pm      pmcombo.PackageManager
+		files   map[string]string
+		set     []string // "<target>=<version>"
+		saved   string   // the file after Set
+		listed  []string // List after reopening it
+		removed string   // the file after removing every target again
+	}{
+		{
+			name:  "npm nests overrides below their parent",
+			pm:    pmcombo.NPM,
+			files: map[string]string{"package.json": "{\n  \"name\": \"root\",\n  \"overrides\": {\n    \"webpack\": \"5.90.0\"\n  }\n}"},
+			set:   []string{"webpack>lodash=4.17.21", "lodash@<4.17.21=4.17.21", "webpack=5.91.0"},
+			saved: `{
+  "name": "root",
+  "overrides": {
+    "webpack": {
+      ".": "5.91.0",
+      "lodash": "4.17.21"
+    },
+    "lodash@<4.17.21": "4.17.21"
+  }
+}
+`,
+			listed:  []string{"webpack 5.91.0", "webpack>lodash 4.17.21", "lodash@<4.17.21 4.17.21"},
+			removed: "{\n  \"name\": \"root\"\n}\n",
+		},
+		{
+			name:    "pnpm workspace file",
+			pm:      pmcombo.PNPM,
+			files:   map[string]string{"pnpm-workspace.yaml": "# workspace\npackages:\n  - packages/*\n"},
+			set:     []string{"lodash@<4.17.21=4.17.21"},
+			saved:   "# workspace\npackages:\n    - packages/*\noverrides:\n    lodash@<4.17.21: 4.17.21\n",
+			listed:  []string{"lodash@<4.17.21 4.17.21"},
+			removed: "# workspace\npackages:\n    - packages/*\n",
+		},
+		{
+			name:    "yarn resolutions",
+			pm:      pmcombo.Yarn,
+			set:     []string{"webpack>lodash@^4.0.0=4.17.21"},
+			saved:   "{\n  \"name\": \"root\",\n  \"resolutions\": {\n    \"webpack/lodash@npm:^4.0.0\": \"4.17.21\"\n  }\n}\n",
+			listed:  []string{"webpack>lodash@^4.0.0 4.17.21"},
+			removed: "{\n  \"name\": \"root\"\n}\n",
+		},
+	}
+
+	assertSaved := func(t *testing.T, store *Store, want string) {
+		t.Helper()
+		if err := store.Save(); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		data, err := os.ReadFile(store.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != want {
+			t.Errorf("%s =\n%s\nwant\n%s", filepath.Base(store.Path), got, want)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := openStore(t, tt.pm, tt.files)
+			var targets []Override
+			for _, arg := range tt.set {
+				target, version, _ := strings.Cut(arg, "=")
+				o, err := ParseTarget(target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				o.Version = version
+				if err := store.Set(o); err != nil {
+					t.Fatalf("Set(%s) failed: %v", arg, err)
+				}
+				targets = append(targets, o)
+			}
+			assertSaved(t, store, tt.saved)
+
+			reopened, err := Open(filepath.Dir(store.Path), tt.pm)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			var listed []string
+			for _, o := range reopened.List() {
+				listed = append(listed, o.String()+" "+o.Version)
+			}
+			if !reflect.DeepEqual(listed, tt.listed) {
+				t.Errorf("List = %q, want %q", listed, tt.listed)
+			}
+
+			for _, o := range targets {
+				if removed, err := reopened.Remove(o); err != nil || !removed {
+					t.Fatalf("Remove(%s) = %v, %v", o, removed, err)
+				}
+			}
+			if removed, _ := reopened.Remove(Override{Name: "missing"}); removed {
+				t.Error("Remove of a missing override should report false")
+			}
+			assertSaved(t, reopened, tt.removed)
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	lock := &lockfile.Lockfile{Packages: []lockfile.Package{
+		{Name: "lodash", Version: "4.17.15"},
+		{Name: "lodash", Version: "4.17.20"},
+		{Name: "lodash", Version: "4.17.20"},
+		{Name: "lodash", Version: "4.17.21"},
+		{Name: "lodash", Version: "3.10.1"},
+		{Name: "lodash-es", Version: "4.17.20"},
+	}}
+
+	matches := Explain(lock, Override{Name: "lodash", Range: ">=4.0.0 <4.17.21", Version: "4.17.21"})
+	want := []Match{
+		{Version: "4.17.15", Count: 1},
+		{Version: "4.17.20", Count: 2},
+		{Version: "4.17.21", Count: 1, Forced: true},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("Explain = %+v, want %+v", matches, want)
+	}
+
+	if matches := Explain(lock, Override{Name: "lodash", Version: "^4.17.0"}); len(matches) != 4 || matches[0].Forced || !matches[3].Forced {
+		t.Errorf("Explain without range = %+v", matches)
+	}
+}
+
+// openStore opens the overrides of a project with a bare package.json and
+// the files, which may replace it
+func openStore(t *testing.T, pm pmcombo.PackageManager, files map[string]string) *Store {
+	t.Helper()
+
+	rootDir := t.TempDir()
+	write := func(name string, content string) {
+		path := filepath.Join(rootDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	write("package.json", `{"name": "root"}`)
+	for name, content := range files {
+		write(name, content)
+	}
+
+	store, err := Open(rootDir, pm)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return store
+}
@@ -0,0 +1,264 @@
+package patch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FilePatch is the part of a unified diff changing one file
+type FilePatch struct {
+	Path    string // slash-separated, relative to the package
+	Created bool
+	Deleted bool
+	Hunks   []Hunk
+}
+
+// Hunk is one change in a file; lines keep their trailing newlines
+type Hunk struct {
+	OldStart int
+	Old      []string
+	New      []string
+}
+
+// Parse reads a unified diff as written by DiffDirs, git or pnpm
+func Parse(text string) ([]FilePatch, error) {
+	var files []FilePatch
+	var file *FilePatch
+	var current *Hunk
+	var lastKind byte
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FilePatch{})
+			file, current = &files[len(files)-1], nil
+		case strings.HasPrefix(line, "--- ") && current == nil:
+			if file == nil {
+				files = append(files, FilePatch{})
+				file = &files[len(files)-1]
+			}
+			if name := diffPath(line[4:]); name == "" {
+				file.Created = true
+			} else {
+				file.Path = name
+			}
+		case strings.HasPrefix(line, "+++ ") && current == nil:
+			if file == nil {
+				return nil, fmt.Errorf("line %d: +++ without ---", lineNo)
+			}
+			if name := diffPath(line[4:]); name == "" {
+				file.Deleted = true
+			} else {
+				file.Path = name
+			}
+		case strings.HasPrefix(line, "@@ "):
+			if file == nil || file.Path == "" {
+				return nil, fmt.Errorf("line %d: hunk without a file header", lineNo)
+			}
+			start, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			file.Hunks = append(file.Hunks, Hunk{OldStart: start})
+			current = &file.Hunks[len(file.Hunks)-1]
+		case current != nil && len(line) > 0 && strings.ContainsRune(" -+", rune(line[0])):
+			text := line[1:] + "\n"
+			switch line[0] {
+			case ' ':
+				current.Old = append(current.Old, text)
+				current.New = append(current.New, text)
+			case '-':
+				current.Old = append(current.Old, text)
+			case '+':
+				current.New = append(current.New, text)
+			}
+			lastKind = line[0]
+		case current != nil && line == "":
+			// Some editors strip the space of empty context lines
+			current.Old = append(current.Old, "\n")
+			current.New = append(current.New, "\n")
+			lastKind = ' '
+		case current != nil && strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" applies to the previous line
+			if lastKind != '+' {
+				trimLast(current.Old)
+			}
+			if lastKind != '-' {
+				trimLast(current.New)
+			}
+		default:
+			// git extended headers (index, mode) and text between files
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func trimLast(lines []string) {
+	if len(lines) > 0 {
+		lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], "\n")
+	}
+}
+
+// diffPath strips the a/ or b/ prefix; /dev/null yields ""
+func diffPath(name string) string {
+	name, _, _ = strings.Cut(name, "\t")
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, "a/") || strings.HasPrefix(name, "b/") {
+		return name[2:]
+	}
+	return name
+}
+
+// parseHunkHeader returns the old start line of "@@ -l,s +l,s @@"
+func parseHunkHeader(line string) (int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") {
+		return 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+	startText, _, _ := strings.Cut(fields[1][1:], ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hunk header %q", line)
+	}
+	return start, nil
+}
+
+// Apply applies file patches to the package in dir. A file the patch was
+// already applied to is left alone, so applying twice is harmless. Nothing
+// is written unless every file applies.
+func Apply(dir string, files []FilePatch) (changed bool, err error) {
+	results := map[string]*string{} // nil deletes the file
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+		data, readErr := os.ReadFile(path)
+		exists := readErr == nil
+		if readErr != nil && !os.IsNotExist(readErr) {
+			return false, readErr
+		}
+
+		switch {
+		case file.Deleted:
+			if exists {
+				results[path] = nil
+			}
+			continue
+		case file.Created && exists:
+			if string(data) == newContent(file) {
+				continue
+			}
+			return false, fmt.Errorf("%s already exists", file.Path)
+		case !file.Created && !exists:
+			return false, fmt.Errorf("%s does not exist", file.Path)
+		}
+
+		lines := splitLines(string(data))
+		patched, ok := applyHunks(lines, file.Hunks, false)
+		if !ok {
+			// Already applied when the change reverses cleanly
+			if _, reversed := applyHunks(lines, file.Hunks, true); reversed {
+				continue
+			}
+			return false, fmt.Errorf("%s: patch does not apply", file.Path)
+		}
+		content := strings.Join(patched, "")
+		results[path] = &content
+	}
+
+	for path, content := range results {
+		if content == nil {
+			if err := os.Remove(path); err != nil {
+				return changed, err
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return changed, err
+			}
+			if err := os.WriteFile(path, []byte(*content), 0644); err != nil {
+				return changed, err
+			}
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+func newContent(file FilePatch) string {
+	var b strings.Builder
+	for _, h := range file.Hunks {
+		for _, line := range h.New {
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// applyHunks replaces each hunk's old lines with its new ones (or the other
+// way around when reverse is set). Hunks are looked for at their recorded
+// line first, then anywhere after the previous hunk.
+func applyHunks(lines []string, hunks []Hunk, reverse bool) ([]string, bool) {
+	var result []string
+	pos := 0   // next unconsumed line of lines
+	shift := 0 // how far hunks moved from their recorded position
+	for _, h := range hunks {
+		from, to := h.Old, h.New
+		if reverse {
+			from, to = h.New, h.Old
+		}
+
+		want := max(h.OldStart-1+shift, pos)
+		if len(h.Old) == 0 {
+			// Pure insertions record the line before
+			want = max(h.OldStart+shift, pos)
+		}
+		at := findLines(lines, from, want, pos)
+		if at < 0 {
+			return nil, false
+		}
+		shift += at - want
+		result = append(result, lines[pos:at]...)
+		result = append(result, to...)
+		pos = at + len(from)
+	}
+	return append(result, lines[pos:]...), true
+}
+
+// findLines finds needle in lines at or after min, nearest to want
+func findLines(lines []string, needle []string, want int, min int) int {
+	matches := func(at int) bool {
+		if at < min || at+len(needle) > len(lines) {
+			return false
+		}
+		for i, line := range needle {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	for delta := 0; want-delta >= min || want+delta <= len(lines); delta++ {
+		if matches(want - delta) {
+			return want - delta
+		}
+		if matches(want + delta) {
+			return want + delta
+		}
+	}
+	return -1
+}
@@ -0,0 +1,316 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines around each change
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit is one line of a diff; lines keep their trailing newline, so a
+// final line without one compares unequal to the same text with one
+type edit struct {
+	kind opKind
+	line string
+}
+
+// DiffDirs renders a unified diff turning the files in oldDir into those in
+// newDir, with paths relative to the directories. Nested node_modules are
+// skipped. Binary files can't be patched and return an error.
+func DiffDirs(oldDir string, newDir string) (string, error) {
+	oldFiles, err := listFiles(oldDir)
+	if err != nil {
+		return "", err
+	}
+	newFiles, err := listFiles(newDir)
+	if err != nil {
+		return "", err
+	}
+
+	paths := map[string]bool{}
+	for path := range oldFiles {
+		paths[path] = true
+	}
+	for path := range newFiles {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var b strings.Builder
+	for _, path := range sorted {
+		var oldData, newData []byte
+		if oldFiles[path] {
+			if oldData, err = os.ReadFile(filepath.Join(oldDir, path)); err != nil {
+				return "", err
+			}
+		}
+		if newFiles[path] {
+			if newData, err = os.ReadFile(filepath.Join(newDir, path)); err != nil {
+				return "", err
+			}
+		}
+		if oldFiles[path] && newFiles[path] && bytes.Equal(oldData, newData) {
+			continue
+		}
+		if isBinary(oldData) || isBinary(newData) {
+			return "", fmt.Errorf("binary file %s changed; patches can only contain text", path)
+		}
+		writeFileDiff(&b, path, oldData, newData, oldFiles[path], newFiles[path])
+	}
+	return b.String(), nil
+}
+
+// listFiles returns the regular files under dir by slash-separated
+// relative path
+func listFiles(dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	return files, err
+}
+
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+func writeFileDiff(b *strings.Builder, path string, oldData []byte, newData []byte, oldExists bool, newExists bool) {
+	fmt.Fprintf(b, "diff --git a/%s b/%s\n", path, path)
+	oldName, newName := "a/"+path, "b/"+path
+	switch {
+	case !oldExists:
+		b.WriteString("new file mode 100644\n")
+		oldName = "/dev/null"
+	case !newExists:
+		b.WriteString("deleted file mode 100644\n")
+		newName = "/dev/null"
+	}
+	fmt.Fprintf(b, "--- %s\n+++ %s\n", oldName, newName)
+
+	edits := diffLines(splitLines(string(oldData)), splitLines(string(newData)))
+	for _, h := range hunks(edits) {
+		fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldCount), hunkRange(h.newStart, h.newCount))
+		for _, e := range h.edits {
+			prefix := " "
+			switch e.kind {
+			case opDelete:
+				prefix = "-"
+			case opInsert:
+				prefix = "+"
+			}
+			b.WriteString(prefix + e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+}
+
+// hunkRange formats "start,count" the way git does: the count is left out
+// when it's 1, and an empty range starts at the line before
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits text after each newline, keeping the newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type hunk struct {
+	oldStart, oldCount int // 1-based
+	newStart, newCount int
+	edits              []edit
+}
+
+// hunks groups edits into hunks with contextLines of context, merging
+// changes whose context would overlap
+func hunks(edits []edit) []hunk {
+	var changes []int
+	for i, e := range edits {
+		if e.kind != opEqual {
+			changes = append(changes, i)
+		}
+	}
+
+	var result []hunk
+	for len(changes) > 0 {
+		last := 0
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*contextLines+1 {
+			last++
+		}
+		start := max(changes[0]-contextLines, 0)
+		end := min(changes[last]+contextLines+1, len(edits))
+		changes = changes[last+1:]
+
+		h := hunk{oldStart: 1, newStart: 1, edits: edits[start:end]}
+		for _, e := range edits[:start] {
+			if e.kind != opInsert {
+				h.oldStart++
+			}
+			if e.kind != opDelete {
+				h.newStart++
+			}
+		}
+		for _, e := range h.edits {
+			if e.kind != opInsert {
+				h.oldCount++
+			}
+			if e.kind != opDelete {
+				h.newCount++
+			}
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm, after
+// stripping the common prefix and suffix
+func diffLines(a []string, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []edit
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{opEqual, line})
+	}
+	return edits
+}
+
+func myers(a []string, b []string) []edit {
+	n, m := len(a), len(b)
+	limit := n + m
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+
+	// trace[d] holds the furthest x per diagonal k in [-d, d] after step d
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		if reached(trace[d], d, n, m) {
+			break
+		}
+	}
+	return backtrack(trace, a, b)
+}
+
+// reached reports whether step d arrived at (n, m)
+func reached(row []int, d int, n int, m int) bool {
+	k := n - m
+	return k >= -d && k <= d && (k+d)%2 == 0 && row[k+d] >= n
+}
+
+func backtrack(trace [][]int, a []string, b []string) []edit {
+	x, y := len(a), len(b)
+	var reversed []edit
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, edit{opEqual, a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, edit{opInsert, b[y]})
+		} else {
+			x--
+			reversed = append(reversed, edit{opDelete, a[x]})
+		}
+	}
+	for x > 0 {
+		x--
+		reversed = append(reversed, edit{opEqual, a[x]})
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
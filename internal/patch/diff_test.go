@@ -0,0 +1,141 @@
+package patch
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffDirs(t *testing.T) {
+	oldDir := writeTree(t, map[string]string{
+		"index.js":      "module.exports = 1\n",
+		"lib/util.js":   "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n",
+		"removed.js":    "gone\n",
+		"README.md":     "unchanged\n",
+		"no-newline.js": "x",
+	})
+	newDir := writeTree(t, map[string]string{
+		"index.js":      "module.exports = 2\n",
+		"lib/util.js":   "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nK\nl\n",
+		"added.js":      "new\n",
+		"README.md":     "unchanged\n",
+		"no-newline.js": "y",
+	})
+
+	diff, err := DiffDirs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("DiffDirs failed: %v", err)
+	}
+
+	want := `diff --git a/added.js b/added.js
+new file mode 100644
+--- /dev/null
++++ b/added.js
+@@ -0,0 +1 @@
++new
+diff --git a/index.js b/index.js
+--- a/index.js
++++ b/index.js
+@@ -1 +1 @@
+-module.exports = 1
++module.exports = 2
+diff --git a/lib/util.js b/lib/util.js
+--- a/lib/util.js
++++ b/lib/util.js
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -8,5 +8,5 @@
+ h
+ i
+ j
+-k
++K
+ l
+diff --git a/no-newline.js b/no-newline.js
+--- a/no-newline.js
++++ b/no-newline.js
+@@ -1 +1 @@
+-x
+\ No newline at end of file
++y
+\ No newline at end of file
+diff --git a/removed.js b/removed.js
+deleted file mode 100644
+--- a/removed.js
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`
+	if diff != want {
+		t.Errorf("DiffDirs =\n%s\nwant\n%s", diff, want)
+	}
+
+	// Applying the diff to the old tree gives the new one
+	files, err := Parse(diff)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if changed, err := Apply(oldDir, files); err != nil || !changed {
+		t.Fatalf("Apply = %v, %v", changed, err)
+	}
+	if diff, _ := DiffDirs(oldDir, newDir); diff != "" {
+		t.Errorf("trees differ after Apply:\n%s", diff)
+	}
+
+	// Applying it again is a no-op
+	if changed, err := Apply(oldDir, files); err != nil || changed {
+		t.Errorf("second Apply = %v, %v", changed, err)
+	}
+}
+
+func TestDiffLinesMerge(t *testing.T) {
+	a := strings.Split("1 2 3 4 5 6 7 8 9", " ")
+	b := strings.Split("1 X 3 4 5 6 7 Y 9", " ")
+	if got := hunks(diffLines(a, b)); len(got) != 1 {
+		t.Errorf("changes with overlapping context should share a hunk, got %d", len(got))
+	}
+
+	b = strings.Split("0 1 2 3 4 5 6 7 8 9 10", " ")
+	edits := diffLines(a, b)
+	var inserted []string
+	for _, e := range edits {
+		if e.kind == opInsert {
+			inserted = append(inserted, e.line)
+		} else if e.kind == opDelete {
+			t.Errorf("unexpected delete of %q", e.line)
+		}
+	}
+	if strings.Join(inserted, " ") != "0 10" {
+		t.Errorf("inserted = %q", inserted)
+	}
+}
+
+func TestDiffDirsBinary(t *testing.T) {
+	oldDir := writeTree(t, map[string]string{"image.png": "\x00\x01"})
+	newDir := writeTree(t, map[string]string{"image.png": "\x00\x02"})
+	if _, err := DiffDirs(oldDir, newDir); err == nil {
+		t.Error("DiffDirs should reject changed binary files")
+	}
+}
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,288 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/registry"
+)
+
+// Dir is where patches live, relative to the workspace root; pnpm and bun
+// use the same directory
+const Dir = "patches"
+
+// ApplyCommand is the postinstall script that applies patches for package
+// managers without native patching
+const ApplyCommand = "gnpm patch apply"
+
+const sessionFile = "gnpm-patch.json"
+
+// FileName returns the patch file name for a package version, in pnpm's
+// format: "@scope/pkg" becomes "@scope__pkg@1.0.0.patch"
+func FileName(name string, version string) string {
+	return strings.ReplaceAll(name, "/", "__") + "@" + version + ".patch"
+}
+
+// ParseFileName is the reverse of FileName
+func ParseFileName(file string) (name string, version string, ok bool) {
+	base, found := strings.CutSuffix(file, ".patch")
+	if !found {
+		return "", "", false
+	}
+	at := strings.LastIndex(base, "@")
+	if at <= 0 || at == len(base)-1 {
+		return "", "", false
+	}
+	return strings.ReplaceAll(base[:at], "__", "/"), base[at+1:], true
+}
+
+// Session is a package extracted for editing
+type Session struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	RootDir  string `json:"root"`
+	Original string `json:"original"`
+	EditDir  string `json:"edit"`
+}
+
+// PatchPath returns where the session's patch is saved
+func (s *Session) PatchPath() string {
+	return filepath.Join(s.RootDir, Dir, FileName(s.Name, s.Version))
+}
+
+// Start extracts the package tarball twice into a temporary directory, one
+// pristine copy to diff against and one to edit. An existing patch for the
+// version is applied to the edit copy so it can be changed further.
+func Start(rootDir string, name string, version string, tarball []byte) (*Session, error) {
+	tmp, err := os.MkdirTemp("", "gnpm-patch-")
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{
+		Name:     name,
+		Version:  version,
+		RootDir:  rootDir,
+		Original: filepath.Join(tmp, "original"),
+		EditDir:  filepath.Join(tmp, "edit"),
+	}
+
+	if err := s.start(tarball); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Session) start(tarball []byte) error {
+	for _, dir := range []string{s.Original, s.EditDir} {
+		if err := registry.Extract(tarball, dir); err != nil {
+			return fmt.Errorf("failed to extract %s@%s: %w", s.Name, s.Version, err)
+		}
+	}
+
+	if data, err := os.ReadFile(s.PatchPath()); err == nil {
+		files, err := Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", s.PatchPath(), err)
+		}
+		if _, err := Apply(s.EditDir, files); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", s.PatchPath(), err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(filepath.Dir(s.EditDir), sessionFile), data, 0644)
+}
+
+// OpenSession finds the session of an edit directory created by Start
+func OpenSession(editDir string) (*Session, error) {
+	editDir, err := filepath.Abs(editDir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(editDir), sessionFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s was not created by `gnpm patch`", editDir)
+		}
+		return nil, err
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid patch session in %s: %w", filepath.Dir(editDir), err)
+	}
+	return &s, nil
+}
+
+// Commit diffs the edit directory against the pristine copy, saves the
+// patch and removes the temporary directory. The patch text is returned.
+func (s *Session) Commit() (string, error) {
+	diff, err := DiffDirs(s.Original, s.EditDir)
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", fmt.Errorf("no changes in %s", s.EditDir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.PatchPath()), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(s.PatchPath(), []byte(diff), 0644); err != nil {
+		return "", err
+	}
+	return diff, s.Remove()
+}
+
+// Remove deletes the session's temporary directory
+func (s *Session) Remove() error {
+	return os.RemoveAll(filepath.Dir(s.EditDir))
+}
+
+// Installed is a copy of a package in node_modules
+type Installed struct {
+	Dir     string
+	Version string
+}
+
+// FindInstalled finds every copy of name below the node_modules of dirs,
+// including nested ones. Symlinks are skipped: they point at workspace
+// packages or at a store the package manager owns.
+func FindInstalled(dirs []string, name string) []Installed {
+	var found []Installed
+	seen := map[string]bool{}
+
+	var walk func(dir string)
+	walk = func(dir string) {
+		for _, pkgDir := range packageDirs(filepath.Join(dir, "node_modules")) {
+			if seen[pkgDir] {
+				continue
+			}
+			seen[pkgDir] = true
+
+			pkg, err := context.ReadPackageJSON(filepath.Join(pkgDir, "package.json"))
+			if err == nil && pkg.Name == name {
+				found = append(found, Installed{Dir: pkgDir, Version: pkg.Version})
+			}
+			walk(pkgDir)
+		}
+	}
+	for _, dir := range dirs {
+		walk(dir)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Dir < found[j].Dir })
+	return found
+}
+
+// packageDirs lists the real package directories in a node_modules
+// directory, looking inside scopes
+func packageDirs(nodeModules string) []string {
+	entries, err := os.ReadDir(nodeModules)
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(nodeModules, entry.Name())
+		if strings.HasPrefix(entry.Name(), "@") {
+			dirs = append(dirs, packageDirs(path)...)
+			continue
+		}
+		dirs = append(dirs, path)
+	}
+	return dirs
+}
+
+// Result is the outcome of applying one patch to one installed copy
+type Result struct {
+	File    string // patch file name
+	Name    string
+	Version string
+	Dir     string // installed copy, empty when none has the version
+	Changed bool
+	Err     error
+}
+
+// ApplyAll applies every patch in rootDir's patches directory to the
+// matching copies installed below dirs. Copies that already carry the
+// patch are left unchanged.
+func ApplyAll(rootDir string, dirs []string) ([]Result, error) {
+	entries, err := os.ReadDir(filepath.Join(rootDir, Dir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var results []Result
+	for _, entry := range entries {
+		name, version, ok := ParseFileName(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+		result := Result{File: entry.Name(), Name: name, Version: version}
+
+		data, err := os.ReadFile(filepath.Join(rootDir, Dir, entry.Name()))
+		if err != nil {
+			return results, err
+		}
+		files, err := Parse(string(data))
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		matched := false
+		for _, installed := range FindInstalled(dirs, name) {
+			if installed.Version != version {
+				continue
+			}
+			matched = true
+			r := result
+			r.Dir = installed.Dir
+			r.Changed, r.Err = Apply(installed.Dir, files)
+			results = append(results, r)
+		}
+		if !matched {
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// EnsurePostinstall adds ApplyCommand to the postinstall script so
+// patches are reapplied after every install. It reports whether the
+// manifest changed.
+func EnsurePostinstall(manifest *context.Manifest) (bool, error) {
+	script, ok := manifest.GetString("scripts", "postinstall")
+	if _, exists := manifest.Get("scripts", "postinstall"); exists && !ok {
+		return false, errors.New("scripts.postinstall in package.json is not a string")
+	}
+	if strings.Contains(script, ApplyCommand) {
+		return false, nil
+	}
+
+	if script != "" {
+		script += " && "
+	}
+	return true, manifest.Set([]string{"scripts", "postinstall"}, script+ApplyCommand)
+}
@@ -0,0 +1,219 @@
+package patch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+)
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		file    string
+	}{
+		{"lodash", "4.17.21", "lodash@4.17.21.patch"},
+		{"@babel/core", "7.24.0", "@babel__core@7.24.0.patch"},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.name, tt.version); got != tt.file {
+			t.Errorf("FileName(%s, %s) = %q, want %q", tt.name, tt.version, got, tt.file)
+		}
+		name, version, ok := ParseFileName(tt.file)
+		if !ok || name != tt.name || version != tt.version {
+			t.Errorf("ParseFileName(%q) = %s, %s, %v", tt.file, name, version, ok)
+		}
+	}
+
+	for _, file := range []string{"lodash.patch", "@scope__pkg.patch", "lodash@1.0.0.diff"} {
+		if _, _, ok := ParseFileName(file); ok {
+			t.Errorf("ParseFileName(%q) should fail", file)
+		}
+	}
+}
+
+func TestApplyOffset(t *testing.T) {
+	files, err := Parse(`--- a/index.js
++++ b/index.js
+@@ -2,3 +2,3 @@
+ b
+-c
++C
+ d
+`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Two lines were added above the hunk since the patch was made
+	dir := writeTree(t, map[string]string{"index.js": "x\ny\na\nb\nc\nd\n"})
+	if _, err := Apply(dir, files); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "index.js")); string(data) != "x\ny\na\nb\nC\nd\n" {
+		t.Errorf("index.js = %q", data)
+	}
+
+	conflict := writeTree(t, map[string]string{"index.js": "a\nb\nz\nd\n"})
+	if _, err := Apply(conflict, files); err == nil {
+		t.Error("Apply should fail when the context doesn't match")
+	}
+}
+
+func TestSession(t *testing.T) {
+	rootDir := t.TempDir()
+	tarball := makeTarball(t, map[string]string{
+		"package/package.json": `{"name": "@scope/pkg", "version": "1.0.0"}`,
+		"package/index.js":     "module.exports = 1\n",
+	})
+
+	s, err := Start(rootDir, "@scope/pkg", "1.0.0", tarball)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.EditDir, "index.js"), []byte("module.exports = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := OpenSession(s.EditDir)
+	if err != nil {
+		t.Fatalf("OpenSession failed: %v", err)
+	}
+	first, err := opened.Commit()
+	if err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, "patches", "@scope__pkg@1.0.0.patch")); err != nil {
+		t.Errorf("patch not written: %v", err)
+	}
+	if _, err := os.Stat(s.EditDir); !os.IsNotExist(err) {
+		t.Error("Commit should remove the edit directory")
+	}
+
+	// Editing again starts from the patched version
+	again, err := Start(rootDir, "@scope/pkg", "1.0.0", tarball)
+	if err != nil {
+		t.Fatalf("second Start failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(again.EditDir, "index.js")); string(data) != "module.exports = 2\n" {
+		t.Errorf("edit copy = %q, want the patched file", data)
+	}
+	if second, err := again.Commit(); err != nil || second != first {
+		t.Errorf("recommitting = %q, %v, want the same patch", second, err)
+	}
+
+	// Reverting every change leaves nothing to commit
+	reverted, err := Start(rootDir, "@scope/pkg", "1.0.0", tarball)
+	if err != nil {
+		t.Fatalf("third Start failed: %v", err)
+	}
+	defer reverted.Remove()
+	if err := os.WriteFile(filepath.Join(reverted.EditDir, "index.js"), []byte("module.exports = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reverted.Commit(); err == nil {
+		t.Error("Commit without changes should fail")
+	}
+}
+
+func TestApplyAll(t *testing.T) {
+	rootDir := writeTree(t, map[string]string{
+		"node_modules/lodash/package.json":                `{"name": "lodash", "version": "4.17.21"}`,
+		"node_modules/lodash/index.js":                    "old\n",
+		"node_modules/a/package.json":                     `{"name": "a", "version": "1.0.0"}`,
+		"node_modules/a/node_modules/lodash/package.json": `{"name": "lodash", "version": "3.10.1"}`,
+		"node_modules/a/node_modules/lodash/index.js":     "old\n",
+		"packages/web/node_modules/lodash/package.json":   `{"name": "lodash", "version": "4.17.21"}`,
+		"packages/web/node_modules/lodash/index.js":       "old\n",
+		"patches/lodash@4.17.21.patch":                    "--- a/index.js\n+++ b/index.js\n@@ -1 +1 @@\n-old\n+new\n",
+		"patches/left-pad@1.3.0.patch":                    "--- a/index.js\n+++ b/index.js\n@@ -1 +1 @@\n-old\n+new\n",
+	})
+	dirs := []string{rootDir, filepath.Join(rootDir, "packages", "web")}
+
+	if installed := FindInstalled(dirs, "lodash"); len(installed) != 3 {
+		t.Errorf("FindInstalled = %+v, want 3 copies", installed)
+	}
+
+	results, err := ApplyAll(rootDir, dirs)
+	if err != nil {
+		t.Fatalf("ApplyAll failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v", results)
+	}
+	if r := results[0]; r.Name != "left-pad" || r.Dir != "" {
+		t.Errorf("left-pad result = %+v, want no installed copy", r)
+	}
+	for _, r := range results[1:] {
+		if r.Err != nil || !r.Changed {
+			t.Errorf("lodash result = %+v", r)
+		}
+	}
+
+	for dir, want := range map[string]string{
+		"node_modules/lodash":                "new\n",
+		"packages/web/node_modules/lodash":   "new\n",
+		"node_modules/a/node_modules/lodash": "old\n",
+	} {
+		if data, _ := os.ReadFile(filepath.Join(rootDir, dir, "index.js")); string(data) != want {
+			t.Errorf("%s/index.js = %q, want %q", dir, data, want)
+		}
+	}
+}
+
+func TestEnsurePostinstall(t *testing.T) {
+	tests := []struct {
+		scripts string
+		want    string
+		changed bool
+	}{
+		{`{}`, ApplyCommand, true},
+		{`{"postinstall": "husky"}`, "husky && " + ApplyCommand, true},
+		{`{"postinstall": "` + ApplyCommand + `"}`, ApplyCommand, false},
+	}
+	for _, tt := range tests {
+		dir := writeTree(t, map[string]string{"package.json": `{"scripts": ` + tt.scripts + `}`})
+		manifest, err := context.ReadManifest(filepath.Join(dir, "package.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changed, err := EnsurePostinstall(manifest)
+		if err != nil {
+			t.Fatalf("EnsurePostinstall(%s) failed: %v", tt.scripts, err)
+		}
+		script, _ := manifest.GetString("scripts", "postinstall")
+		if changed != tt.changed || script != tt.want {
+			t.Errorf("EnsurePostinstall(%s) = %v, %q", tt.scripts, changed, script)
+		}
+	}
+}
+
+func makeTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0444, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
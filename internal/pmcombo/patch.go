@@ -0,0 +1,68 @@
+package pmcombo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoNativePatch is returned for package managers without a patch
+// command (npm and yarn classic); gnpm patches their dependencies itself
+var ErrNoNativePatch = errors.New("no native patch command")
+
+// PatchCommand generates the command that prepares a package for patching
+type PatchCommand struct {
+	Options PatchOptions
+}
+
+// NewPatchCommand creates a new patch command
+func NewPatchCommand(opts PatchOptions) *PatchCommand {
+	return &PatchCommand{Options: opts}
+}
+
+// Concat generates the command arguments for the given package manager
+func (c *PatchCommand) Concat(pm PackageManager) ([]string, error) {
+	if c.Options.Package == "" {
+		return nil, fmt.Errorf("no package specified")
+	}
+
+	switch pm {
+	case PNPM, Yarn, Bun:
+		return []string{"patch", c.Options.Package}, nil
+	case Deno:
+		return nil, fmt.Errorf("deno does not support patching dependencies")
+	default:
+		return nil, ErrNoNativePatch
+	}
+}
+
+// PatchCommitCommand generates the command that turns an edited package
+// into a patch and registers it
+type PatchCommitCommand struct {
+	Options PatchCommitOptions
+}
+
+// NewPatchCommitCommand creates a new patch commit command
+func NewPatchCommitCommand(opts PatchCommitOptions) *PatchCommitCommand {
+	return &PatchCommitCommand{Options: opts}
+}
+
+// Concat generates the command arguments for the given package manager
+func (c *PatchCommitCommand) Concat(pm PackageManager) ([]string, error) {
+	if c.Options.Dir == "" {
+		return nil, fmt.Errorf("no directory specified")
+	}
+
+	switch pm {
+	case PNPM:
+		return []string{"patch-commit", c.Options.Dir}, nil
+	case Yarn:
+		// -s stores the patch in the project instead of printing it
+		return []string{"patch-commit", "-s", c.Options.Dir}, nil
+	case Bun:
+		return []string{"patch", "--commit", c.Options.Dir}, nil
+	case Deno:
+		return nil, fmt.Errorf("deno does not support patching dependencies")
+	default:
+		return nil, ErrNoNativePatch
+	}
+}
@@ -0,0 +1,70 @@
+package pmcombo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPatchCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       PackageManager
+		expected []string
+	}{
+		{name: "pnpm patch", pm: PNPM, expected: []string{"patch", "lodash@4.17.21"}},
+		{name: "yarn patch", pm: Yarn, expected: []string{"patch", "lodash@4.17.21"}},
+		{name: "bun patch", pm: Bun, expected: []string{"patch", "lodash@4.17.21"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewPatchCommand(PatchOptions{Package: "lodash@4.17.21"}).Concat(tt.pm)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestPatchCommitCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		pm       PackageManager
+		expected []string
+	}{
+		{name: "pnpm patch-commit", pm: PNPM, expected: []string{"patch-commit", "/tmp/edit"}},
+		{name: "yarn patch-commit", pm: Yarn, expected: []string{"patch-commit", "-s", "/tmp/edit"}},
+		{name: "bun patch --commit", pm: Bun, expected: []string{"patch", "--commit", "/tmp/edit"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewPatchCommitCommand(PatchCommitOptions{Dir: "/tmp/edit"}).Concat(tt.pm)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestPatchCommandNotNative(t *testing.T) {
+	for _, pm := range []PackageManager{NPM, YarnClassic} {
+		if _, err := NewPatchCommand(PatchOptions{Package: "lodash"}).Concat(pm); !errors.Is(err, ErrNoNativePatch) {
+			t.Errorf("%s: expected ErrNoNativePatch, got %v", pm, err)
+		}
+		if _, err := NewPatchCommitCommand(PatchCommitOptions{Dir: "/tmp/edit"}).Concat(pm); !errors.Is(err, ErrNoNativePatch) {
+			t.Errorf("%s: expected ErrNoNativePatch, got %v", pm, err)
+		}
+	}
+
+	if _, err := NewPatchCommand(PatchOptions{Package: "lodash"}).Concat(Deno); err == nil || errors.Is(err, ErrNoNativePatch) {
+		t.Errorf("deno: expected an unsupported error, got %v", err)
+	}
+}
@@ -117,9 +117,8 @@ type DlxOptions struct {
 
 // UpdateOptions for the update command
 type UpdateOptions struct {
-	Packages    []string
-	Interactive bool
-	Latest      bool
+	Packages []string
+	Latest   bool
 }
 
 // ConfigOptions for the config command
@@ -129,6 +128,16 @@ type ConfigOptions struct {
 	Value  string
 }
 
+// PatchOptions for the patch command
+type PatchOptions struct {
+	Package string // name, optionally with @version
+}
+
+// PatchCommitOptions for the patch commit command
+type PatchCommitOptions struct {
+	Dir string // directory the package was edited in
+}
+
 // WhyOptions for the why command
 type WhyOptions struct {
 	Package string
@@ -20,30 +20,15 @@ func (c *UpdateCommand) Concat(pm PackageManager) ([]string, error) {
 		args = append(args, c.Options.Packages...)
 
 	case Yarn:
-		if c.Options.Interactive {
-			args = append(args, "upgrade-interactive")
-		} else {
-			args = append(args, "up")
-		}
+		args = append(args, "up")
 		args = append(args, c.Options.Packages...)
 
 	case YarnClassic:
-		if c.Options.Interactive {
-			args = append(args, "upgrade-interactive")
-			if c.Options.Latest {
-				args = append(args, "--latest")
-			}
-		} else {
-			args = append(args, "upgrade")
-		}
+		args = append(args, "upgrade")
 		args = append(args, c.Options.Packages...)
 
 	case PNPM:
-		if c.Options.Interactive {
-			args = append(args, "update", "-i")
-		} else {
-			args = append(args, "update")
-		}
+		args = append(args, "update")
 		if c.Options.Latest {
 			args = append(args, "--latest")
 		}
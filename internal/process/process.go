@@ -0,0 +1,124 @@
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+)
+
+// Grace is how long a process gets to exit after being asked to stop,
+// before it is killed
+const Grace = 5 * time.Second
+
+// Process is a child process running in a process group of its own, so
+// stopping it also stops everything it started, such as the programs a
+// shell script runs
+type Process struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// Run runs cmd in a new process group and waits for it. SIGINT, SIGTERM
+// and SIGHUP sent to gnpm are forwarded to the group, which is killed when
+// the process is still running Grace after the first one, or once it exits
+// so nothing it started outlives it. When cmd reads the terminal gnpm runs
+// in the foreground of, the group takes over the terminal until it exits,
+// so Ctrl-C and input reach it directly.
+func Run(cmd *exec.Cmd) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwarded...)
+	defer signal.Stop(signals)
+
+	restore := foreground(cmd)
+	p, err := Start(cmd)
+	if err != nil {
+		restore()
+		return err
+	}
+
+	var escalate <-chan time.Time
+	signaled := false
+	for {
+		select {
+		case <-p.Done():
+			restore()
+			if signaled {
+				// Don't leave orphans behind, such as background jobs
+				// that ignored the signal
+				kill(cmd, true)
+			}
+			return p.Wait()
+		case sig := <-signals:
+			if forward(cmd, sig) && escalate == nil {
+				signaled = true
+				escalate = time.After(Grace)
+			}
+		case <-escalate:
+			kill(cmd, false)
+		}
+	}
+}
+
+// ExitCode returns the exit status of the process err reports, as shells
+// report it: 128+n for a process killed by signal n. ok is false when err
+// isn't about a process exiting, such as a command that wasn't found.
+func ExitCode(err error) (code int, ok bool) {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if code, ok := signalCode(exitErr); ok {
+			return code, true
+		}
+		return exitErr.ExitCode(), true
+	}
+	// Such as the built-in shell's exit status
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode(), true
+	}
+	return 0, false
+}
+
+// Start starts cmd in a new process group
+func Start(cmd *exec.Cmd) (*Process, error) {
+	setGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &Process{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Done is closed once the process has exited
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait waits for the process to exit and returns its result like
+// exec.Cmd.Wait
+func (p *Process) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// Stop asks the process group to terminate, kills it when the process is
+// still running after grace, and waits for it. Whatever is left of the
+// group after the process exits is killed too.
+func (p *Process) Stop(grace time.Duration) error {
+	terminate(p.cmd)
+	exited := true
+	select {
+	case <-p.done:
+	case <-time.After(grace):
+		exited = false
+	}
+	kill(p.cmd, exited)
+	return p.Wait()
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStopKillsGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	// The shell ignores SIGTERM and leaves a grandchild running
+	cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 30 & echo $!; wait")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Start(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	p.Stop(200 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Stop took %v", elapsed)
+	}
+	select {
+	case <-p.Done():
+	default:
+		t.Error("Done isn't closed after Stop")
+	}
+
+	// A killed grandchild may linger as a zombie until init reaps it
+	pid := strings.TrimSpace(line)
+	state, _ := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+	if s := strings.TrimSpace(string(state)); s != "" && !strings.HasPrefix(s, "Z") {
+		t.Errorf("grandchild %s is still running (%s)", pid, s)
+	}
+}
+
+func TestWait(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	p, err := Start(exec.Command("sh", "-c", "exit 3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := p.Wait().(*exec.ExitError); !ok || err.ExitCode() != 3 {
+		t.Errorf("Wait = %v, want exit status 3", err)
+	}
+}
+
+type statusError int
+
+func (e statusError) Error() string { return "status" }
+func (e statusError) ExitCode() int { return int(e) }
+
+func TestExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		want   int
+		wantOK bool
+	}{
+		{"exit status", exec.Command("sh", "-c", "exit 3").Run(), 3, true},
+		{"signal", exec.Command("sh", "-c", "kill -TERM $$").Run(), 143, true},
+		{"wrapped", fmt.Errorf("script failed: %w", statusError(2)), 2, true},
+		{"not found", exec.Command("gnpm-missing-command").Run(), 0, false},
+		{"other", errors.New("boom"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, ok := ExitCode(tt.err)
+			if code != tt.want || ok != tt.wantOK {
+				t.Errorf("ExitCode(%v) = %d, %v, want %d, %v", tt.err, code, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+//go:build unix
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// forwarded are the signals Run relays to the process group
+var forwarded = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP}
+
+func setGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// foreground makes cmd's process group the terminal's foreground group when
+// cmd reads the terminal and gnpm is in its foreground. It returns a
+// function giving the terminal back to gnpm once cmd has exited.
+func foreground(cmd *exec.Cmd) func() {
+	noop := func() {}
+	stdin, ok := cmd.Stdin.(*os.File)
+	if !ok {
+		return noop
+	}
+	fd := int(stdin.Fd())
+	pgrp, err := tcgetpgrp(fd)
+	if err != nil || pgrp != syscall.Getpgrp() {
+		return noop
+	}
+
+	setGroup(cmd)
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = fd
+	return func() {
+		// gnpm is now in a background group, which the terminal stops
+		// with SIGTTOU for taking the foreground unless it is ignored
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		tcsetpgrp(fd, pgrp)
+	}
+}
+
+func tcgetpgrp(fd int) (int, error) {
+	var pgrp int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCGPGRP), uintptr(unsafe.Pointer(&pgrp))); errno != 0 {
+		return 0, errno
+	}
+	return int(pgrp), nil
+}
+
+func tcsetpgrp(fd int, pgrp int) error {
+	id := int32(pgrp)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), uintptr(syscall.TIOCSPGRP), uintptr(unsafe.Pointer(&id))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// forward sends sig to the process group and reports that the group should
+// be killed if it doesn't exit
+func forward(cmd *exec.Cmd, sig os.Signal) bool {
+	syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
+	return true
+}
+
+// terminate sends SIGTERM to the process group; the process leads it, so
+// its pid is the group id
+func terminate(cmd *exec.Cmd) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+}
+
+// kill sends SIGKILL to the process group, which also reaches children
+// left behind by a process that has exited
+func kill(cmd *exec.Cmd, exited bool) {
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// signalCode returns 128+n for a process killed by signal n
+func signalCode(err *exec.ExitError) (int, bool) {
+	status, ok := err.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return 128 + int(status.Signal()), true
+}
@@ -0,0 +1,47 @@
+package process
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// forwarded are the signals Run handles. Ctrl-C reaches every process on
+// the console, so Run only keeps gnpm alive to report the child's status.
+var forwarded = []os.Signal{os.Interrupt}
+
+// setGroup leaves the process in gnpm's console; taskkill finds its
+// children through the process tree instead
+func setGroup(cmd *exec.Cmd) {}
+
+// foreground does nothing: the child shares gnpm's console
+func foreground(cmd *exec.Cmd) func() {
+	return func() {}
+}
+
+// forward leaves the signal to the console, which delivered it to the
+// child already. Programs may handle Ctrl-C themselves, so they aren't
+// killed for ignoring it.
+func forward(cmd *exec.Cmd, sig os.Signal) bool {
+	return false
+}
+
+// terminate asks the process tree to close. Console programs usually
+// ignore this, so kill follows after the grace period.
+func terminate(cmd *exec.Cmd) {
+	exec.Command("taskkill", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// kill force-kills the process tree. Once the process has exited its pid
+// may belong to another program, so nothing is killed then.
+func kill(cmd *exec.Cmd, exited bool) {
+	if exited {
+		return
+	}
+	exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// signalCode reports nothing: Windows processes always have an exit code
+func signalCode(err *exec.ExitError) (int, bool) {
+	return 0, false
+}
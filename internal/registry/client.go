@@ -0,0 +1,448 @@
+package registry
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AcceptAbbreviated requests the abbreviated (install) metadata format
+	AcceptAbbreviated = "application/vnd.npm.install-v1+json; q=1.0, application/json; q=0.8, */*"
+	acceptFull        = "application/json"
+
+	defaultTimeout       = 30 * time.Second
+	defaultRetries       = 2
+	defaultRetryMinDelay = 10 * time.Second
+	defaultRetryMaxDelay = 60 * time.Second
+	retryFactor          = 10
+)
+
+// Options configures a Client
+type Options struct {
+	Registry   string        // default registry, usually from native.GetRegistry
+	Config     Config        // merged .npmrc settings (scoped registries, auth, proxies, retries)
+	CacheDir   string        // directory for the ETag cache; empty disables caching
+	Offline    bool          // only serve from the cache
+	HTTPClient *http.Client  // overrides the transport built from Config
+	Timeout    time.Duration // default for fetch-timeout, for large downloads
+	UserAgent  string
+}
+
+// Client fetches package metadata from npm-compatible registries
+type Client struct {
+	registry      string
+	config        Config
+	cacheDir      string
+	offline       bool
+	http          *http.Client
+	userAgent     string
+	retries       int
+	retryMinDelay time.Duration
+	retryMaxDelay time.Duration
+}
+
+// HTTPError is returned for unsuccessful registry responses
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Message    string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("GET %s: %d %s", e.URL, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("GET %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// ErrNotCached is returned in offline mode when a document isn't cached
+var ErrNotCached = errors.New("not available in the offline cache")
+
+// IsNotFound reports whether err is a 404 from the registry
+func IsNotFound(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// New creates a Client. Settings from Config: strict-ssl, proxy, https-proxy,
+// noproxy, fetch-retries, fetch-retry-mintimeout, fetch-retry-maxtimeout,
+// fetch-timeout and offline.
+func New(opts Options) *Client {
+	config := opts.Config
+	if config == nil {
+		config = Config{}
+	}
+
+	registry := opts.Registry
+	if registry == "" {
+		registry, _ = config.Get("registry")
+	}
+	if registry == "" {
+		registry = DefaultRegistry
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = config.proxyFunc()
+		if !config.Bool("strict-ssl", true) {
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		timeout := opts.Timeout
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		httpClient = &http.Client{
+			Transport: transport,
+			Timeout:   config.Duration("fetch-timeout", timeout),
+		}
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = "gnpm"
+	}
+
+	return &Client{
+		registry:      withTrailingSlash(registry),
+		config:        config,
+		cacheDir:      opts.CacheDir,
+		offline:       opts.Offline || config.Bool("offline", false),
+		http:          httpClient,
+		userAgent:     userAgent,
+		retries:       config.Int("fetch-retries", defaultRetries),
+		retryMinDelay: config.Duration("fetch-retry-mintimeout", defaultRetryMinDelay),
+		retryMaxDelay: config.Duration("fetch-retry-maxtimeout", defaultRetryMaxDelay),
+	}
+}
+
+// Registry returns the default registry URL
+func (c *Client) Registry() string {
+	return c.registry
+}
+
+// RegistryFor returns the registry a package is fetched from
+func (c *Client) RegistryFor(name string) string {
+	return c.config.RegistryFor(name, c.registry)
+}
+
+// PackumentURL returns the metadata URL of a package
+func (c *Client) PackumentURL(name string) string {
+	return c.RegistryFor(name) + escapeName(name)
+}
+
+// escapeName encodes scoped names the way the registry expects: @scope%2fname
+func escapeName(name string) string {
+	return strings.Replace(name, "/", "%2f", 1)
+}
+
+// Packument fetches the full metadata of a package, including publish times,
+// maintainers and readme-level fields
+func (c *Client) Packument(name string) (*Packument, error) {
+	return c.packument(name, acceptFull)
+}
+
+// AbbreviatedPackument fetches the smaller install metadata of a package:
+// versions with their dependencies and dist, and dist-tags
+func (c *Client) AbbreviatedPackument(name string) (*Packument, error) {
+	return c.packument(name, AcceptAbbreviated)
+}
+
+func (c *Client) packument(name string, accept string) (*Packument, error) {
+	data, err := c.get(c.PackumentURL(name), accept)
+	if err != nil {
+		return nil, err
+	}
+
+	var packument Packument
+	if err := json.Unmarshal(data, &packument); err != nil {
+		return nil, fmt.Errorf("parse metadata of %s: %w", name, err)
+	}
+	if packument.Name == "" {
+		packument.Name = name
+	}
+	return &packument, nil
+}
+
+// Manifest fetches the manifest a spec (dist-tag, version or range) refers to
+func (c *Client) Manifest(name string, spec string) (*Manifest, error) {
+	packument, err := c.AbbreviatedPackument(name)
+	if err != nil {
+		return nil, err
+	}
+	return packument.Resolve(spec)
+}
+
+// cacheEntry is a cached registry response
+type cacheEntry struct {
+	URL  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Data json.RawMessage `json:"data"`
+}
+
+// get fetches a JSON document, revalidating cached copies with If-None-Match
+func (c *Client) get(rawURL string, accept string) ([]byte, error) {
+	cached, hasCache := c.readCache(rawURL, accept)
+	if c.offline {
+		if hasCache {
+			return cached.Data, nil
+		}
+		return nil, fmt.Errorf("GET %s: %w", rawURL, ErrNotCached)
+	}
+
+	data, etag, err := c.fetch(rawURL, accept, cached.ETag)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		// 304 Not Modified
+		return cached.Data, nil
+	}
+	if etag != "" {
+		c.writeCache(rawURL, accept, cacheEntry{URL: rawURL, ETag: etag, Data: data})
+	}
+	return data, nil
+}
+
+// Tarball downloads a version's tarball and checks it against the integrity
+// (or legacy shasum) the registry published. Tarballs are immutable and
+// large, so they bypass the ETag cache.
+func (c *Client) Tarball(dist Dist) ([]byte, error) {
+	if dist.Tarball == "" {
+		return nil, fmt.Errorf("no tarball URL published")
+	}
+	if c.offline {
+		return nil, fmt.Errorf("GET %s: %w", dist.Tarball, ErrNotCached)
+	}
+
+	data, _, err := c.fetch(dist.Tarball, "application/octet-stream", "")
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyIntegrity(data, dist); err != nil {
+		return nil, fmt.Errorf("%s: %w", dist.Tarball, err)
+	}
+	return data, nil
+}
+
+// fetch performs a GET, retrying network errors, 429s and 5xx responses
+// with backoff
+func (c *Client) fetch(rawURL string, accept string, etag string) ([]byte, string, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.backoff(attempt, lastErr))
+		}
+
+		data, newETag, err := c.do(rawURL, accept, etag)
+		if err == nil {
+			return data, newETag, nil
+		}
+
+		lastErr = err
+		if !retryable(err) {
+			break
+		}
+	}
+	return nil, "", lastErr
+}
+
+// VerifyIntegrity checks data against the strongest hash dist publishes
+func VerifyIntegrity(data []byte, dist Dist) error {
+	// Subresource integrity: "sha512-<base64>", possibly several
+	for _, sri := range strings.Fields(dist.Integrity) {
+		algo, digest, ok := strings.Cut(sri, "-")
+		if !ok {
+			continue
+		}
+		var sum []byte
+		switch algo {
+		case "sha512":
+			h := sha512.Sum512(data)
+			sum = h[:]
+		case "sha256":
+			h := sha256.Sum256(data)
+			sum = h[:]
+		case "sha1":
+			h := sha1.Sum(data)
+			sum = h[:]
+		default:
+			continue
+		}
+		if base64.StdEncoding.EncodeToString(sum) != digest {
+			return fmt.Errorf("integrity check failed (%s)", algo)
+		}
+		return nil
+	}
+
+	if dist.Shasum != "" {
+		h := sha1.Sum(data)
+		if hex.EncodeToString(h[:]) != strings.ToLower(dist.Shasum) {
+			return fmt.Errorf("integrity check failed (shasum)")
+		}
+	}
+	return nil
+}
+
+// retryAfterError carries a Retry-After hint from a 429 or 5xx
+type retryAfterError struct {
+	*HTTPError
+	after time.Duration
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.HTTPError
+}
+
+func (c *Client) do(rawURL string, accept string, etag string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", accept)
+	req.Header.Set("User-Agent", c.userAgent)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if auth := c.config.authFor(rawURL, c.registry); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return nil, etag, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		httpErr := &HTTPError{URL: rawURL, StatusCode: resp.StatusCode, Message: errorMessage(body)}
+		if after := parseRetryAfter(resp.Header.Get("Retry-After")); after > 0 && retryable(httpErr) {
+			return nil, "", &retryAfterError{HTTPError: httpErr, after: after}
+		}
+		return nil, "", httpErr
+	}
+
+	return body, resp.Header.Get("ETag"), nil
+}
+
+// errorMessage extracts the registry's {"error": "..."} message, if any
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		return payload.Error
+	}
+	return ""
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+func retryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= 500
+	}
+	// Network errors
+	return true
+}
+
+// backoff grows exponentially from fetch-retry-mintimeout by a factor of 10,
+// capped at fetch-retry-maxtimeout, and honors Retry-After
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	var retryAfter *retryAfterError
+	if errors.As(err, &retryAfter) && retryAfter.after > 0 {
+		return min(retryAfter.after, c.retryMaxDelay)
+	}
+
+	delay := c.retryMinDelay
+	for i := 1; i < attempt; i++ {
+		delay *= retryFactor
+		if delay >= c.retryMaxDelay {
+			return c.retryMaxDelay
+		}
+	}
+	return min(delay, c.retryMaxDelay)
+}
+
+func (c *Client) cachePath(rawURL string, accept string) string {
+	sum := sha256.Sum256([]byte(accept + "\n" + rawURL))
+	return filepath.Join(c.cacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Client) readCache(rawURL string, accept string) (cacheEntry, bool) {
+	if c.cacheDir == "" {
+		return cacheEntry{}, false
+	}
+	data, err := os.ReadFile(c.cachePath(rawURL, accept))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != rawURL {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// writeCache stores a response atomically; cache failures are not fatal
+func (c *Client) writeCache(rawURL string, accept string, entry cacheEntry) {
+	if c.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.cacheDir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.cachePath(rawURL, accept)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,381 @@
+package registry
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const reactPackument = `{
+  "name": "react",
+  "description": "React is a JavaScript library for building user interfaces.",
+  "dist-tags": {"latest": "18.3.1", "next": "19.0.0-rc.1"},
+  "versions": {
+    "17.0.2": {"name": "react", "version": "17.0.2", "dist": {"tarball": "https://registry.example/react/-/react-17.0.2.tgz"}},
+    "18.2.0": {"name": "react", "version": "18.2.0", "deprecated": false},
+    "18.3.1": {
+      "name": "react",
+      "version": "18.3.1",
+      "license": {"type": "MIT"},
+      "dependencies": {"loose-envify": "^1.1.0"},
+      "engines": {"node": ">=0.10.0"},
+      "repository": "github:facebook/react",
+      "maintainers": ["Dan <dan@example.com> (https://example.com)"],
+      "dist": {"integrity": "sha512-abc", "unpackedSize": 318000}
+    },
+    "19.0.0-rc.1": {"name": "react", "version": "19.0.0-rc.1", "engines": ["node >= 0.10"]}
+  },
+  "time": {"18.3.1": "2024-04-26T16:42:15.698Z"},
+  "maintainers": {"name": "react-bot", "email": "bot@example.com"},
+  "repository": {"type": "git", "url": "git+https://github.com/facebook/react.git", "directory": "packages/react"}
+}`
+
+// stubRegistry is an httptest registry serving fixed documents by path
+type stubRegistry struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+	docs     map[string]string
+	etag     string
+	failures int // respond 503 to this many requests first
+}
+
+func newStubRegistry(t *testing.T, docs map[string]string) *stubRegistry {
+	t.Helper()
+
+	stub := &stubRegistry{docs: docs}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.mu.Lock()
+		defer stub.mu.Unlock()
+		stub.requests = append(stub.requests, r)
+
+		if stub.failures > 0 {
+			stub.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		doc, ok := stub.docs[r.URL.EscapedPath()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Not found"}`))
+			return
+		}
+
+		if stub.etag != "" {
+			if r.Header.Get("If-None-Match") == stub.etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", stub.etag)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(doc))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *stubRegistry) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *stubRegistry) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.requests)
+}
+
+// fastRetries keeps retry backoff short in tests
+func fastRetries(config Config) Config {
+	config["fetch-retry-mintimeout"] = "1"
+	config["fetch-retry-maxtimeout"] = "5"
+	return config
+}
+
+func TestPackument(t *testing.T) {
+	stub := newStubRegistry(t, map[string]string{"/react": reactPackument})
+	client := New(Options{Registry: stub.URL, Config: fastRetries(Config{})})
+
+	packument, err := client.Packument("react")
+	if err != nil {
+		t.Fatalf("Packument failed: %v", err)
+	}
+
+	if got := stub.lastRequest().Header.Get("Accept"); got != "application/json" {
+		t.Errorf("expected full metadata Accept header, got %q", got)
+	}
+	if packument.DistTags["latest"] != "18.3.1" {
+		t.Errorf("unexpected dist-tags %v", packument.DistTags)
+	}
+	if packument.Repository.URL != "git+https://github.com/facebook/react.git" || packument.Repository.Directory != "packages/react" {
+		t.Errorf("unexpected repository %+v", packument.Repository)
+	}
+	if len(packument.Maintainers) != 1 || packument.Maintainers[0].Name != "react-bot" {
+		t.Errorf("unexpected maintainers %+v", packument.Maintainers)
+	}
+	if packument.Time["18.3.1"].Year() != 2024 {
+		t.Errorf("unexpected publish time %v", packument.Time["18.3.1"])
+	}
+
+	latest := packument.Versions["18.3.1"]
+	if latest.License != "MIT" || latest.Repository.URL != "github:facebook/react" || latest.Dist.UnpackedSize != 318000 {
+		t.Errorf("unexpected manifest %+v", latest)
+	}
+	if latest.Maintainers[0] != (Person{Name: "Dan", Email: "dan@example.com", URL: "https://example.com"}) {
+		t.Errorf("unexpected maintainer %+v", latest.Maintainers[0])
+	}
+	if packument.Versions["18.2.0"].Deprecated != "" {
+		t.Errorf("expected deprecated: false to be empty")
+	}
+
+	expectedVersions := "17.0.2 18.2.0 18.3.1 19.0.0-rc.1"
+	if got := strings.Join(packument.VersionList(), " "); got != expectedVersions {
+		t.Errorf("VersionList = %q, want %q", got, expectedVersions)
+	}
+}
+
+func TestManifestResolvesSpecs(t *testing.T) {
+	stub := newStubRegistry(t, map[string]string{"/react": reactPackument})
+	client := New(Options{Registry: stub.URL, Config: fastRetries(Config{})})
+
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"", "18.3.1"},
+		{"latest", "18.3.1"},
+		{"next", "19.0.0-rc.1"},
+		{"17.0.2", "17.0.2"},
+		{"^18.0.0", "18.3.1"},
+		{"<18.3", "18.2.0"},
+		{"*", "18.3.1"},
+	}
+
+	for _, tt := range tests {
+		manifest, err := client.Manifest("react", tt.spec)
+		if err != nil {
+			t.Errorf("Manifest(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if manifest.Version != tt.expected {
+			t.Errorf("Manifest(%q) = %s, want %s", tt.spec, manifest.Version, tt.expected)
+		}
+	}
+
+	if got := stub.lastRequest().Header.Get("Accept"); got != AcceptAbbreviated {
+		t.Errorf("expected abbreviated Accept header, got %q", got)
+	}
+	if _, err := client.Manifest("react", "^20"); err == nil {
+		t.Error("expected an error for a range without matches")
+	}
+}
+
+func TestScopedRegistryAndAuth(t *testing.T) {
+	public := newStubRegistry(t, map[string]string{"/react": reactPackument})
+	private := newStubRegistry(t, map[string]string{"/npm/@acme%2fui": `{"name": "@acme/ui", "versions": {}}`})
+
+	t.Setenv("ACME_TOKEN", "secret-token")
+	config := fastRetries(Config{
+		"@acme:registry": private.URL + "/npm/",
+		strings.TrimPrefix(private.URL, "http:") + "/npm/:_authToken": "${ACME_TOKEN}",
+	})
+	client := New(Options{Registry: public.URL, Config: config})
+
+	if _, err := client.Packument("@acme/ui"); err != nil {
+		t.Fatalf("scoped Packument failed: %v", err)
+	}
+	if got := private.lastRequest().Header.Get("Authorization"); got != "Bearer secret-token" {
+		t.Errorf("expected the scoped registry token, got %q", got)
+	}
+
+	if _, err := client.Packument("react"); err != nil {
+		t.Fatalf("Packument failed: %v", err)
+	}
+	if got := public.lastRequest().Header.Get("Authorization"); got != "" {
+		t.Errorf("token leaked to another registry: %q", got)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	stub := newStubRegistry(t, map[string]string{"/react": reactPackument})
+	nerf := strings.TrimPrefix(stub.URL, "http:") + "/"
+	config := fastRetries(Config{
+		nerf + ":username":  "alice",
+		nerf + ":_password": base64.StdEncoding.EncodeToString([]byte("hunter2")),
+	})
+
+	if _, err := New(Options{Registry: stub.URL, Config: config}).Packument("react"); err != nil {
+		t.Fatalf("Packument failed: %v", err)
+	}
+
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:hunter2"))
+	if got := stub.lastRequest().Header.Get("Authorization"); got != expected {
+		t.Errorf("Authorization = %q, want %q", got, expected)
+	}
+}
+
+func TestRetriesServerErrors(t *testing.T) {
+	stub := newStubRegistry(t, map[string]string{"/react": reactPackument})
+	stub.failures = 2
+
+	client := New(Options{Registry: stub.URL, Config: fastRetries(Config{"fetch-retries": "2"})})
+	if _, err := client.Packument("react"); err != nil {
+		t.Fatalf("expected success after retries: %v", err)
+	}
+	if stub.requestCount() != 3 {
+		t.Errorf("expected 3 requests, got %d", stub.requestCount())
+	}
+
+	stub.failures = 5
+	if _, err := client.Packument("react"); err == nil {
+		t.Fatal("expected an error once retries are exhausted")
+	}
+}
+
+func TestNotFoundIsNotRetried(t *testing.T) {
+	stub := newStubRegistry(t, map[string]string{})
+	client := New(Options{Registry: stub.URL, Config: fastRetries(Config{})})
+
+	_, err := client.Packument("missing")
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "Not found") {
+		t.Errorf("expected the registry's error message, got %q", err)
+	}
+	if stub.requestCount() != 1 {
+		t.Errorf("expected a single request, got %d", stub.requestCount())
+	}
+}
+
+func TestTarballIntegrity(t *testing.T) {
+	const tarball = "not really a tarball"
+	stub := newStubRegistry(t, map[string]string{"/react/-/react-18.3.1.tgz": tarball})
+	client := New(Options{Registry: stub.URL, Config: fastRetries(Config{})})
+
+	sha512sum := sha512.Sum512([]byte(tarball))
+	sha1sum := sha1.Sum([]byte(tarball))
+	url := stub.URL + "/react/-/react-18.3.1.tgz"
+
+	for _, dist := range []Dist{
+		{Tarball: url, Integrity: "sha512-" + base64.StdEncoding.EncodeToString(sha512sum[:])},
+		{Tarball: url, Shasum: hex.EncodeToString(sha1sum[:])},
+		{Tarball: url},
+	} {
+		data, err := client.Tarball(dist)
+		if err != nil {
+			t.Errorf("Tarball(%+v) failed: %v", dist, err)
+			continue
+		}
+		if string(data) != tarball {
+			t.Errorf("Tarball = %q", data)
+		}
+	}
+
+	if _, err := client.Tarball(Dist{Tarball: url, Integrity: "sha512-AAAA"}); err == nil || !strings.Contains(err.Error(), "integrity") {
+		t.Errorf("expected an integrity error, got %v", err)
+	}
+}
+
+func TestETagCache(t *testing.T) {
+	stub := newStubRegistry(t, map[string]string{"/react": reactPackument})
+	stub.etag = `"v1"`
+	cacheDir := t.TempDir()
+
+	client := New(Options{Registry: stub.URL, Config: fastRetries(Config{}), CacheDir: cacheDir})
+	if _, err := client.Packument("react"); err != nil {
+		t.Fatalf("Packument failed: %v", err)
+	}
+
+	// The registry now answers 304s; the cached document must be served
+	stub.docs["/react"] = `{"broken": `
+	packument, err := client.Packument("react")
+	if err != nil {
+		t.Fatalf("revalidated Packument failed: %v", err)
+	}
+	if got := stub.lastRequest().Header.Get("If-None-Match"); got != `"v1"` {
+		t.Errorf("expected If-None-Match, got %q", got)
+	}
+	if packument.DistTags["latest"] != "18.3.1" {
+		t.Errorf("expected the cached packument, got %+v", packument.DistTags)
+	}
+
+	// Offline clients only use the cache
+	requests := stub.requestCount()
+	offline := New(Options{Registry: stub.URL, CacheDir: cacheDir, Offline: true})
+	if _, err := offline.Packument("react"); err != nil {
+		t.Fatalf("offline Packument failed: %v", err)
+	}
+	if stub.requestCount() != requests {
+		t.Error("offline client made a request")
+	}
+	if _, err := offline.AbbreviatedPackument("react"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached for an uncached document, got %v", err)
+	}
+}
+
+func TestProxyConfig(t *testing.T) {
+	config := Config{
+		"https-proxy": "http://secure-proxy:8080",
+		"proxy":       "http://proxy:3128",
+		"noproxy":     "internal.example, .corp",
+	}
+	proxy := config.proxyFunc()
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://registry.npmjs.org/react", "http://secure-proxy:8080"},
+		{"http://registry.npmjs.org/react", "http://proxy:3128"},
+		{"https://internal.example/react", ""},
+		{"https://npm.corp/react", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		got, err := proxy(req)
+		if err != nil {
+			t.Fatalf("proxy(%s) failed: %v", tt.url, err)
+		}
+		gotURL := ""
+		if got != nil {
+			gotURL = got.String()
+		}
+		if gotURL != tt.expected {
+			t.Errorf("proxy(%s) = %q, want %q", tt.url, gotURL, tt.expected)
+		}
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("NPM_TOKEN", "abc")
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"${NPM_TOKEN}", "abc"},
+		{"prefix-${NPM_TOKEN}-suffix", "prefix-abc-suffix"},
+		{`\${NPM_TOKEN}`, "${NPM_TOKEN}"},
+		{"${UNSET_GNPM_VARIABLE}", ""},
+		{"plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		if got := expandEnv(tt.value); got != tt.expected {
+			t.Errorf("expandEnv(%q) = %q, want %q", tt.value, got, tt.expected)
+		}
+	}
+}
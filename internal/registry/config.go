@@ -0,0 +1,197 @@
+package registry
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultRegistry = "https://registry.npmjs.org/"
+
+// Config is the registry-related subset of the merged .npmrc settings
+type Config map[string]string
+
+var envPattern = regexp.MustCompile(`(\\*)\$\{([^${}?]+)(\?)?\}`)
+
+// Get returns a setting with ${ENV} references expanded, like npm does.
+// Escaped references (\${VAR}) are kept literally.
+func (c Config) Get(key string) (string, bool) {
+	value, ok := c[key]
+	if !ok {
+		return "", false
+	}
+	return expandEnv(value), true
+}
+
+func expandEnv(value string) string {
+	return envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		parts := envPattern.FindStringSubmatch(match)
+		escapes := parts[1]
+		if len(escapes)%2 == 1 {
+			return escapes[:len(escapes)-1] + match[len(escapes):]
+		}
+		return escapes + os.Getenv(parts[2])
+	})
+}
+
+// Bool returns a boolean setting, falling back to def when unset or invalid
+func (c Config) Bool(key string, def bool) bool {
+	value, ok := c.Get(key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return def
+	}
+	return parsed
+}
+
+// Int returns an integer setting, falling back to def when unset or invalid
+func (c Config) Int(key string, def int) int {
+	value, ok := c.Get(key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return def
+	}
+	return parsed
+}
+
+// Duration returns a millisecond setting such as fetch-retry-mintimeout
+func (c Config) Duration(key string, def time.Duration) time.Duration {
+	ms := c.Int(key, -1)
+	if ms < 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// RegistryFor returns the registry a package is fetched from: the scope's
+// registry (@scope:registry=...) when configured, the default otherwise
+func (c Config) RegistryFor(name string, defaultRegistry string) string {
+	if strings.HasPrefix(name, "@") {
+		scope, _, _ := strings.Cut(name, "/")
+		if registry, ok := c.Get(scope + ":registry"); ok && registry != "" {
+			return withTrailingSlash(registry)
+		}
+	}
+	if defaultRegistry == "" {
+		defaultRegistry = DefaultRegistry
+	}
+	return withTrailingSlash(defaultRegistry)
+}
+
+func withTrailingSlash(registry string) string {
+	if strings.HasSuffix(registry, "/") {
+		return registry
+	}
+	return registry + "/"
+}
+
+// nerfDart strips the scheme from a URL, producing the "//host/path/" prefix
+// .npmrc scopes credentials with
+func nerfDart(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	path := u.Path
+	if !strings.HasSuffix(path, "/") {
+		path = path[:strings.LastIndex(path, "/")+1]
+	}
+	return "//" + u.Host + path
+}
+
+// authFor returns the Authorization header for a request URL. Credentials
+// are matched against the longest "//host/path/:key" prefix of the URL, so a
+// token for one registry is never sent to another. Unscoped _authToken and
+// _auth only apply to the default registry.
+func (c Config) authFor(requestURL string, defaultRegistry string) string {
+	prefix := nerfDart(requestURL)
+	for prefix != "" && prefix != "//" {
+		if header := c.authForPrefix(prefix); header != "" {
+			return header
+		}
+		trimmed := strings.TrimSuffix(prefix, "/")
+		idx := strings.LastIndex(trimmed, "/")
+		if idx < 2 {
+			break
+		}
+		prefix = trimmed[:idx+1]
+	}
+
+	if strings.HasPrefix(nerfDart(requestURL), nerfDart(defaultRegistry)) {
+		if token, ok := c.Get("_authToken"); ok && token != "" {
+			return "Bearer " + token
+		}
+		if auth, ok := c.Get("_auth"); ok && auth != "" {
+			return "Basic " + auth
+		}
+	}
+	return ""
+}
+
+func (c Config) authForPrefix(prefix string) string {
+	if token, ok := c.Get(prefix + ":_authToken"); ok && token != "" {
+		return "Bearer " + token
+	}
+	if auth, ok := c.Get(prefix + ":_auth"); ok && auth != "" {
+		return "Basic " + auth
+	}
+
+	username, hasUser := c.Get(prefix + ":username")
+	encoded, hasPassword := c.Get(prefix + ":_password")
+	if hasUser && hasPassword {
+		password, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return ""
+		}
+		credentials := username + ":" + string(password)
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	return ""
+}
+
+// proxyFunc picks the proxy for a request from https-proxy/proxy/noproxy,
+// falling back to the HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment
+func (c Config) proxyFunc() func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if c.noProxy(req.URL.Hostname()) {
+			return nil, nil
+		}
+
+		var proxy string
+		if req.URL.Scheme == "https" {
+			proxy, _ = c.Get("https-proxy")
+		}
+		if proxy == "" {
+			proxy, _ = c.Get("proxy")
+		}
+		if proxy == "" {
+			return http.ProxyFromEnvironment(req)
+		}
+		return url.Parse(proxy)
+	}
+}
+
+func (c Config) noProxy(host string) bool {
+	value, _ := c.Get("noproxy")
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimPrefix(strings.TrimSpace(entry), ".")
+		if entry == "" {
+			continue
+		}
+		if entry == "*" || host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
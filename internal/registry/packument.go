@@ -0,0 +1,224 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// Packument is the registry document describing every version of a package.
+// Abbreviated packuments only carry Name, DistTags, Versions and Modified.
+type Packument struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	DistTags    map[string]string    `json:"dist-tags"`
+	Versions    map[string]*Manifest `json:"versions"`
+	Time        map[string]time.Time `json:"time"`
+	Modified    string               `json:"modified"`
+	Maintainers People               `json:"maintainers"`
+	Repository  Repository           `json:"repository"`
+	Homepage    string               `json:"homepage"`
+	License     LooseString          `json:"license"`
+}
+
+// Manifest is the metadata of a single published version
+type Manifest struct {
+	Name                 string            `json:"name"`
+	Version              string            `json:"version"`
+	Description          string            `json:"description"`
+	License              LooseString       `json:"license"`
+	Homepage             string            `json:"homepage"`
+	Deprecated           LooseString       `json:"deprecated"`
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	Engines              context.Engines   `json:"engines"`
+	OS                   []string          `json:"os"`
+	CPU                  []string          `json:"cpu"`
+	Dist                 Dist              `json:"dist"`
+	Repository           Repository        `json:"repository"`
+	Maintainers          People            `json:"maintainers"`
+}
+
+// Dist describes a version's tarball
+type Dist struct {
+	Tarball      string `json:"tarball"`
+	Shasum       string `json:"shasum"`
+	Integrity    string `json:"integrity"`
+	FileCount    int    `json:"fileCount"`
+	UnpackedSize int64  `json:"unpackedSize"`
+}
+
+// LooseString is a string field that some old packages publish as another
+// JSON type: "deprecated": false, or "license": {"type": "MIT"}
+type LooseString string
+
+// UnmarshalJSON keeps strings and the "type" of license objects; anything else is empty
+func (s *LooseString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = LooseString(str)
+		return nil
+	}
+
+	var obj struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &obj); err == nil {
+		*s = LooseString(obj.Type)
+		return nil
+	}
+
+	*s = ""
+	return nil
+}
+
+// Repository is the repository field, published as a string or an object
+type Repository struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Directory string `json:"directory"`
+}
+
+// UnmarshalJSON accepts both "github:user/repo" strings and objects
+func (r *Repository) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*r = Repository{URL: str}
+		return nil
+	}
+
+	type plain Repository
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err == nil {
+		*r = Repository(obj)
+	}
+	return nil
+}
+
+// Person is a maintainer or author
+type Person struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	URL   string `json:"url"`
+}
+
+// String formats the person the way package.json's shorthand does
+func (p Person) String() string {
+	s := p.Name
+	if p.Email != "" {
+		s += " <" + p.Email + ">"
+	}
+	if p.URL != "" {
+		s += " (" + p.URL + ")"
+	}
+	return strings.TrimSpace(s)
+}
+
+var personPattern = regexp.MustCompile(`^([^<(]*?)\s*(?:<([^>]*)>)?\s*(?:\(([^)]*)\))?$`)
+
+// UnmarshalJSON accepts objects and "Name <email> (url)" strings
+func (p *Person) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		match := personPattern.FindStringSubmatch(strings.TrimSpace(str))
+		if match == nil {
+			*p = Person{Name: str}
+			return nil
+		}
+		*p = Person{Name: match[1], Email: match[2], URL: match[3]}
+		return nil
+	}
+
+	type plain Person
+	var obj plain
+	if err := json.Unmarshal(data, &obj); err == nil {
+		*p = Person(obj)
+	}
+	return nil
+}
+
+// People is a list of persons that tolerates a single object or string
+type People []Person
+
+// UnmarshalJSON accepts an array or a single person
+func (p *People) UnmarshalJSON(data []byte) error {
+	var list []Person
+	if err := json.Unmarshal(data, &list); err == nil {
+		*p = list
+		return nil
+	}
+
+	var single Person
+	if err := json.Unmarshal(data, &single); err == nil && single.Name != "" {
+		*p = People{single}
+	}
+	return nil
+}
+
+// VersionList returns the published versions in ascending semver order
+func (p *Packument) VersionList() []string {
+	var parsed []semver.Version
+	for version := range p.Versions {
+		if v, err := semver.Parse(version); err == nil {
+			parsed = append(parsed, v)
+		}
+	}
+	semver.Sort(parsed)
+
+	versions := make([]string, 0, len(parsed))
+	for _, v := range parsed {
+		versions = append(versions, v.String())
+	}
+	return versions
+}
+
+// Resolve picks the manifest a spec refers to: a dist-tag, an exact version,
+// or the highest version satisfying a range. An empty spec means "latest".
+// Like npm, the latest tag wins when it satisfies the range.
+func (p *Packument) Resolve(spec string) (*Manifest, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = "latest"
+	}
+
+	if version, ok := p.DistTags[spec]; ok {
+		if manifest, ok := p.Versions[version]; ok {
+			return manifest, nil
+		}
+		return nil, fmt.Errorf("%s: dist-tag %s points to missing version %s", p.Name, spec, version)
+	}
+
+	if manifest, ok := p.Versions[spec]; ok {
+		return manifest, nil
+	}
+
+	rng, err := semver.ParseRange(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is neither a dist-tag nor a valid range", p.Name, spec)
+	}
+
+	if latest, ok := p.DistTags["latest"]; ok {
+		if v, err := semver.Parse(latest); err == nil && rng.Contains(v) {
+			if manifest, ok := p.Versions[latest]; ok {
+				return manifest, nil
+			}
+		}
+	}
+
+	versions := make([]string, 0, len(p.Versions))
+	for version := range p.Versions {
+		versions = append(versions, version)
+	}
+	version, ok := semver.MaxSatisfying(versions, spec)
+	if !ok {
+		return nil, fmt.Errorf("%s: no version matches %s", p.Name, spec)
+	}
+	return p.Versions[version], nil
+}
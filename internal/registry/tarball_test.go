@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeTarball(t *testing.T, entries []*tar.Header, contents []string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for i, header := range entries {
+		header.Size = int64(len(contents[i]))
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	tarball := makeTarball(t, []*tar.Header{
+		{Name: "package/package.json", Mode: 0444, Typeflag: tar.TypeReg},
+		{Name: "package/bin/cli.js", Mode: 0755, Typeflag: tar.TypeReg},
+		{Name: "package/./lib/../index.js", Mode: 0644, Typeflag: tar.TypeReg},
+	}, []string{`{"version": "1.0.0"}`, "#!/usr/bin/env node\n", "module.exports = 1\n"})
+
+	dir := t.TempDir()
+	if err := Extract(tarball, dir); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+
+	// Read-only files stay editable and executables keep their bits
+	info, err := os.Stat(filepath.Join(dir, "package.json"))
+	if err != nil || info.Mode().Perm()&0200 == 0 {
+		t.Errorf("package.json = %v, %v; want a writable file", info, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "bin", "cli.js")); err != nil || (info.Mode().Perm()&0100 == 0 && os.PathSeparator == '/') {
+		t.Errorf("bin/cli.js = %v, %v; want an executable", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.js")); err != nil {
+		t.Errorf("index.js: %v", err)
+	}
+}
+
+func TestExtractStaysInDir(t *testing.T) {
+	tarball := makeTarball(t, []*tar.Header{
+		{Name: "package/../../escape.js", Mode: 0644, Typeflag: tar.TypeReg},
+	}, []string{"bad"})
+
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "pkg")
+	if err := Extract(tarball, dir); err != nil {
+		t.Fatalf("Extract failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escape.js")); !os.IsNotExist(err) {
+		t.Error("an entry was written outside the target directory")
+	}
+}
@@ -0,0 +1,287 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// SplitSpec splits "name@spec" into its name and spec; scoped names keep
+// their leading "@". The spec is empty when none is given.
+func SplitSpec(arg string) (string, string) {
+	idx := strings.LastIndex(arg, "@")
+	if idx <= 0 {
+		return arg, ""
+	}
+	return arg[:idx], arg[idx+1:]
+}
+
+// Matches returns the manifests a spec selects, in ascending order: the
+// version a dist-tag or exact version points to, or every version satisfying
+// a range. An empty spec means "latest".
+func (p *Packument) Matches(spec string) ([]*Manifest, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = "latest"
+	}
+
+	if _, isTag := p.DistTags[spec]; isTag {
+		manifest, err := p.Resolve(spec)
+		if err != nil {
+			return nil, err
+		}
+		return []*Manifest{manifest}, nil
+	}
+	if manifest, ok := p.Versions[spec]; ok {
+		return []*Manifest{manifest}, nil
+	}
+
+	rng, err := semver.ParseRange(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is neither a dist-tag nor a valid range", p.Name, spec)
+	}
+
+	var matches []*Manifest
+	for _, version := range p.VersionList() {
+		v, err := semver.Parse(version)
+		if err == nil && rng.Contains(v) {
+			matches = append(matches, p.Versions[version])
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("%s: no version matches %s", p.Name, spec)
+	}
+	return matches, nil
+}
+
+// Fields returns the document `view` prints and selects fields from: the
+// version's manifest merged with the package-level dist-tags, versions,
+// publish times and maintainers, the way npm view presents it
+func (p *Packument) Fields(manifest *Manifest) map[string]interface{} {
+	fields := map[string]interface{}{
+		"name":    manifest.Name,
+		"version": manifest.Version,
+	}
+	if manifest.Name == "" {
+		fields["name"] = p.Name
+	}
+
+	setString(fields, "description", manifest.Description, p.Description)
+	setString(fields, "license", string(manifest.License), string(p.License))
+	setString(fields, "homepage", manifest.Homepage, p.Homepage)
+	setString(fields, "deprecated", string(manifest.Deprecated))
+
+	setMap(fields, "dependencies", manifest.Dependencies)
+	setMap(fields, "devDependencies", manifest.DevDependencies)
+	setMap(fields, "peerDependencies", manifest.PeerDependencies)
+	setMap(fields, "optionalDependencies", manifest.OptionalDependencies)
+	setMap(fields, "engines", manifest.Engines)
+	setMap(fields, "dist-tags", p.DistTags)
+
+	if len(manifest.OS) > 0 {
+		fields["os"] = manifest.OS
+	}
+	if len(manifest.CPU) > 0 {
+		fields["cpu"] = manifest.CPU
+	}
+
+	dist := map[string]interface{}{}
+	setString(dist, "tarball", manifest.Dist.Tarball)
+	setString(dist, "shasum", manifest.Dist.Shasum)
+	setString(dist, "integrity", manifest.Dist.Integrity)
+	if manifest.Dist.FileCount > 0 {
+		dist["fileCount"] = manifest.Dist.FileCount
+	}
+	if manifest.Dist.UnpackedSize > 0 {
+		dist["unpackedSize"] = manifest.Dist.UnpackedSize
+	}
+	if len(dist) > 0 {
+		fields["dist"] = dist
+	}
+
+	repository := manifest.Repository
+	if repository.URL == "" {
+		repository = p.Repository
+	}
+	if repository.URL != "" {
+		repo := map[string]interface{}{"url": repository.URL}
+		setString(repo, "type", repository.Type)
+		setString(repo, "directory", repository.Directory)
+		fields["repository"] = repo
+	}
+
+	maintainers := manifest.Maintainers
+	if len(maintainers) == 0 {
+		maintainers = p.Maintainers
+	}
+	if len(maintainers) > 0 {
+		list := make([]string, 0, len(maintainers))
+		for _, person := range maintainers {
+			list = append(list, person.String())
+		}
+		fields["maintainers"] = list
+	}
+
+	if versions := p.VersionList(); len(versions) > 0 {
+		fields["versions"] = versions
+	}
+	if len(p.Time) > 0 {
+		times := make(map[string]interface{}, len(p.Time))
+		for key, t := range p.Time {
+			times[key] = t.UTC().Format(time.RFC3339)
+		}
+		fields["time"] = times
+	}
+
+	return fields
+}
+
+func setString(fields map[string]interface{}, key string, values ...string) {
+	for _, value := range values {
+		if value != "" {
+			fields[key] = value
+			return
+		}
+	}
+}
+
+func setMap(fields map[string]interface{}, key string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	fields[key] = m
+}
+
+// SelectField looks up a dotted path such as "dist.tarball" or
+// "dependencies.react". Map keys containing dots (like "time.1.0.0") are
+// matched greedily. List elements are addressed by index.
+func SelectField(fields map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = fields
+	rest := path
+	for rest != "" {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			key, value, ok := lookupKey(node, rest)
+			if !ok {
+				return nil, false
+			}
+			current = value
+			rest = strings.TrimPrefix(rest[len(key):], ".")
+		case []string:
+			head, tail, _ := strings.Cut(rest, ".")
+			var idx int
+			if _, err := fmt.Sscanf(head, "%d", &idx); err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+			rest = tail
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// lookupKey finds the longest key that is a dot-separated prefix of path
+func lookupKey(node map[string]interface{}, path string) (string, interface{}, bool) {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+	for _, key := range keys {
+		if path == key || strings.HasPrefix(path, key+".") {
+			return key, node[key], true
+		}
+	}
+	return "", nil, false
+}
+
+var (
+	shorthandPattern = regexp.MustCompile(`^(?:(github|gitlab|bitbucket|gist):)?([\w.-]+)/([\w.-]+?)(?:\.git)?(?:#.*)?$`)
+	scpPattern       = regexp.MustCompile(`^(?:[\w.-]+@)?([\w-]+\.[\w.-]+):([^/][^:]*?)(?:\.git)?/?$`)
+)
+
+var shorthandHosts = map[string]string{
+	"":          "github.com",
+	"github":    "github.com",
+	"gitlab":    "gitlab.com",
+	"bitbucket": "bitbucket.org",
+}
+
+// RepositoryURL turns a repository field into a browsable https URL:
+// "github:user/repo", "user/repo", "git+https://host/repo.git",
+// "git://host/repo" and "git@host:user/repo.git" all work. A monorepo
+// directory is appended as a tree path. Empty if the URL can't be resolved.
+func RepositoryURL(repo Repository) string {
+	raw := strings.TrimSpace(repo.URL)
+	if raw == "" {
+		return ""
+	}
+
+	var base string
+	if match := shorthandPattern.FindStringSubmatch(raw); match != nil && !strings.Contains(raw, "://") {
+		if match[1] == "gist" {
+			return "https://gist.github.com/" + match[3]
+		}
+		base = "https://" + shorthandHosts[match[1]] + "/" + match[2] + "/" + match[3]
+	} else {
+		base = normalizeGitURL(raw)
+	}
+	if base == "" {
+		return ""
+	}
+
+	if dir := strings.Trim(repo.Directory, "/"); dir != "" {
+		base += "/tree/HEAD/" + dir
+	}
+	return base
+}
+
+func normalizeGitURL(raw string) string {
+	raw = strings.TrimPrefix(raw, "git+")
+	raw, _, _ = strings.Cut(raw, "#")
+
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		// scp-like syntax: git@github.com:user/repo.git
+		match := scpPattern.FindStringSubmatch(raw)
+		if match == nil {
+			return ""
+		}
+		return "https://" + match[1] + "/" + match[2]
+	}
+
+	switch scheme {
+	case "https", "http", "git", "ssh":
+	default:
+		return ""
+	}
+
+	// Drop credentials and ports, which only make sense for the git transport
+	if at := strings.Index(rest, "@"); at >= 0 && at < strings.Index(rest+"/", "/") {
+		rest = rest[at+1:]
+	}
+	host, path, _ := strings.Cut(rest, "/")
+	if scheme != "https" && scheme != "http" {
+		host, _, _ = strings.Cut(host, ":")
+		scheme = "https"
+	}
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	if host == "" {
+		return ""
+	}
+	if path == "" {
+		return scheme + "://" + host
+	}
+	return scheme + "://" + host + "/" + path
+}
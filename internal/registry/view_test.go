@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func loadReact(t *testing.T) *Packument {
+	t.Helper()
+	var packument Packument
+	if err := json.Unmarshal([]byte(reactPackument), &packument); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return &packument
+}
+
+func TestSplitSpec(t *testing.T) {
+	tests := []struct {
+		arg  string
+		name string
+		spec string
+	}{
+		{"react", "react", ""},
+		{"react@^18", "react", "^18"},
+		{"react@next", "react", "next"},
+		{"@types/node", "@types/node", ""},
+		{"@types/node@20.1.0", "@types/node", "20.1.0"},
+	}
+	for _, tt := range tests {
+		name, spec := SplitSpec(tt.arg)
+		if name != tt.name || spec != tt.spec {
+			t.Errorf("SplitSpec(%q) = %q, %q, want %q, %q", tt.arg, name, spec, tt.name, tt.spec)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	packument := loadReact(t)
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"", []string{"18.3.1"}},
+		{"next", []string{"19.0.0-rc.1"}},
+		{"17.0.2", []string{"17.0.2"}},
+		{">=17", []string{"17.0.2", "18.2.0", "18.3.1"}},
+		{"^18", []string{"18.2.0", "18.3.1"}},
+	}
+	for _, tt := range tests {
+		matches, err := packument.Matches(tt.spec)
+		if err != nil {
+			t.Errorf("Matches(%q): %v", tt.spec, err)
+			continue
+		}
+		var got []string
+		for _, manifest := range matches {
+			got = append(got, manifest.Version)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Matches(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	if _, err := packument.Matches("^20"); err == nil {
+		t.Error("Matches(^20) should fail")
+	}
+}
+
+func TestSelectField(t *testing.T) {
+	packument := loadReact(t)
+	fields := packument.Fields(packument.Versions["18.3.1"])
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"version", "18.3.1"},
+		{"license", "MIT"},
+		{"dist.integrity", "sha512-abc"},
+		{"dist.unpackedSize", int64(318000)},
+		{"dependencies.loose-envify", "^1.1.0"},
+		{"dist-tags.next", "19.0.0-rc.1"},
+		{"repository.url", "github:facebook/react"},
+		{"time.18.3.1", "2024-04-26T16:42:15Z"},
+		{"versions.0", "17.0.2"},
+		{"maintainers", []string{"Dan <dan@example.com> (https://example.com)"}},
+	}
+	for _, tt := range tests {
+		got, ok := SelectField(fields, tt.path)
+		if !ok {
+			t.Errorf("SelectField(%q) not found", tt.path)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SelectField(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"nope", "dist.nope", "versions.9", "version.major"} {
+		if got, ok := SelectField(fields, path); ok {
+			t.Errorf("SelectField(%q) = %v, want not found", path, got)
+		}
+	}
+
+	// Older versions fall back to the package-level repository and maintainers
+	old := packument.Fields(packument.Versions["17.0.2"])
+	if got, _ := SelectField(old, "repository.directory"); got != "packages/react" {
+		t.Errorf("repository.directory = %v, want packages/react", got)
+	}
+	if _, ok := SelectField(old, "deprecated"); ok {
+		t.Error("deprecated should be absent")
+	}
+}
+
+func TestRepositoryURL(t *testing.T) {
+	tests := []struct {
+		repo Repository
+		want string
+	}{
+		{Repository{URL: "github:facebook/react"}, "https://github.com/facebook/react"},
+		{Repository{URL: "facebook/react"}, "https://github.com/facebook/react"},
+		{Repository{URL: "gitlab:group/project"}, "https://gitlab.com/group/project"},
+		{Repository{URL: "bitbucket:team/repo"}, "https://bitbucket.org/team/repo"},
+		{Repository{URL: "gist:user/11081aaa"}, "https://gist.github.com/11081aaa"},
+		{Repository{URL: "git+https://github.com/lodash/lodash.git"}, "https://github.com/lodash/lodash"},
+		{Repository{URL: "https://github.com/lodash/lodash"}, "https://github.com/lodash/lodash"},
+		{Repository{URL: "git://github.com/isaacs/node-glob.git"}, "https://github.com/isaacs/node-glob"},
+		{Repository{URL: "git+ssh://git@github.com/user/repo.git"}, "https://github.com/user/repo"},
+		{Repository{URL: "ssh://git@git.example.com:2222/team/repo.git"}, "https://git.example.com/team/repo"},
+		{Repository{URL: "git@github.com:user/repo.git"}, "https://github.com/user/repo"},
+		{Repository{URL: "https://github.com/user/repo.git#main"}, "https://github.com/user/repo"},
+		{
+			Repository{URL: "git+https://github.com/facebook/react.git", Directory: "packages/react"},
+			"https://github.com/facebook/react/tree/HEAD/packages/react",
+		},
+		{Repository{URL: ""}, ""},
+		{Repository{URL: "file:../local"}, ""},
+	}
+	for _, tt := range tests {
+		if got := RepositoryURL(tt.repo); got != tt.want {
+			t.Errorf("RepositoryURL(%+v) = %q, want %q", tt.repo, got, tt.want)
+		}
+	}
+}
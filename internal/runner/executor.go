@@ -5,14 +5,21 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/AkaraChen/gnpm/internal/context"
 	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/native"
 	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/process"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/shell"
+	"github.com/AkaraChen/gnpm/internal/toolchain"
 )
 
 // Options for running commands
 type Options struct {
 	Verbose bool
 	DryRun  bool
+	Spec    *context.PackageManagerSpec // pinned package manager version, if any
 }
 
 // Run executes a package manager command
@@ -20,7 +27,7 @@ func Run(pm pmcombo.PackageManager, args []string, workDir string, opts Options)
 	executable := pm.Executable()
 	cmdStr := executable
 	if len(args) > 0 {
-		cmdStr += " " + strings.Join(args, " ")
+		cmdStr += " " + shell.Join(args)
 	}
 
 	if opts.DryRun {
@@ -28,17 +35,53 @@ func Run(pm pmcombo.PackageManager, args []string, workDir string, opts Options)
 		return nil
 	}
 
+	bin, err := resolve(pm, workDir, opts)
+	if err != nil {
+		return err
+	}
+
 	if opts.Verbose {
+		if bin.Source != toolchain.SourcePath {
+			logger.Dim("using %s %s from %s", executable, bin.Version, bin.Source)
+		}
 		logger.Command(cmdStr)
 	}
 
-	cmd := exec.Command(executable, args...)
+	cmd := bin.Command(args...)
 	cmd.Dir = workDir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	return cmd.Run()
+	return process.Run(cmd)
+}
+
+// resolve finds the package manager binary, applying the pin's onFail policy
+func resolve(pm pmcombo.PackageManager, workDir string, opts Options) (toolchain.Binary, error) {
+	var registryURL string
+	var config registry.Config
+	if opts.Spec != nil {
+		registryURL, _ = native.GetRegistry(native.RegistryOptions{Dir: workDir})
+		config = registry.Config(native.LoadNpmrc(workDir))
+	}
+	bin, err := toolchain.Resolve(pm, opts.Spec, workDir, toolchain.ResolveOptions{
+		Offline:  toolchain.Offline(),
+		Registry: registryURL,
+		Config:   config,
+	})
+	if err == nil {
+		return bin, nil
+	}
+
+	switch opts.Spec.OnFail {
+	case "ignore":
+		return bin, nil
+	case "warn":
+		logger.Warn("%v", err)
+		return bin, nil
+	default:
+		return bin, err
+	}
 }
 
 // RunOutput executes a package manager command and returns the output
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
+
+	"github.com/AkaraChen/gnpm/internal/semver"
 )
 
 const (
@@ -53,7 +55,7 @@ func detectBunVersionFromSystem(rootDir string) (bunVersionResult, error) {
 		return bunVersionResult{}, fmt.Errorf("detect bun version: %w", err)
 	}
 
-	version, ok := parseSemver(output)
+	version, ok := semver.Extract(output)
 	if !ok {
 		return bunVersionResult{}, fmt.Errorf("bun returned an unparseable version: %q", strings.TrimSpace(output))
 	}
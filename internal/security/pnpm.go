@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +13,8 @@ import (
 	projectcontext "github.com/AkaraChen/gnpm/internal/context"
 	"github.com/AkaraChen/gnpm/internal/logger"
 	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/semver"
+	"github.com/AkaraChen/gnpm/internal/yamldoc"
 	"gopkg.in/yaml.v3"
 )
 
@@ -156,7 +157,7 @@ func detectPNPMVersionFromSystem(rootDir string) (pnpmVersionResult, error) {
 			continue
 		}
 
-		version, ok := parseSemver(output)
+		version, ok := semver.Extract(output)
 		if !ok {
 			errors = append(errors, fmt.Sprintf("%s returned an unparseable version: %q", probe.source, strings.TrimSpace(output)))
 			continue
@@ -203,12 +204,12 @@ func EnsurePNPMBestPractices(rootDir string, version string, opts Options) (Resu
 	}
 
 	path := filepath.Join(rootDir, pnpmWorkspaceFile)
-	doc, err := readOrCreateYAMLDocument(path)
+	doc, err := yamldoc.Read(path)
 	if err != nil {
 		return result, err
 	}
 
-	root := ensureMappingDocument(doc)
+	root := yamldoc.Root(doc)
 	if !supportsPMSetting(version, "10.9.0") {
 		result.Unsupported = append(result.Unsupported, "dangerouslyAllowAllBuilds")
 	} else if ensureBool(root, "dangerouslyAllowAllBuilds", false) {
@@ -258,76 +259,11 @@ func EnsurePNPMBestPractices(rootDir string, version string, opts Options) (Resu
 		return result, nil
 	}
 
-	return result, writeYAMLDocument(path, doc)
-}
-
-func readOrCreateYAMLDocument(path string) (*yaml.Node, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return newMappingDocument(), nil
-		}
-		return nil, err
-	}
-
-	var doc yaml.Node
-	if len(strings.TrimSpace(string(data))) == 0 {
-		return newMappingDocument(), nil
-	}
-	if err := yaml.Unmarshal(data, &doc); err != nil {
-		return nil, err
-	}
-	return &doc, nil
-}
-
-func newMappingDocument() *yaml.Node {
-	return &yaml.Node{
-		Kind: yaml.DocumentNode,
-		Content: []*yaml.Node{{
-			Kind: yaml.MappingNode,
-			Tag:  "!!map",
-		}},
-	}
-}
-
-func ensureMappingDocument(doc *yaml.Node) *yaml.Node {
-	if doc.Kind != yaml.DocumentNode {
-		doc.Kind = yaml.DocumentNode
-	}
-	if len(doc.Content) == 0 || doc.Content[0] == nil {
-		doc.Content = []*yaml.Node{{
-			Kind: yaml.MappingNode,
-			Tag:  "!!map",
-		}}
-	}
-
-	root := doc.Content[0]
-	if root.Kind != yaml.MappingNode {
-		root.Kind = yaml.MappingNode
-		root.Tag = "!!map"
-		root.Value = ""
-		root.Content = nil
-	}
-	if root.Tag == "" {
-		root.Tag = "!!map"
-	}
-	return root
-}
-
-func writeYAMLDocument(path string, doc *yaml.Node) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return err
-	}
-
-	data, err := yaml.Marshal(doc)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0644)
+	return result, yamldoc.Write(path, doc)
 }
 
 func ensureBool(root *yaml.Node, key string, desired bool) bool {
-	value := findMapValue(root, key)
+	value := yamldoc.Get(root, key)
 	if value != nil && value.Kind == yaml.ScalarNode && value.Tag == "!!bool" {
 		current, err := strconv.ParseBool(value.Value)
 		if err == nil && current == desired {
@@ -335,12 +271,12 @@ func ensureBool(root *yaml.Node, key string, desired bool) bool {
 		}
 	}
 
-	setMapValue(root, key, boolNode(desired))
+	yamldoc.Set(root, key, boolNode(desired))
 	return true
 }
 
 func ensureString(root *yaml.Node, key string, desired string) bool {
-	value := findMapValue(root, key)
+	value := yamldoc.Get(root, key)
 	if value != nil && value.Kind == yaml.ScalarNode && value.Value == desired {
 		if value.Tag == "" {
 			value.Tag = "!!str"
@@ -348,12 +284,12 @@ func ensureString(root *yaml.Node, key string, desired string) bool {
 		return false
 	}
 
-	setMapValue(root, key, stringNode(desired))
+	yamldoc.Set(root, key, yamldoc.String(desired))
 	return true
 }
 
 func ensureMinInt(root *yaml.Node, key string, min int) bool {
-	value := findMapValue(root, key)
+	value := yamldoc.Get(root, key)
 	if value != nil && value.Kind == yaml.ScalarNode && (value.Tag == "!!int" || value.Tag == "") {
 		current, err := strconv.Atoi(value.Value)
 		if err == nil && current >= min {
@@ -364,12 +300,12 @@ func ensureMinInt(root *yaml.Node, key string, min int) bool {
 		}
 	}
 
-	setMapValue(root, key, intNode(min))
+	yamldoc.Set(root, key, intNode(min))
 	return true
 }
 
 func ensureMap(root *yaml.Node, key string) bool {
-	value := findMapValue(root, key)
+	value := yamldoc.Get(root, key)
 	if value != nil && value.Kind == yaml.MappingNode {
 		if value.Tag == "" {
 			value.Tag = "!!map"
@@ -377,30 +313,10 @@ func ensureMap(root *yaml.Node, key string) bool {
 		return false
 	}
 
-	setMapValue(root, key, mapNode())
+	yamldoc.Set(root, key, yamldoc.Map())
 	return true
 }
 
-func findMapValue(root *yaml.Node, key string) *yaml.Node {
-	for i := 0; i+1 < len(root.Content); i += 2 {
-		if root.Content[i].Kind == yaml.ScalarNode && root.Content[i].Value == key {
-			return root.Content[i+1]
-		}
-	}
-	return nil
-}
-
-func setMapValue(root *yaml.Node, key string, value *yaml.Node) {
-	for i := 0; i+1 < len(root.Content); i += 2 {
-		if root.Content[i].Kind == yaml.ScalarNode && root.Content[i].Value == key {
-			root.Content[i+1] = value
-			return
-		}
-	}
-
-	root.Content = append(root.Content, stringNode(key), value)
-}
-
 func boolNode(value bool) *yaml.Node {
 	return &yaml.Node{
 		Kind:  yaml.ScalarNode,
@@ -417,105 +333,10 @@ func intNode(value int) *yaml.Node {
 	}
 }
 
-func stringNode(value string) *yaml.Node {
-	return &yaml.Node{
-		Kind:  yaml.ScalarNode,
-		Tag:   "!!str",
-		Value: value,
-	}
-}
-
-func mapNode() *yaml.Node {
-	return &yaml.Node{
-		Kind:  yaml.MappingNode,
-		Tag:   "!!map",
-		Style: yaml.FlowStyle,
-	}
-}
-
-type semver struct {
-	Major      int
-	Minor      int
-	Patch      int
-	Prerelease string
-}
-
-var semverPattern = regexp.MustCompile(`v?([0-9]+)\.([0-9]+)\.([0-9]+)(?:-([0-9A-Za-z.-]+))?`)
-
-func parseSemver(value string) (semver, bool) {
-	match := semverPattern.FindStringSubmatch(strings.TrimSpace(value))
-	if match == nil {
-		return semver{}, false
-	}
-
-	major, err := strconv.Atoi(match[1])
-	if err != nil {
-		return semver{}, false
-	}
-	minor, err := strconv.Atoi(match[2])
-	if err != nil {
-		return semver{}, false
-	}
-	patch, err := strconv.Atoi(match[3])
-	if err != nil {
-		return semver{}, false
-	}
-
-	return semver{
-		Major:      major,
-		Minor:      minor,
-		Patch:      patch,
-		Prerelease: match[4],
-	}, true
-}
-
 func compareSemver(a string, b string) int {
-	aVersion, aOK := parseSemver(a)
-	bVersion, bOK := parseSemver(b)
-	if !aOK || !bOK {
-		return strings.Compare(a, b)
-	}
-
-	if aVersion.Major != bVersion.Major {
-		return compareInt(aVersion.Major, bVersion.Major)
-	}
-	if aVersion.Minor != bVersion.Minor {
-		return compareInt(aVersion.Minor, bVersion.Minor)
-	}
-	if aVersion.Patch != bVersion.Patch {
-		return compareInt(aVersion.Patch, bVersion.Patch)
-	}
-	if aVersion.Prerelease == bVersion.Prerelease {
-		return 0
-	}
-	if aVersion.Prerelease == "" {
-		return 1
-	}
-	if bVersion.Prerelease == "" {
-		return -1
-	}
-	return strings.Compare(aVersion.Prerelease, bVersion.Prerelease)
+	return semver.Compare(a, b)
 }
 
 func supportsPMSetting(version string, minVersion string) bool {
 	return compareSemver(version, minVersion) >= 0
 }
-
-func compareInt(a int, b int) int {
-	switch {
-	case a < b:
-		return -1
-	case a > b:
-		return 1
-	default:
-		return 0
-	}
-}
-
-func (v semver) String() string {
-	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
-	if v.Prerelease != "" {
-		version += "-" + v.Prerelease
-	}
-	return version
-}
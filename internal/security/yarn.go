@@ -8,6 +8,9 @@ import (
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/AkaraChen/gnpm/internal/semver"
+	"github.com/AkaraChen/gnpm/internal/yamldoc"
 )
 
 const (
@@ -65,7 +68,7 @@ func detectYarnVersionFromSystem(rootDir string) (yarnVersionResult, error) {
 			continue
 		}
 
-		version, ok := parseSemver(output)
+		version, ok := semver.Extract(output)
 		if !ok {
 			errors = append(errors, fmt.Sprintf("%s returned an unparseable version: %q", probe.source, strings.TrimSpace(output)))
 			continue
@@ -85,12 +88,12 @@ func EnsureYarnBestPractices(rootDir string, version string, opts Options) (Resu
 	}
 
 	path := filepath.Join(rootDir, yarnRCFile)
-	doc, err := readOrCreateYAMLDocument(path)
+	doc, err := yamldoc.Read(path)
 	if err != nil {
 		return result, err
 	}
 
-	root := ensureMappingDocument(doc)
+	root := yamldoc.Root(doc)
 	if !supportsPMSetting(version, "2.0.0") {
 		result.Unsupported = append(result.Unsupported, "defaultSemverRangePrefix")
 	} else if ensureString(root, "defaultSemverRangePrefix", "") {
@@ -125,11 +128,11 @@ func EnsureYarnBestPractices(rootDir string, version string, opts Options) (Resu
 		return result, nil
 	}
 
-	return result, writeYAMLDocument(path, doc)
+	return result, yamldoc.Write(path, doc)
 }
 
 func ensureMinYarnDuration(root *yaml.Node, key string, minMinutes int) bool {
-	value := findMapValue(root, key)
+	value := yamldoc.Get(root, key)
 	if value != nil && value.Kind == yaml.ScalarNode {
 		current, ok := yarnDurationMinutes(value.Value)
 		if ok && current >= minMinutes {
@@ -140,7 +143,7 @@ func ensureMinYarnDuration(root *yaml.Node, key string, minMinutes int) bool {
 		}
 	}
 
-	setMapValue(root, key, intNode(minMinutes))
+	yamldoc.Set(root, key, intNode(minMinutes))
 	return true
 }
 
@@ -0,0 +1,487 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Range is a parsed node-semver range: a union (||) of comparator sets, each
+// of which must be satisfied entirely
+type Range struct {
+	raw  string
+	sets []comparatorSet
+}
+
+// comparator is a single operator/version pair; an empty op with a nil
+// version matches every version
+type comparator struct {
+	op      string // "<", "<=", ">", ">=", "=" or "" for any
+	version *Version
+}
+
+var (
+	orPattern       = regexp.MustCompile(`\s*\|\|\s*`)
+	hyphenPattern   = regexp.MustCompile(`^\s*(\S+)\s+-\s+(\S+)\s*$`)
+	operatorSpacing = regexp.MustCompile(`(~>?|\^|[<>]=?|=)\s+`)
+	partialPattern  = regexp.MustCompile(`^v?([0-9]+|[xX*])(?:\.([0-9]+|[xX*]))?(?:\.([0-9]+|[xX*]))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+)
+
+// ParseRange parses a range such as "^1.2.3", ">=18 <21", "1.x || 2.0.0 - 2.5"
+// or "~1.2.3-beta.1". An empty range matches every version.
+func ParseRange(value string) (Range, error) {
+	r := Range{raw: strings.TrimSpace(value)}
+
+	for _, alternative := range orPattern.Split(r.raw, -1) {
+		set, err := parseComparatorSet(alternative)
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid range %q: %w", value, err)
+		}
+		r.sets = append(r.sets, set)
+	}
+
+	return r, nil
+}
+
+// MustParseRange is like ParseRange but panics on invalid input
+func MustParseRange(value string) Range {
+	r, err := ParseRange(value)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// ValidRange reports whether value parses as a range
+func ValidRange(value string) bool {
+	_, err := ParseRange(value)
+	return err == nil
+}
+
+// Raw returns the range as it was written
+func (r Range) Raw() string {
+	return r.raw
+}
+
+// String returns the desugared range, e.g. "^1.2.3" becomes ">=1.2.3 <2.0.0-0"
+func (r Range) String() string {
+	alternatives := make([]string, 0, len(r.sets))
+	for _, set := range r.sets {
+		parts := make([]string, 0, len(set))
+		for _, c := range set {
+			parts = append(parts, c.String())
+		}
+		alternatives = append(alternatives, strings.Join(parts, " "))
+	}
+	return strings.Join(alternatives, "||")
+}
+
+func (c comparator) String() string {
+	if c.version == nil {
+		return "*"
+	}
+	if c.op == "=" {
+		return c.version.String()
+	}
+	return c.op + c.version.String()
+}
+
+// Contains reports whether v satisfies the range. Like node-semver, a
+// prerelease version only matches when a comparator in the same set has a
+// prerelease on the same major.minor.patch, so "^1.2.3-beta.1" admits
+// 1.2.3-beta.2 but not 1.2.4-beta.1.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if set.matches(v) && set.allowsPrerelease(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsPrerelease is like Contains but treats prerelease versions like
+// releases (node-semver's includePrerelease option)
+func (r Range) ContainsPrerelease(v Version) bool {
+	for _, set := range r.sets {
+		if set.matches(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MinVersion returns the lowest version that can satisfy the range
+func (r Range) MinVersion() (Version, bool) {
+	var best *Version
+	for _, set := range r.sets {
+		candidate := Version{}
+		for _, c := range set {
+			if c.version == nil {
+				continue
+			}
+			switch c.op {
+			case ">":
+				next := *c.version
+				if next.IsPrerelease() {
+					next.Prerelease = append(append([]string{}, next.Prerelease...), "0")
+				} else {
+					next.Patch++
+				}
+				if candidate.LessThan(next) {
+					candidate = next
+				}
+			case "=", ">=":
+				if candidate.LessThan(*c.version) {
+					candidate = *c.version
+				}
+			}
+		}
+		if !set.matches(candidate) {
+			continue
+		}
+		if best == nil || candidate.LessThan(*best) {
+			found := candidate
+			best = &found
+		}
+	}
+
+	if best == nil {
+		return Version{}, false
+	}
+	return *best, true
+}
+
+type comparatorSet []comparator
+
+func (s comparatorSet) matches(v Version) bool {
+	for _, c := range s {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s comparatorSet) allowsPrerelease(v Version) bool {
+	if !v.IsPrerelease() {
+		return true
+	}
+	for _, c := range s {
+		if c.version == nil || !c.version.IsPrerelease() {
+			continue
+		}
+		if c.version.Major == v.Major && c.version.Minor == v.Minor && c.version.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v Version) bool {
+	if c.version == nil {
+		return true
+	}
+
+	cmp := v.Compare(*c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// parseComparatorSet desugars one ||-separated alternative into primitive comparators
+func parseComparatorSet(value string) (comparatorSet, error) {
+	value = strings.TrimSpace(value)
+
+	if match := hyphenPattern.FindStringSubmatch(value); match != nil {
+		return parseHyphen(match[1], match[2])
+	}
+
+	value = operatorSpacing.ReplaceAllString(value, "$1")
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return comparatorSet{{}}, nil
+	}
+
+	var set comparatorSet
+	for _, field := range fields {
+		comparators, err := parseComparator(field)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comparators...)
+	}
+
+	// "*" comparators are redundant next to real ones
+	var filtered comparatorSet
+	for _, c := range set {
+		if c.version != nil {
+			filtered = append(filtered, c)
+		}
+	}
+	if len(filtered) == 0 {
+		return comparatorSet{{}}, nil
+	}
+	return filtered, nil
+}
+
+// partial is a possibly incomplete version such as "1", "1.2.x" or "1.2.3-rc.1"
+type partial struct {
+	major, minor, patch uint64
+	hasMinor, hasPatch  bool
+	anyMajor            bool
+	prerelease          []string
+}
+
+func parsePartial(value string) (partial, error) {
+	match := partialPattern.FindStringSubmatch(value)
+	if match == nil {
+		return partial{}, fmt.Errorf("invalid version %q", value)
+	}
+
+	p := partial{}
+	if isWildcard(match[1]) {
+		p.anyMajor = true
+		return p, nil
+	}
+
+	var err error
+	if p.major, err = strconv.ParseUint(match[1], 10, 64); err != nil {
+		return partial{}, err
+	}
+	if match[2] == "" || isWildcard(match[2]) {
+		return p, nil
+	}
+	p.hasMinor = true
+	if p.minor, err = strconv.ParseUint(match[2], 10, 64); err != nil {
+		return partial{}, err
+	}
+	if match[3] == "" || isWildcard(match[3]) {
+		return p, nil
+	}
+	p.hasPatch = true
+	if p.patch, err = strconv.ParseUint(match[3], 10, 64); err != nil {
+		return partial{}, err
+	}
+	if match[4] != "" {
+		p.prerelease = strings.Split(match[4], ".")
+	}
+	return p, nil
+}
+
+func isWildcard(value string) bool {
+	return value == "x" || value == "X" || value == "*"
+}
+
+func (p partial) version() *Version {
+	return &Version{Major: p.major, Minor: p.minor, Patch: p.patch, Prerelease: p.prerelease}
+}
+
+// upperBound returns the exclusive "-0" bound just past the partial's wildcard part
+func upperBound(major uint64, minor uint64, patch uint64) *Version {
+	return &Version{Major: major, Minor: minor, Patch: patch, Prerelease: []string{"0"}}
+}
+
+func parseComparator(value string) ([]comparator, error) {
+	switch {
+	case strings.HasPrefix(value, "^"):
+		return parseCaret(strings.TrimPrefix(value, "^"))
+	case strings.HasPrefix(value, "~>"):
+		return parseTilde(strings.TrimPrefix(value, "~>"))
+	case strings.HasPrefix(value, "~"):
+		return parseTilde(strings.TrimPrefix(value, "~"))
+	}
+
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			break
+		}
+	}
+	return parseXRange(op, strings.TrimPrefix(value, op))
+}
+
+// parseCaret desugars ^ ranges: changes that don't modify the left-most non-zero part
+func parseCaret(value string) ([]comparator, error) {
+	if value == "" {
+		return []comparator{{}}, nil
+	}
+	p, err := parsePartial(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.anyMajor:
+		return []comparator{{}}, nil
+	case !p.hasMinor:
+		return []comparator{{">=", p.version()}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+	case !p.hasPatch:
+		if p.major == 0 {
+			return []comparator{{">=", p.version()}, {"<", upperBound(0, p.minor+1, 0)}}, nil
+		}
+		return []comparator{{">=", p.version()}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+	case p.major > 0:
+		return []comparator{{">=", p.version()}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+	case p.minor > 0:
+		return []comparator{{">=", p.version()}, {"<", upperBound(0, p.minor+1, 0)}}, nil
+	default:
+		return []comparator{{">=", p.version()}, {"<", upperBound(0, 0, p.patch+1)}}, nil
+	}
+}
+
+// parseTilde desugars ~ ranges: patch-level changes when a minor is given
+func parseTilde(value string) ([]comparator, error) {
+	if value == "" {
+		return []comparator{{}}, nil
+	}
+	p, err := parsePartial(value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.anyMajor:
+		return []comparator{{}}, nil
+	case !p.hasMinor:
+		return []comparator{{">=", p.version()}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+	default:
+		return []comparator{{">=", p.version()}, {"<", upperBound(p.major, p.minor+1, 0)}}, nil
+	}
+}
+
+// parseXRange desugars comparisons with partial or wildcard versions
+func parseXRange(op string, value string) ([]comparator, error) {
+	if value == "" {
+		value = "*"
+	}
+	p, err := parsePartial(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.anyMajor {
+		if op == "<" || op == ">" {
+			// Nothing is below or above everything
+			return []comparator{{"<", upperBound(0, 0, 0)}}, nil
+		}
+		return []comparator{{}}, nil
+	}
+
+	if p.hasPatch {
+		if op == "" {
+			op = "="
+		}
+		return []comparator{{op, p.version()}}, nil
+	}
+
+	switch op {
+	case ">":
+		// >1 is >=2.0.0, >1.2 is >=1.3.0
+		if !p.hasMinor {
+			return []comparator{{">=", &Version{Major: p.major + 1}}}, nil
+		}
+		return []comparator{{">=", &Version{Major: p.major, Minor: p.minor + 1}}}, nil
+	case ">=":
+		return []comparator{{">=", p.version()}}, nil
+	case "<":
+		return []comparator{{"<", upperBound(p.major, p.minor, 0)}}, nil
+	case "<=":
+		// <=1 is <2.0.0-0, <=1.2 is <1.3.0-0
+		if !p.hasMinor {
+			return []comparator{{"<", upperBound(p.major+1, 0, 0)}}, nil
+		}
+		return []comparator{{"<", upperBound(p.major, p.minor+1, 0)}}, nil
+	default:
+		if !p.hasMinor {
+			return []comparator{{">=", p.version()}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+		}
+		return []comparator{{">=", p.version()}, {"<", upperBound(p.major, p.minor+1, 0)}}, nil
+	}
+}
+
+// parseHyphen desugars "a - b" inclusive ranges
+func parseHyphen(from string, to string) (comparatorSet, error) {
+	low, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	high, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var set comparatorSet
+	if !low.anyMajor {
+		set = append(set, comparator{">=", low.version()})
+	}
+
+	switch {
+	case high.anyMajor:
+	case !high.hasMinor:
+		set = append(set, comparator{"<", upperBound(high.major+1, 0, 0)})
+	case !high.hasPatch:
+		set = append(set, comparator{"<", upperBound(high.major, high.minor+1, 0)})
+	default:
+		set = append(set, comparator{"<=", high.version()})
+	}
+
+	if len(set) == 0 {
+		return comparatorSet{{}}, nil
+	}
+	return set, nil
+}
+
+// Satisfies reports whether version satisfies rng; invalid input never does
+func Satisfies(version string, rng string) bool {
+	v, err := Parse(version)
+	if err != nil {
+		return false
+	}
+	r, err := ParseRange(rng)
+	if err != nil {
+		return false
+	}
+	return r.Contains(v)
+}
+
+// MaxSatisfying returns the highest of versions that satisfies rng
+func MaxSatisfying(versions []string, rng string) (string, bool) {
+	return pickSatisfying(versions, rng, 1)
+}
+
+// MinSatisfying returns the lowest of versions that satisfies rng
+func MinSatisfying(versions []string, rng string) (string, bool) {
+	return pickSatisfying(versions, rng, -1)
+}
+
+func pickSatisfying(versions []string, rng string, direction int) (string, bool) {
+	r, err := ParseRange(rng)
+	if err != nil {
+		return "", false
+	}
+
+	var best string
+	var bestVersion Version
+	for _, candidate := range versions {
+		v, err := Parse(candidate)
+		if err != nil || !r.Contains(v) {
+			continue
+		}
+		if best == "" || v.Compare(bestVersion) == direction {
+			best = candidate
+			bestVersion = v
+		}
+	}
+	return best, best != ""
+}
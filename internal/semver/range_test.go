@@ -0,0 +1,390 @@
+package semver
+
+import "testing"
+
+// Cases follow node-semver's range-include/range-exclude/range-parse fixtures
+
+func TestRangeIncludes(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+	}{
+		{"1.0.0 - 2.0.0", "1.2.3"},
+		{"^1.2.3+build", "1.2.3"},
+		{"^1.2.3+build", "1.3.0"},
+		{"1.2.3-pre+asdf - 2.4.3-pre+asdf", "1.2.3"},
+		{"1.2.3-pre+asdf - 2.4.3-pre+asdf", "1.2.3-pre.2"},
+		{"1.2.3-pre+asdf - 2.4.3-pre+asdf", "2.4.3-alpha"},
+		{"1.2.3+asdf - 2.4.3+asdf", "1.2.3"},
+		{"1.0.0", "1.0.0"},
+		{">=*", "0.2.4"},
+		{"", "1.0.0"},
+		{"*", "1.2.3"},
+		{"*", "v1.2.3"},
+		{">=1.0.0", "1.0.0"},
+		{">=1.0.0", "1.0.1"},
+		{">=1.0.0", "1.1.0"},
+		{">1.0.0", "1.0.1"},
+		{">1.0.0", "1.1.0"},
+		{"<=2.0.0", "2.0.0"},
+		{"<=2.0.0", "1.9999.9999"},
+		{"<=2.0.0", "0.2.9"},
+		{"<2.0.0", "1.9999.9999"},
+		{"<2.0.0", "0.2.9"},
+		{">= 1.0.0", "1.0.0"},
+		{">=  1.0.0", "1.0.1"},
+		{">=   1.0.0", "1.1.0"},
+		{"> 1.0.0", "1.0.1"},
+		{">  1.0.0", "1.1.0"},
+		{"<=   2.0.0", "2.0.0"},
+		{"<= 2.0.0", "1.9999.9999"},
+		{"<    2.0.0", "1.9999.9999"},
+		{"<\t2.0.0", "0.2.9"},
+		{">=0.1.97", "v0.1.97"},
+		{">=0.1.97", "0.1.97"},
+		{"0.1.20 || 1.2.4", "1.2.4"},
+		{">=0.2.3 || <0.0.1", "0.0.0"},
+		{">=0.2.3 || <0.0.1", "0.2.3"},
+		{">=0.2.3 || <0.0.1", "0.2.4"},
+		{"||", "1.3.4"},
+		{"2.x.x", "2.1.3"},
+		{"1.2.x", "1.2.3"},
+		{"1.2.x || 2.x", "2.1.3"},
+		{"1.2.x || 2.x", "1.2.3"},
+		{"x", "1.2.3"},
+		{"2.*.*", "2.1.3"},
+		{"1.2.*", "1.2.3"},
+		{"1.2.* || 2.*", "2.1.3"},
+		{"1.2.* || 2.*", "1.2.3"},
+		{"2", "2.1.2"},
+		{"2.3", "2.3.1"},
+		{"~0.0.1", "0.0.1"},
+		{"~0.0.1", "0.0.2"},
+		{"~x", "0.0.9"},
+		{"~2", "2.0.9"},
+		{"~2.4", "2.4.0"},
+		{"~2.4", "2.4.5"},
+		{"~>3.2.1", "3.2.2"},
+		{"~1", "1.2.3"},
+		{"~>1", "1.2.3"},
+		{"~> 1", "1.2.3"},
+		{"~1.0", "1.0.2"},
+		{"~ 1.0", "1.0.2"},
+		{"~ 1.0.3", "1.0.12"},
+		{">=1", "1.0.0"},
+		{">= 1", "1.0.0"},
+		{"<1.2", "1.1.1"},
+		{"< 1.2", "1.1.1"},
+		{"~v0.5.4-pre", "0.5.5"},
+		{"~v0.5.4-pre", "0.5.4"},
+		{"=0.7.x", "0.7.2"},
+		{"<=0.7.x", "0.7.2"},
+		{">=0.7.x", "0.7.2"},
+		{"<=0.7.x", "0.6.2"},
+		{"~1.2.1 >=1.2.3", "1.2.3"},
+		{"~1.2.1 =1.2.3", "1.2.3"},
+		{"~1.2.1 1.2.3", "1.2.3"},
+		{"~1.2.1 >=1.2.3 1.2.3", "1.2.3"},
+		{"~1.2.1 1.2.3 >=1.2.3", "1.2.3"},
+		{">=1.2.1 1.2.3", "1.2.3"},
+		{"1.2.3 >=1.2.1", "1.2.3"},
+		{">=1.2.3 >=1.2.1", "1.2.3"},
+		{">=1.2.1 >=1.2.3", "1.2.3"},
+		{">=1.2", "1.2.8"},
+		{"^1.2.3", "1.8.1"},
+		{"^0.1.2", "0.1.2"},
+		{"^0.1", "0.1.2"},
+		{"^0.0.1", "0.0.1"},
+		{"^1.2", "1.4.2"},
+		{"^1.2 ^1", "1.4.2"},
+		{"^1.2.3-alpha", "1.2.3-pre"},
+		{"^1.2.0-alpha", "1.2.0-pre"},
+		{"^0.0.1-alpha", "0.0.1-beta"},
+		{"^0.0.1-alpha", "0.0.1"},
+		{"^0.1.1-alpha", "0.1.1-beta"},
+		{"^x", "1.2.3"},
+		{"x - 1.0.0", "0.9.7"},
+		{"x - 1.x", "0.9.7"},
+		{"1.0.0 - x", "1.9.7"},
+		{"1.x - x", "1.9.7"},
+		{"<=7.x", "7.9.9"},
+		{">=18", "20.11.0"},
+		{">=18 <21", "20.11.0"},
+		{"^18 || ^20 || >=22", "22.1.0"},
+		{"v1.2.3 - v2", "2.9.0"},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q) failed: %v", tt.rng, err)
+			continue
+		}
+		if !r.Contains(MustParse(tt.version)) {
+			t.Errorf("%q should include %s (desugared %q)", tt.rng, tt.version, r.String())
+		}
+	}
+}
+
+func TestRangeExcludes(t *testing.T) {
+	tests := []struct {
+		rng     string
+		version string
+	}{
+		{"1.0.0 - 2.0.0", "2.2.3"},
+		{"1.2.3+asdf - 2.4.3+asdf", "1.2.3-pre.2"},
+		{"1.2.3+asdf - 2.4.3+asdf", "2.4.3-alpha"},
+		{"^1.2.3+build", "2.0.0"},
+		{"^1.2.3+build", "1.2.0"},
+		{"^1.2.3", "1.2.3-pre"},
+		{"^1.2", "1.2.0-pre"},
+		{">1.2", "1.3.0-beta"},
+		{"<=1.2.3", "1.2.3-beta"},
+		{"^1.2.3", "1.2.3-beta"},
+		{"=0.7.x", "0.7.0-asdf"},
+		{">=0.7.x", "0.7.0-asdf"},
+		{"<=0.7.x", "0.7.0-asdf"},
+		{"1", "1.0.0beta"},
+		{"<1", "1.0.0beta"},
+		{"< 1", "1.0.0beta"},
+		{"1.0.0", "1.0.1"},
+		{">=1.0.0", "0.0.0"},
+		{">=1.0.0", "0.0.1"},
+		{">=1.0.0", "0.1.0"},
+		{">1.0.0", "0.0.1"},
+		{">1.0.0", "0.1.0"},
+		{"<=2.0.0", "3.0.0"},
+		{"<=2.0.0", "2.9999.9999"},
+		{"<=2.0.0", "2.2.9"},
+		{"<2.0.0", "2.9999.9999"},
+		{"<2.0.0", "2.2.9"},
+		{">=0.1.97", "v0.1.93"},
+		{">=0.1.97", "0.1.93"},
+		{"0.1.20 || 1.2.4", "1.2.3"},
+		{">=0.2.3 || <0.0.1", "0.0.3"},
+		{">=0.2.3 || <0.0.1", "0.2.2"},
+		{"2.x.x", "1.1.3"},
+		{"2.x.x", "3.1.3"},
+		{"1.2.x", "1.3.3"},
+		{"1.2.x || 2.x", "3.1.3"},
+		{"1.2.x || 2.x", "1.1.3"},
+		{"2.*.*", "1.1.3"},
+		{"2.*.*", "3.1.3"},
+		{"1.2.*", "1.3.3"},
+		{"1.2.* || 2.*", "3.1.3"},
+		{"1.2.* || 2.*", "1.1.3"},
+		{"2", "1.1.2"},
+		{"2.3", "2.4.1"},
+		{"~0.0.1", "0.1.0-alpha"},
+		{"~0.0.1", "0.1.0"},
+		{"~2.4", "2.5.0"},
+		{"~2.4", "2.3.9"},
+		{"~>3.2.1", "3.3.2"},
+		{"~>3.2.1", "3.2.0"},
+		{"~1", "0.2.3"},
+		{"~>1", "2.2.3"},
+		{"~1.0", "1.1.0"},
+		{"<1", "1.0.0"},
+		{">=1.2", "1.1.1"},
+		{"1", "2.0.0beta"},
+		{"~v0.5.4-beta", "0.5.4-alpha"},
+		{"=0.7.x", "0.8.2"},
+		{">=0.7.x", "0.6.2"},
+		{"<0.7.x", "0.7.2"},
+		{"<1.2.3", "1.2.3-beta"},
+		{"=1.2.3", "1.2.3-beta"},
+		{">1.2", "1.2.8"},
+		{"^0.0.1", "0.0.2-alpha"},
+		{"^0.0.1", "0.0.2"},
+		{"^1.2.3", "2.0.0-alpha"},
+		{"^1.2.3", "1.2.2"},
+		{"^1.2", "1.1.9"},
+		{"*", "v1.2.3-foo"},
+		{"^1.0.0", "2.0.0-rc1"},
+		{"1 - 2", "2.0.0-pre"},
+		{"1 - 2", "1.0.0-pre"},
+		{"1.0 - 2", "1.0.0-pre"},
+		{"1.1.x", "1.0.0-a"},
+		{"1.1.x", "1.1.0-a"},
+		{"1.1.x", "1.2.0-a"},
+		{"1.x", "1.0.0-a"},
+		{"1.x", "1.1.0-a"},
+		{"1.x", "1.2.0-a"},
+		{">=1.0.0 <1.1.0", "1.1.0"},
+		{">=1.0.0 <1.1.0", "1.1.0-pre"},
+		{">=1.0.0 <1.1.0-pre", "1.1.0-pre"},
+		{"<1.0.0 || >1.0.0", "1.0.0"},
+		{">*", "1.2.3"},
+		{"<*", "1.2.3"},
+		{">=18 <21", "21.0.0"},
+		{"^18 || ^20", "19.9.0"},
+	}
+
+	for _, tt := range tests {
+		// node-semver's fixtures include loose versions such as "1.0.0beta";
+		// they are invalid here and must never satisfy a range either
+		if Satisfies(tt.version, tt.rng) {
+			t.Errorf("%q should exclude %s (desugared %q)", tt.rng, tt.version, MustParseRange(tt.rng).String())
+		}
+	}
+}
+
+func TestRangeContainsPrerelease(t *testing.T) {
+	tests := []struct {
+		rng      string
+		version  string
+		expected bool
+	}{
+		{"^1.2.3", "1.5.0-beta.1", true},
+		{">=18", "23.0.0-nightly.2024", true},
+		{"1.x", "1.2.0-a", true},
+		{"^1.0.0", "2.0.0-rc1", false},
+		{"<2.0.0", "2.0.0-rc.1", true},
+	}
+
+	for _, tt := range tests {
+		r := MustParseRange(tt.rng)
+		if got := r.ContainsPrerelease(MustParse(tt.version)); got != tt.expected {
+			t.Errorf("%q ContainsPrerelease(%s) = %v, want %v", tt.rng, tt.version, got, tt.expected)
+		}
+	}
+}
+
+func TestRangeString(t *testing.T) {
+	tests := []struct {
+		rng      string
+		expected string
+	}{
+		{"1.0.0 - 2.0.0", ">=1.0.0 <=2.0.0"},
+		{"1 - 2", ">=1.0.0 <3.0.0-0"},
+		{"1.0 - 2.0", ">=1.0.0 <2.1.0-0"},
+		{"1.0.0", "1.0.0"},
+		{">=*", "*"},
+		{"", "*"},
+		{"*", "*"},
+		{">=1.0.0", ">=1.0.0"},
+		{">1.0.0", ">1.0.0"},
+		{"<=2.0.0", "<=2.0.0"},
+		{"1", ">=1.0.0 <2.0.0-0"},
+		{"<2.0.0", "<2.0.0"},
+		{">= 1.0.0", ">=1.0.0"},
+		{"0.1.20 || 1.2.4", "0.1.20||1.2.4"},
+		{">=0.2.3 || <0.0.1", ">=0.2.3||<0.0.1"},
+		{"||", "*||*"},
+		{"2.x.x", ">=2.0.0 <3.0.0-0"},
+		{"1.2.x", ">=1.2.0 <1.3.0-0"},
+		{"1.2.x || 2.x", ">=1.2.0 <1.3.0-0||>=2.0.0 <3.0.0-0"},
+		{"x", "*"},
+		{"2.3", ">=2.3.0 <2.4.0-0"},
+		{"~2.4", ">=2.4.0 <2.5.0-0"},
+		{"~>3.2.1", ">=3.2.1 <3.3.0-0"},
+		{"~1", ">=1.0.0 <2.0.0-0"},
+		{"~1.0", ">=1.0.0 <1.1.0-0"},
+		{"^0", ">=0.0.0 <1.0.0-0"},
+		{"^1", ">=1.0.0 <2.0.0-0"},
+		{"^1.2", ">=1.2.0 <2.0.0-0"},
+		{"^1.2.3", ">=1.2.3 <2.0.0-0"},
+		{"^1.2.0", ">=1.2.0 <2.0.0-0"},
+		{"^0.0.1", ">=0.0.1 <0.0.2-0"},
+		{"^0.1.2", ">=0.1.2 <0.2.0-0"},
+		{"^0.0.1-beta", ">=0.0.1-beta <0.0.2-0"},
+		{"^0.0", ">=0.0.0 <0.1.0-0"},
+		{"^0.x", ">=0.0.0 <1.0.0-0"},
+		{"~1.2.3-beta", ">=1.2.3-beta <1.3.0-0"},
+		{">1", ">=2.0.0"},
+		{">1.2", ">=1.3.0"},
+		{"<1.2", "<1.2.0-0"},
+		{"<=1", "<2.0.0-0"},
+		{"<=1.2", "<1.3.0-0"},
+		{"<=0.7.x", "<0.8.0-0"},
+		{"=0.7.x", ">=0.7.0 <0.8.0-0"},
+		{">*", "<0.0.0-0"},
+		{"~1.2.1 >=1.2.3", ">=1.2.1 <1.3.0-0 >=1.2.3"},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q) failed: %v", tt.rng, err)
+			continue
+		}
+		if got := r.String(); got != tt.expected {
+			t.Errorf("ParseRange(%q).String() = %q, want %q", tt.rng, got, tt.expected)
+		}
+	}
+}
+
+func TestInvalidRanges(t *testing.T) {
+	for _, rng := range []string{
+		"latest",
+		"next",
+		"blerg",
+		">=1.2.3 blerg",
+		"git+https://github.com/user/repo.git",
+		"file:../pkg",
+		"1.2.3.4",
+		">>1.2.3",
+	} {
+		if ValidRange(rng) {
+			t.Errorf("expected %q to be an invalid range", rng)
+		}
+	}
+}
+
+func TestMaxMinSatisfying(t *testing.T) {
+	versions := []string{"1.2.3", "1.2.4", "1.3.0-beta.1", "1.3.0", "2.0.0", "2.1.0-rc.1", "not-a-version"}
+
+	tests := []struct {
+		rng      string
+		max      string
+		min      string
+		expected bool
+	}{
+		{"^1.2.3", "1.3.0", "1.2.3", true},
+		{"~1.2.3", "1.2.4", "1.2.3", true},
+		{"*", "2.0.0", "1.2.3", true},
+		{">=2.1.0-rc.0", "2.1.0-rc.1", "2.1.0-rc.1", true},
+		{"^3", "", "", false},
+		{"blerg", "", "", false},
+	}
+
+	for _, tt := range tests {
+		max, ok := MaxSatisfying(versions, tt.rng)
+		if ok != tt.expected || max != tt.max {
+			t.Errorf("MaxSatisfying(%q) = %q, %v; want %q, %v", tt.rng, max, ok, tt.max, tt.expected)
+		}
+		min, ok := MinSatisfying(versions, tt.rng)
+		if ok != tt.expected || min != tt.min {
+			t.Errorf("MinSatisfying(%q) = %q, %v; want %q, %v", tt.rng, min, ok, tt.min, tt.expected)
+		}
+	}
+}
+
+func TestMinVersion(t *testing.T) {
+	tests := []struct {
+		rng      string
+		expected string
+	}{
+		{"*", "0.0.0"},
+		{"^1.2.3", "1.2.3"},
+		{"~1.2", "1.2.0"},
+		{">1.2.3", "1.2.4"},
+		{">1.2", "1.3.0"},
+		{">1.2.3-beta", "1.2.3-beta.0"},
+		{"<2.0.0", "0.0.0"},
+		{"^2 || ^1", "1.0.0"},
+		{"1.x", "1.0.0"},
+		{">=4.0.0 <4.0.0", ""},
+	}
+
+	for _, tt := range tests {
+		v, ok := MustParseRange(tt.rng).MinVersion()
+		got := ""
+		if ok {
+			got = v.String()
+		}
+		if got != tt.expected {
+			t.Errorf("MinVersion(%q) = %q, want %q", tt.rng, got, tt.expected)
+		}
+	}
+}
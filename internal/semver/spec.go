@@ -0,0 +1,94 @@
+package semver
+
+import "strings"
+
+// Dependency protocols understood by ParseDependency
+const (
+	ProtocolNPM       = "npm"
+	ProtocolWorkspace = "workspace"
+)
+
+// Dependency is a package.json dependency specifier split into its parts
+type Dependency struct {
+	Name     string // package the specifier resolves to; differs from the key for npm: aliases
+	Protocol string // ProtocolNPM or ProtocolWorkspace when the specifier used one
+	Range    string // version part of the specifier, e.g. "^1.2.3", "*" or "latest"
+}
+
+// ParseDependency splits a dependency specifier such as "^1.2.3",
+// "npm:string-width@^4.2.0" or "workspace:^" declared under name
+func ParseDependency(name string, spec string) Dependency {
+	spec = strings.TrimSpace(spec)
+	dep := Dependency{Name: name, Range: spec}
+
+	switch {
+	case strings.HasPrefix(spec, "npm:"):
+		dep.Protocol = ProtocolNPM
+		dep.Name, dep.Range = splitAlias(strings.TrimPrefix(spec, "npm:"))
+		if dep.Name == "" {
+			dep.Name = name
+		}
+	case strings.HasPrefix(spec, "workspace:"):
+		dep.Protocol = ProtocolWorkspace
+		dep.Range = strings.TrimPrefix(spec, "workspace:")
+		// workspace:other@^1.0.0 aliases another workspace package
+		if strings.LastIndex(dep.Range, "@") > 0 {
+			dep.Name, dep.Range = splitAlias(dep.Range)
+		}
+	}
+
+	if dep.Range == "" {
+		dep.Range = "*"
+	}
+	return dep
+}
+
+// splitAlias splits "name@range" or "@scope/name@range"; a bare range returns an empty name
+func splitAlias(value string) (string, string) {
+	at := strings.LastIndex(value, "@")
+	if at <= 0 {
+		if strings.HasPrefix(value, "@") || !ValidRange(value) {
+			return value, ""
+		}
+		return "", value
+	}
+	return value[:at], value[at+1:]
+}
+
+// IsWorkspace reports whether the dependency uses the workspace: protocol
+func (d Dependency) IsWorkspace() bool {
+	return d.Protocol == ProtocolWorkspace
+}
+
+// SemverRange parses the dependency's range. The workspace shorthands "^" and
+// "~" match any version, like "*". Dist-tags, URLs, git and file specifiers
+// are not ranges and return false.
+func (d Dependency) SemverRange() (Range, bool) {
+	rng := d.Range
+	if d.IsWorkspace() && (rng == "^" || rng == "~") {
+		rng = "*"
+	}
+	r, err := ParseRange(rng)
+	if err != nil {
+		return Range{}, false
+	}
+	return r, true
+}
+
+// PublishedRange returns the range a workspace: dependency is replaced with
+// when the depending package is published and the dependency is at version:
+// "workspace:*" becomes "1.2.3", "workspace:^" becomes "^1.2.3" and
+// "workspace:~" becomes "~1.2.3". Other ranges are used as written.
+func (d Dependency) PublishedRange(version string) string {
+	if !d.IsWorkspace() {
+		return d.Range
+	}
+	switch d.Range {
+	case "*":
+		return version
+	case "^", "~":
+		return d.Range + version
+	default:
+		return d.Range
+	}
+}
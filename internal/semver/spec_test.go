@@ -0,0 +1,80 @@
+package semver
+
+import "testing"
+
+func TestParseDependency(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected Dependency
+	}{
+		{"react", "^18.2.0", Dependency{Name: "react", Range: "^18.2.0"}},
+		{"react", "", Dependency{Name: "react", Range: "*"}},
+		{"react", "latest", Dependency{Name: "react", Range: "latest"}},
+		{"string-width-cjs", "npm:string-width@^4.2.0", Dependency{Name: "string-width", Protocol: ProtocolNPM, Range: "^4.2.0"}},
+		{"types", "npm:@types/node@20.x", Dependency{Name: "@types/node", Protocol: ProtocolNPM, Range: "20.x"}},
+		{"lodash-alias", "npm:lodash", Dependency{Name: "lodash", Protocol: ProtocolNPM, Range: "*"}},
+		{"scoped-alias", "npm:@scope/pkg", Dependency{Name: "@scope/pkg", Protocol: ProtocolNPM, Range: "*"}},
+		{"lib", "workspace:*", Dependency{Name: "lib", Protocol: ProtocolWorkspace, Range: "*"}},
+		{"lib", "workspace:^", Dependency{Name: "lib", Protocol: ProtocolWorkspace, Range: "^"}},
+		{"lib", "workspace:^1.2.0", Dependency{Name: "lib", Protocol: ProtocolWorkspace, Range: "^1.2.0"}},
+		{"lib", "workspace:../lib", Dependency{Name: "lib", Protocol: ProtocolWorkspace, Range: "../lib"}},
+		{"alias", "workspace:@scope/lib@*", Dependency{Name: "@scope/lib", Protocol: ProtocolWorkspace, Range: "*"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseDependency(tt.name, tt.spec); got != tt.expected {
+			t.Errorf("ParseDependency(%q, %q) = %+v, want %+v", tt.name, tt.spec, got, tt.expected)
+		}
+	}
+}
+
+func TestDependencySemverRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		version  string
+		ok       bool
+		contains bool
+	}{
+		{"^18.2.0", "18.3.1", true, true},
+		{"npm:string-width@^4.2.0", "4.2.3", true, true},
+		{"npm:string-width@^4.2.0", "5.0.0", true, false},
+		{"workspace:^", "3.0.0", true, true},
+		{"workspace:~", "0.1.0", true, true},
+		{"workspace:^1.2.0", "2.0.0", true, false},
+		{"latest", "1.0.0", false, false},
+		{"github:user/repo", "1.0.0", false, false},
+		{"file:../pkg", "1.0.0", false, false},
+		{"workspace:../lib", "1.0.0", false, false},
+	}
+
+	for _, tt := range tests {
+		r, ok := ParseDependency("dep", tt.spec).SemverRange()
+		if ok != tt.ok {
+			t.Errorf("SemverRange(%q) ok = %v, want %v", tt.spec, ok, tt.ok)
+			continue
+		}
+		if ok && r.Contains(MustParse(tt.version)) != tt.contains {
+			t.Errorf("SemverRange(%q) contains %s = %v, want %v", tt.spec, tt.version, !tt.contains, tt.contains)
+		}
+	}
+}
+
+func TestPublishedRange(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"workspace:*", "1.4.0"},
+		{"workspace:^", "^1.4.0"},
+		{"workspace:~", "~1.4.0"},
+		{"workspace:^1.0.0", "^1.0.0"},
+		{"^2.0.0", "^2.0.0"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseDependency("dep", tt.spec).PublishedRange("1.4.0"); got != tt.expected {
+			t.Errorf("PublishedRange(%q) = %q, want %q", tt.spec, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version as defined by semver.org
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string // dot-separated prerelease identifiers
+	Build      []string // dot-separated build metadata, ignored for precedence
+}
+
+var versionPattern = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)\.([0-9]+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?$`)
+
+// Parse parses a full version such as "1.2.3", "v1.2.3-beta.1" or "=1.2.3+build".
+// Leading "=" and "v" are accepted like node-semver does; partial versions are not.
+func Parse(value string) (Version, error) {
+	trimmed := strings.TrimSpace(value)
+	trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "="))
+
+	match := versionPattern.FindStringSubmatch(trimmed)
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid version %q", value)
+	}
+
+	var v Version
+	var err error
+	for i, field := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if *field, err = parseNumber(match[i+1]); err != nil {
+			return Version{}, fmt.Errorf("invalid version %q: %w", value, err)
+		}
+	}
+
+	if match[4] != "" {
+		v.Prerelease = strings.Split(match[4], ".")
+		for _, id := range v.Prerelease {
+			if isNumeric(id) && len(id) > 1 && id[0] == '0' {
+				return Version{}, fmt.Errorf("invalid version %q: prerelease identifier %q has a leading zero", value, id)
+			}
+		}
+	}
+	if match[5] != "" {
+		v.Build = strings.Split(match[5], ".")
+	}
+
+	return v, nil
+}
+
+// MustParse is like Parse but panics on invalid input
+func MustParse(value string) Version {
+	v, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Valid reports whether value is a full version
+func Valid(value string) bool {
+	_, err := Parse(value)
+	return err == nil
+}
+
+func parseNumber(value string) (uint64, error) {
+	if len(value) > 1 && value[0] == '0' {
+		return 0, fmt.Errorf("%q has a leading zero", value)
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// String formats the version without a "v" prefix
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version has prerelease identifiers
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 when v has lower, equal or higher precedence than o.
+// Build metadata is ignored.
+func (v Version) Compare(o Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// LessThan reports whether v has lower precedence than o
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Equal reports whether v and o have the same precedence
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// Compare compares two version strings. Invalid versions sort before valid
+// ones and are compared lexically among themselves.
+func Compare(a string, b string) int {
+	av, aErr := Parse(a)
+	bv, bErr := Parse(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return av.Compare(bv)
+	case aErr == nil:
+		return 1
+	case bErr == nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// Sort sorts versions in ascending precedence
+func Sort(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LessThan(versions[j])
+	})
+}
+
+// comparePrerelease applies semver.org precedence rules: a version without
+// prerelease identifiers is higher, numeric identifiers compare numerically and
+// are lower than alphanumeric ones, and a shorter set of identifiers is lower.
+func comparePrerelease(a []string, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(a), len(b))
+}
+
+func compareIdentifier(a string, b string) int {
+	aNumeric := isNumeric(a)
+	bNumeric := isNumeric(b)
+	switch {
+	case aNumeric && bNumeric:
+		if len(a) != len(b) {
+			return compareInt(len(a), len(b))
+		}
+		return strings.Compare(a, b)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isNumeric(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func compareUint(a uint64, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+var coercePattern = regexp.MustCompile(`(^|[^0-9])([0-9]{1,16})(?:\.([0-9]{1,16}))?(?:\.([0-9]{1,16}))?`)
+
+// Coerce extracts the first version-like number sequence from value, filling
+// missing parts with zero: "v2" is 2.0.0 and "node 18.19" is 18.19.0.
+// Prerelease and build information is dropped, like node-semver's coerce.
+func Coerce(value string) (Version, bool) {
+	match := coercePattern.FindStringSubmatch(value)
+	if match == nil {
+		return Version{}, false
+	}
+
+	var v Version
+	for i, field := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(match[i+2], 10, 64)
+		if err != nil {
+			return Version{}, false
+		}
+		*field = n
+	}
+	return v, true
+}
+
+var extractPattern = regexp.MustCompile(`[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?`)
+
+// Extract finds the first full version, including prerelease identifiers, in
+// free-form text such as the output of "pnpm --version"
+func Extract(value string) (Version, bool) {
+	for _, candidate := range extractPattern.FindAllString(value, -1) {
+		if v, err := Parse(candidate); err == nil {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// Diff names the most significant difference between two versions: "major",
+// "minor", "patch", "premajor", "preminor", "prepatch", "prerelease", or "" when
+// they are equal
+func Diff(a Version, b Version) string {
+	if a.Compare(b) == 0 {
+		return ""
+	}
+
+	prefix := ""
+	if a.IsPrerelease() || b.IsPrerelease() {
+		prefix = "pre"
+	}
+
+	switch {
+	case a.Major != b.Major:
+		return prefix + "major"
+	case a.Minor != b.Minor:
+		return prefix + "minor"
+	case a.Patch != b.Patch:
+		return prefix + "patch"
+	default:
+		return "prerelease"
+	}
+}
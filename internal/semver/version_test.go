@@ -0,0 +1,207 @@
+package semver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v1.2.3", "1.2.3", true},
+		{"=1.2.3", "1.2.3", true},
+		{" = v1.2.3 ", "1.2.3", true},
+		{"1.2.3-beta.1", "1.2.3-beta.1", true},
+		{"1.2.3-0", "1.2.3-0", true},
+		{"1.2.3+build.5", "1.2.3+build.5", true},
+		{"1.2.3-rc.1+build.5", "1.2.3-rc.1+build.5", true},
+		{"1.2.3-alpha-1", "1.2.3-alpha-1", true},
+		{"10.20.30", "10.20.30", true},
+		{"1.2", "", false},
+		{"1", "", false},
+		{"1.2.x", "", false},
+		{"01.2.3", "", false},
+		{"1.02.3", "", false},
+		{"1.2.03", "", false},
+		{"1.2.3-01", "", false},
+		{"1.2.3-beta..1", "", false},
+		{"1.2.3.4", "", false},
+		{"a.b.c", "", false},
+		{"", "", false},
+		{"^1.2.3", "", false},
+		{"99999999999999999999.0.0", "", false},
+	}
+
+	for _, tt := range tests {
+		v, err := Parse(tt.input)
+		if (err == nil) != tt.valid {
+			t.Errorf("Parse(%q) error = %v, want valid=%v", tt.input, err, tt.valid)
+			continue
+		}
+		if tt.valid && v.String() != tt.expected {
+			t.Errorf("Parse(%q) = %q, want %q", tt.input, v.String(), tt.expected)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Each pair is ordered: the first version has lower precedence
+	ordered := [][2]string{
+		{"0.0.0", "0.0.1"},
+		{"0.0.9", "0.0.10"},
+		{"0.9.0", "0.10.0"},
+		{"1.2.3", "1.2.4"},
+		{"1.2.3", "1.3.0"},
+		{"1.2.3", "2.0.0"},
+		{"9.0.0", "10.0.0"},
+		{"1.0.0-alpha", "1.0.0"},
+		{"1.0.0-alpha", "1.0.0-alpha.1"},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta"},
+		{"1.0.0-alpha.beta", "1.0.0-beta"},
+		{"1.0.0-beta", "1.0.0-beta.2"},
+		{"1.0.0-beta.2", "1.0.0-beta.11"},
+		{"1.0.0-beta.11", "1.0.0-rc.1"},
+		{"1.0.0-rc.1", "1.0.0"},
+		{"1.0.0-0", "1.0.0-alpha"},
+		{"1.0.0-9", "1.0.0-10"},
+		{"1.0.0-1", "1.0.0-a"},
+		{"1.0.0-a.1", "1.0.0-a.1.1"},
+		{"11.0.0-rc.1", "11.0.0"},
+		{"1.2.3-r100", "1.2.3-r2"}, // alphanumeric identifiers compare lexically
+		{"0.0.0-foo", "0.0.0"},
+	}
+
+	for _, pair := range ordered {
+		a := MustParse(pair[0])
+		b := MustParse(pair[1])
+		if got := a.Compare(b); got != -1 {
+			t.Errorf("Compare(%s, %s) = %d, want -1", pair[0], pair[1], got)
+		}
+		if got := b.Compare(a); got != 1 {
+			t.Errorf("Compare(%s, %s) = %d, want 1", pair[1], pair[0], got)
+		}
+	}
+
+	equal := [][2]string{
+		{"1.2.3", "v1.2.3"},
+		{"1.2.3", "=1.2.3"},
+		{"1.2.3+build.1", "1.2.3+build.2"},
+		{"1.2.3-beta.4+a", "1.2.3-beta.4"},
+	}
+	for _, pair := range equal {
+		if got := MustParse(pair[0]).Compare(MustParse(pair[1])); got != 0 {
+			t.Errorf("Compare(%s, %s) = %d, want 0", pair[0], pair[1], got)
+		}
+	}
+}
+
+func TestCompareStrings(t *testing.T) {
+	if Compare("1.10.0", "1.9.0") != 1 {
+		t.Error("expected 1.10.0 > 1.9.0")
+	}
+	if Compare("not-a-version", "1.0.0") != -1 {
+		t.Error("expected invalid versions to sort first")
+	}
+}
+
+func TestSort(t *testing.T) {
+	versions := []Version{
+		MustParse("1.10.0"),
+		MustParse("1.2.0"),
+		MustParse("1.2.0-rc.1"),
+		MustParse("0.9.0"),
+	}
+	Sort(versions)
+
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.String())
+	}
+	expected := []string{"0.9.0", "1.2.0-rc.1", "1.2.0", "1.10.0"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Sort = %v, want %v", got, expected)
+	}
+}
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v2", "2.0.0", true},
+		{"v2.1", "2.1.0", true},
+		{"node 18.19", "18.19.0", true},
+		{"1.2.3-beta.1", "1.2.3", true},
+		{"1.2.3.4", "1.2.3", true},
+		{"version 42.6.7.9.3-alpha", "42.6.7", true},
+		{">=1.2.3", "1.2.3", true},
+		{"a1b2", "1.0.0", true},
+		{"no version", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		v, ok := Coerce(tt.input)
+		if ok != tt.ok {
+			t.Errorf("Coerce(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && v.String() != tt.expected {
+			t.Errorf("Coerce(%q) = %q, want %q", tt.input, v.String(), tt.expected)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{"10.20.0\n", "10.20.0", true},
+		{"v11.0.0-rc.1", "11.0.0-rc.1", true},
+		{"Yarn version 4.10.3 (node v20.1.0)", "4.10.3", true},
+		{"1.3.0+abc123", "1.3.0+abc123", true},
+		{"unknown", "", false},
+	}
+
+	for _, tt := range tests {
+		v, ok := Extract(tt.input)
+		if ok != tt.ok {
+			t.Errorf("Extract(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			continue
+		}
+		if ok && v.String() != tt.expected {
+			t.Errorf("Extract(%q) = %q, want %q", tt.input, v.String(), tt.expected)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"1.2.3", "1.2.3", ""},
+		{"1.2.3", "2.0.0", "major"},
+		{"1.2.3", "1.3.0", "minor"},
+		{"1.2.3", "1.2.4", "patch"},
+		{"1.2.3", "2.0.0-rc.1", "premajor"},
+		{"1.2.3-beta.1", "1.3.0", "preminor"},
+		{"1.2.3", "1.2.4-0", "prepatch"},
+		{"1.2.3-beta.1", "1.2.3-beta.2", "prerelease"},
+		{"1.2.3+a", "1.2.3+b", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Diff(MustParse(tt.a), MustParse(tt.b)); got != tt.expected {
+			t.Errorf("Diff(%s, %s) = %q, want %q", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// builtin runs a built-in command and returns its exit status
+type builtin func(r *Runner, args []string, io stdio) int
+
+// builtins are the commands the built-in shell runs itself so scripts using
+// them work the same everywhere, including Windows
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"cd":     builtinCd,
+		"cp":     builtinCp,
+		"echo":   builtinEcho,
+		"exit":   builtinExit,
+		"export": builtinExport,
+		"false":  func(*Runner, []string, stdio) int { return 1 },
+		"mkdir":  builtinMkdir,
+		"mv":     builtinMv,
+		"pwd":    builtinPwd,
+		"rm":     builtinRm,
+		"true":   func(*Runner, []string, stdio) int { return 0 },
+		"unset":  builtinUnset,
+	}
+}
+
+// flags splits leading single-letter flags such as -rf from args. It stops
+// at -- or the first argument not starting with -.
+func flags(name string, args []string, allowed string, io stdio) (map[rune]bool, []string, bool) {
+	set := map[rune]bool{}
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "-" {
+		arg := args[0]
+		args = args[1:]
+		if arg == "--" {
+			break
+		}
+		for _, c := range arg[1:] {
+			if !strings.ContainsRune(allowed, c) {
+				fmt.Fprintf(io.err, "%s: unsupported option -%c\n", name, c)
+				return nil, nil, false
+			}
+			set[c] = true
+		}
+	}
+	return set, args, true
+}
+
+func failf(io stdio, format string, args ...interface{}) int {
+	fmt.Fprintf(io.err, format+"\n", args...)
+	return 1
+}
+
+// errorText returns err without the operation and path os errors add
+func errorText(err error) string {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	if linkErr, ok := err.(*os.LinkError); ok {
+		err = linkErr.Err
+	}
+	return err.Error()
+}
+
+func builtinEcho(r *Runner, args []string, io stdio) int {
+	newline := true
+	if len(args) > 0 && args[0] == "-n" {
+		newline = false
+		args = args[1:]
+	}
+	text := strings.Join(args, " ")
+	if newline {
+		text += "\n"
+	}
+	if _, err := fmt.Fprint(io.out, text); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func builtinPwd(r *Runner, args []string, io stdio) int {
+	fmt.Fprintln(io.out, r.dir)
+	return 0
+}
+
+func builtinCd(r *Runner, args []string, io stdio) int {
+	if len(args) > 1 {
+		return failf(io, "cd: too many arguments")
+	}
+	target, _ := r.lookup("HOME")
+	if len(args) == 1 {
+		target = args[0]
+	}
+	dir := r.abs(target)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return failf(io, "cd: %s: %s", target, errorText(err))
+	}
+	if !info.IsDir() {
+		return failf(io, "cd: %s: not a directory", target)
+	}
+	r.dir = filepath.Clean(dir)
+	r.setVar("PWD", r.dir)
+	return 0
+}
+
+func builtinExit(r *Runner, args []string, io stdio) int {
+	status := r.status
+	if len(args) > 0 {
+		code, err := strconv.Atoi(args[0])
+		if err != nil {
+			return failf(io, "exit: %s: numeric argument required", args[0])
+		}
+		status = code & 0xff
+	}
+	r.exited = true
+	return status
+}
+
+func builtinExport(r *Runner, args []string, io stdio) int {
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !validName(name) {
+			return failf(io, "export: %s: not a valid identifier", arg)
+		}
+		if hasValue {
+			r.setVar(name, value)
+		}
+		r.exported[name] = true
+	}
+	return 0
+}
+
+func builtinUnset(r *Runner, args []string, io stdio) int {
+	for _, name := range args {
+		delete(r.vars, name)
+		delete(r.exported, name)
+	}
+	return 0
+}
+
+func builtinMkdir(r *Runner, args []string, io stdio) int {
+	opts, args, ok := flags("mkdir", args, "p", io)
+	if !ok {
+		return 1
+	}
+	if len(args) == 0 {
+		return failf(io, "mkdir: missing operand")
+	}
+
+	status := 0
+	for _, dir := range args {
+		var err error
+		if opts['p'] {
+			err = os.MkdirAll(r.abs(dir), 0755)
+		} else {
+			err = os.Mkdir(r.abs(dir), 0755)
+		}
+		if err != nil {
+			status = failf(io, "mkdir: %s: %s", dir, errorText(err))
+		}
+	}
+	return status
+}
+
+func builtinRm(r *Runner, args []string, io stdio) int {
+	opts, args, ok := flags("rm", args, "rRf", io)
+	if !ok {
+		return 1
+	}
+	recursive, force := opts['r'] || opts['R'], opts['f']
+	if len(args) == 0 && !force {
+		return failf(io, "rm: missing operand")
+	}
+
+	status := 0
+	for _, name := range args {
+		path := r.abs(name)
+		info, err := os.Lstat(path)
+		if err != nil {
+			if !force || !os.IsNotExist(err) {
+				status = failf(io, "rm: %s: %s", name, errorText(err))
+			}
+			continue
+		}
+		if info.IsDir() && !recursive {
+			status = failf(io, "rm: %s: is a directory", name)
+			continue
+		}
+		if recursive {
+			err = os.RemoveAll(path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			status = failf(io, "rm: %s: %s", name, errorText(err))
+		}
+	}
+	return status
+}
+
+func builtinCp(r *Runner, args []string, io stdio) int {
+	opts, args, ok := flags("cp", args, "rRpf", io)
+	if !ok {
+		return 1
+	}
+	if len(args) < 2 {
+		return failf(io, "cp: missing operand")
+	}
+	recursive := opts['r'] || opts['R']
+
+	sources, dest := args[:len(args)-1], args[len(args)-1]
+	destInfo, err := os.Stat(r.abs(dest))
+	intoDir := err == nil && destInfo.IsDir()
+	if len(sources) > 1 && !intoDir {
+		return failf(io, "cp: %s: not a directory", dest)
+	}
+
+	status := 0
+	for _, src := range sources {
+		target := r.abs(dest)
+		if intoDir {
+			target = filepath.Join(target, filepath.Base(src))
+		}
+		info, err := os.Stat(r.abs(src))
+		if err != nil {
+			status = failf(io, "cp: %s: %s", src, errorText(err))
+			continue
+		}
+		if info.IsDir() {
+			if !recursive {
+				status = failf(io, "cp: %s: is a directory (not copied)", src)
+				continue
+			}
+			err = copyDir(r.abs(src), target)
+		} else {
+			err = copyFile(r.abs(src), target, info.Mode())
+		}
+		if err != nil {
+			status = failf(io, "cp: %s: %s", src, errorText(err))
+		}
+	}
+	return status
+}
+
+func builtinMv(r *Runner, args []string, io stdio) int {
+	_, args, ok := flags("mv", args, "f", io)
+	if !ok {
+		return 1
+	}
+	if len(args) < 2 {
+		return failf(io, "mv: missing operand")
+	}
+
+	sources, dest := args[:len(args)-1], args[len(args)-1]
+	destInfo, err := os.Stat(r.abs(dest))
+	intoDir := err == nil && destInfo.IsDir()
+	if len(sources) > 1 && !intoDir {
+		return failf(io, "mv: %s: not a directory", dest)
+	}
+
+	status := 0
+	for _, src := range sources {
+		target := r.abs(dest)
+		if intoDir {
+			target = filepath.Join(target, filepath.Base(src))
+		}
+		if err := os.Rename(r.abs(src), target); err != nil {
+			status = failf(io, "mv: %s: %s", src, errorText(err))
+		}
+	}
+	return status
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// copyDir copies the directory tree at src to dest
+func copyDir(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode())
+		}
+	})
+}
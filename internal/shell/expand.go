@@ -0,0 +1,245 @@
+package shell
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// segment is a piece of an expanded field; quoted text is never globbed
+type segment struct {
+	text   string
+	quoted bool
+}
+
+// field is one argument being built from a word
+type field struct {
+	segments []segment
+	set      bool // a quoted part makes the field exist even when empty
+}
+
+func (f *field) add(text string, quoted bool) {
+	f.segments = append(f.segments, segment{text: text, quoted: quoted})
+	if quoted || text != "" {
+		f.set = true
+	}
+}
+
+func (f *field) String() string {
+	var b strings.Builder
+	for _, s := range f.segments {
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+// expandFields expands a word into arguments: parameters and command
+// substitutions are expanded, unquoted results split on whitespace and
+// unquoted glob patterns matched against the filesystem
+func (r *Runner) expandFields(w word, io stdio) ([]string, error) {
+	fields := []*field{{}}
+	for _, p := range w {
+		text, err := r.expandPart(p, io)
+		if err != nil {
+			return nil, err
+		}
+		if p.kind == partLit || p.kind == partTilde || p.quoted {
+			fields[len(fields)-1].add(text, p.quoted || p.kind != partLit)
+			continue
+		}
+
+		// Unquoted expansions are split into separate fields
+		words := strings.Fields(text)
+		if len(words) == 0 {
+			continue
+		}
+		if strings.TrimLeft(text[:1], " \t\n") == "" && fields[len(fields)-1].set {
+			fields = append(fields, &field{})
+		}
+		for i, s := range words {
+			if i > 0 {
+				fields = append(fields, &field{})
+			}
+			fields[len(fields)-1].add(s, false)
+		}
+		if last := text[len(text)-1]; last == ' ' || last == '\t' || last == '\n' {
+			fields = append(fields, &field{})
+		}
+	}
+
+	var args []string
+	for _, f := range fields {
+		if !f.set {
+			continue
+		}
+		if matches := r.glob(f); len(matches) > 0 {
+			args = append(args, matches...)
+			continue
+		}
+		args = append(args, f.String())
+	}
+	return args, nil
+}
+
+// expandString expands a word into a single string, without splitting or
+// globbing, as for assignments and redirection targets
+func (r *Runner) expandString(w word, io stdio) (string, error) {
+	var b strings.Builder
+	for _, p := range w {
+		text, err := r.expandPart(p, io)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(text)
+	}
+	return b.String(), nil
+}
+
+func (r *Runner) expandPart(p part, io stdio) (string, error) {
+	switch p.kind {
+	case partTilde:
+		if home, ok := r.lookup("HOME"); ok {
+			return home, nil
+		}
+		home, _ := os.UserHomeDir()
+		return home, nil
+	case partParam:
+		if p.text == "?" {
+			return strconv.Itoa(r.status), nil
+		}
+		value, _ := r.lookup(p.text)
+		if value == "" && p.hasDef {
+			return r.expandString(p.def, io)
+		}
+		return value, nil
+	case partSubst:
+		var out bytes.Buffer
+		sub := r.subshell()
+		sub.runList(p.list, stdio{in: nil, out: &out, err: io.err})
+		r.status = sub.status
+		return strings.TrimRight(out.String(), "\n"), nil
+	default:
+		return p.text, nil
+	}
+}
+
+// glob returns the files matching the field's unquoted pattern characters,
+// sorted, or nothing when it has no pattern or matches nothing. Like sh,
+// * and ? don't match a leading dot unless the pattern has one.
+func (r *Runner) glob(f *field) []string {
+	var pattern strings.Builder
+	meta := false
+	for _, s := range f.segments {
+		if s.quoted {
+			pattern.WriteString(escapeGlob(s.text))
+			continue
+		}
+		if strings.ContainsAny(s.text, "*?[") {
+			meta = true
+		}
+		pattern.WriteString(s.text)
+	}
+	if !meta {
+		return nil
+	}
+
+	pat := pattern.String()
+	base := ""
+	if strings.HasPrefix(pat, "/") {
+		base = "/"
+	}
+	matches := []string{base}
+	for _, seg := range strings.Split(pat, "/") {
+		if seg == "" {
+			continue
+		}
+		var next []string
+		for _, m := range matches {
+			if !hasGlobMeta(seg) {
+				candidate := m + unescapeGlob(seg)
+				if _, err := os.Lstat(r.abs(candidate)); err == nil {
+					next = append(next, candidate+"/")
+				}
+				continue
+			}
+			entries, err := os.ReadDir(r.abs(m))
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				name := e.Name()
+				if strings.HasPrefix(name, ".") && !strings.HasPrefix(seg, ".") {
+					continue
+				}
+				if ok, _ := path.Match(seg, name); ok {
+					next = append(next, m+name+"/")
+				}
+			}
+		}
+		matches = next
+	}
+
+	results := make([]string, 0, len(matches))
+	for _, m := range matches {
+		m = strings.TrimSuffix(m, "/")
+		if strings.HasSuffix(pat, "/") {
+			if info, err := os.Stat(r.abs(m)); err != nil || !info.IsDir() {
+				continue
+			}
+			m += "/"
+		}
+		results = append(results, m)
+	}
+	sort.Strings(results)
+	return results
+}
+
+// abs resolves a path against the runner's directory
+func (r *Runner) abs(name string) string {
+	if name == "" {
+		return r.dir
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(r.dir, name)
+}
+
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func unescapeGlob(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// hasGlobMeta reports whether s has unescaped pattern characters
+func hasGlobMeta(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
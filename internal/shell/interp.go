@@ -0,0 +1,478 @@
+package shell
+
+import (
+	stdcontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AkaraChen/gnpm/internal/process"
+)
+
+// Options configure a built-in shell run
+type Options struct {
+	Dir    string
+	Env    []string // KEY=value pairs; later entries win
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Context stops the script when done: no further commands start and
+	// running ones are stopped with their process groups
+	Context stdcontext.Context
+}
+
+// ExitError reports a script that finished with a non-zero status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the script's exit status
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// Run runs script with the built-in shell: a portable subset of POSIX sh
+// that behaves the same on every platform. It supports variable
+// assignments and expansion, quoting, &&, ||, ;, pipes, ( subshells ),
+// $(...), redirections, globbing and the builtins cd, echo, rm, mkdir, cp,
+// mv, pwd, export, unset, true, false and exit. A non-zero exit status is
+// returned as an *ExitError, and a cancelled context's error when
+// opts.Context stopped the script.
+func Run(script string, opts Options) error {
+	l, err := parse(script)
+	if err != nil {
+		return err
+	}
+
+	r := &Runner{dir: opts.Dir, vars: map[string]string{}, exported: map[string]bool{}, ctx: opts.Context}
+	if r.dir == "" {
+		if r.dir, err = os.Getwd(); err != nil {
+			return err
+		}
+	}
+	for _, kv := range opts.Env {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			r.vars[key] = value
+			r.exported[key] = true
+		}
+	}
+
+	out, errOut := opts.Stdout, opts.Stderr
+	if out == nil {
+		out = io.Discard
+	}
+	if errOut == nil {
+		errOut = io.Discard
+	}
+	r.runList(l, stdio{in: opts.Stdin, out: out, err: errOut})
+	if r.ctx != nil && r.ctx.Err() != nil {
+		return r.ctx.Err()
+	}
+	if r.status != 0 {
+		return &ExitError{Code: r.status}
+	}
+	return nil
+}
+
+// Runner holds the state of a running script
+type Runner struct {
+	dir      string
+	vars     map[string]string
+	exported map[string]bool
+	status   int
+	exited   bool
+	ctx      stdcontext.Context // nil when the script can't be cancelled
+}
+
+type stdio struct {
+	in  io.Reader
+	out io.Writer
+	err io.Writer
+}
+
+// subshell copies the runner so changes don't leak back, as in ( ... ),
+// pipelines and $(...)
+func (r *Runner) subshell() *Runner {
+	sub := &Runner{dir: r.dir, vars: map[string]string{}, exported: map[string]bool{}, status: r.status, ctx: r.ctx}
+	for k, v := range r.vars {
+		sub.vars[k] = v
+	}
+	for k := range r.exported {
+		sub.exported[k] = true
+	}
+	return sub
+}
+
+// cancelled reports whether the script's context is done, which ends it
+// with status 130 as if interrupted
+func (r *Runner) cancelled() bool {
+	if r.ctx == nil || r.ctx.Err() == nil {
+		return false
+	}
+	r.status = 130
+	return true
+}
+
+func (r *Runner) lookup(name string) (string, bool) {
+	value, ok := r.vars[name]
+	return value, ok
+}
+
+func (r *Runner) setVar(name, value string) {
+	r.vars[name] = value
+}
+
+// environ returns the exported variables plus the command's own
+// assignments
+func (r *Runner) environ(assigns map[string]string) []string {
+	env := map[string]string{}
+	for k := range r.exported {
+		if v, ok := r.vars[k]; ok {
+			env[k] = v
+		}
+	}
+	for k, v := range assigns {
+		env[k] = v
+	}
+
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (r *Runner) runList(l *list, io stdio) {
+	for _, item := range l.items {
+		if r.exited || r.cancelled() {
+			return
+		}
+		r.runAndOr(item, io)
+	}
+}
+
+func (r *Runner) runAndOr(ao *andOr, io stdio) {
+	r.status = r.runPipeline(ao.first, io)
+	for _, part := range ao.rest {
+		if r.exited || r.cancelled() {
+			return
+		}
+		if (part.op == "&&") != (r.status == 0) {
+			continue
+		}
+		r.status = r.runPipeline(part.pipe, io)
+	}
+}
+
+func (r *Runner) runPipeline(pipe *pipeline, io stdio) int {
+	status := 0
+	if len(pipe.cmds) == 1 {
+		status = r.runCommand(pipe.cmds[0], io)
+	} else {
+		status = r.runPiped(pipe.cmds, io)
+	}
+
+	if pipe.negate {
+		if status == 0 {
+			return 1
+		}
+		return 0
+	}
+	return status
+}
+
+// runPiped runs commands concurrently, each in a subshell, connecting
+// each one's stdout to the next one's stdin. The status is the last
+// command's.
+func (r *Runner) runPiped(cmds []*command, io stdio) int {
+	statuses := make([]int, len(cmds))
+	var wg sync.WaitGroup
+	in := io.in
+	for i, cmd := range cmds {
+		cmdIO := stdio{in: in, out: io.out, err: io.err}
+		var pr, pw *os.File
+		if i < len(cmds)-1 {
+			var err error
+			pr, pw, err = os.Pipe()
+			if err != nil {
+				fmt.Fprintf(io.err, "gnpm: %v\n", err)
+				return 1
+			}
+			cmdIO.out = pw
+			in = pr
+		}
+
+		wg.Add(1)
+		go func(i int, cmd *command, cmdIO stdio, pw *os.File) {
+			defer wg.Done()
+			statuses[i] = r.subshell().runCommand(cmd, cmdIO)
+			if pw != nil {
+				pw.Close()
+			}
+			// Let the writer before us stop once we are done reading
+			if reader, ok := cmdIO.in.(*os.File); ok && i > 0 {
+				reader.Close()
+			}
+		}(i, cmd, cmdIO, pw)
+	}
+	wg.Wait()
+	return statuses[len(statuses)-1]
+}
+
+func (r *Runner) runCommand(cmd *command, io stdio) int {
+	io, closeAll, err := r.redirect(cmd.redirs, io)
+	defer closeAll()
+	if err != nil {
+		fmt.Fprintf(io.err, "gnpm: %v\n", err)
+		return 1
+	}
+
+	if cmd.group != nil {
+		sub := r.subshell()
+		sub.runList(cmd.group, io)
+		return sub.status
+	}
+
+	assigns := map[string]string{}
+	var order []string
+	for _, a := range cmd.assigns {
+		value, err := r.expandString(a.value, io)
+		if err != nil {
+			fmt.Fprintf(io.err, "gnpm: %v\n", err)
+			return 1
+		}
+		assigns[a.name] = value
+		order = append(order, a.name)
+	}
+
+	var args []string
+	for _, w := range cmd.args {
+		fields, err := r.expandFields(w, io)
+		if err != nil {
+			fmt.Fprintf(io.err, "gnpm: %v\n", err)
+			return 1
+		}
+		args = append(args, fields...)
+	}
+
+	// Assignments alone set shell variables
+	if len(args) == 0 {
+		for _, name := range order {
+			r.setVar(name, assigns[name])
+		}
+		if hasSubst(cmd) {
+			return r.status
+		}
+		return 0
+	}
+
+	if builtin, ok := builtins[args[0]]; ok {
+		return builtin(r, args[1:], io)
+	}
+	return r.runExternal(args, assigns, io)
+}
+
+// hasSubst reports whether the command's assignments used $(...), whose
+// status then becomes the command's
+func hasSubst(cmd *command) bool {
+	for _, a := range cmd.assigns {
+		for _, p := range a.value {
+			if p.kind == partSubst {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redirect opens the command's redirections, returning the new stdio and a
+// function closing the opened files
+func (r *Runner) redirect(redirs []redirect, io stdio) (stdio, func(), error) {
+	var files []*os.File
+	closeAll := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	for _, redir := range redirs {
+		target, err := r.expandString(redir.target, io)
+		if err != nil {
+			return io, closeAll, err
+		}
+
+		if redir.op == ">&" {
+			w := io.out
+			if target == "2" {
+				w = io.err
+			}
+			if redir.fd == 2 {
+				io.err = w
+			} else {
+				io.out = w
+			}
+			continue
+		}
+
+		name := target
+		if name == "/dev/null" {
+			name = os.DevNull
+		} else {
+			name = r.abs(name)
+		}
+
+		var f *os.File
+		switch redir.op {
+		case "<":
+			f, err = os.Open(name)
+		case ">>":
+			f, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		default:
+			f, err = os.Create(name)
+		}
+		if err != nil {
+			return io, closeAll, fmt.Errorf("%s: %w", target, errors.Unwrap(err))
+		}
+		files = append(files, f)
+
+		switch redir.fd {
+		case 0:
+			io.in = f
+		case 1:
+			io.out = f
+		case 2:
+			io.err = f
+		default:
+			io.out, io.err = f, f
+		}
+	}
+	return io, closeAll, nil
+}
+
+// runExternal runs a program found on the script's PATH
+func (r *Runner) runExternal(args []string, assigns map[string]string, io stdio) int {
+	pathEnv, ok := assigns["PATH"]
+	if !ok {
+		pathEnv, _ = r.lookup("PATH")
+	}
+	program, ok := r.lookPath(args[0], pathEnv)
+	if !ok {
+		fmt.Fprintf(io.err, "gnpm: %s: command not found\n", args[0])
+		return 127
+	}
+
+	cmd := exec.Command(program, args[1:]...)
+	cmd.Dir = r.dir
+	cmd.Env = r.environ(assigns)
+	cmd.Stdin = io.in
+	cmd.Stdout = io.out
+	cmd.Stderr = io.err
+
+	err := r.runProcess(cmd)
+	if err == nil {
+		return 0
+	}
+	if code, ok := process.ExitCode(err); ok {
+		return code
+	}
+	fmt.Fprintf(io.err, "gnpm: %s: %v\n", args[0], err)
+	return 126
+}
+
+// runProcess runs cmd in a process group of its own, which gets the
+// signals sent to gnpm or is stopped when the script's context is done
+func (r *Runner) runProcess(cmd *exec.Cmd) error {
+	if r.ctx == nil {
+		return process.Run(cmd)
+	}
+
+	p, err := process.Start(cmd)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-p.Done():
+		return p.Wait()
+	case <-r.ctx.Done():
+		return p.Stop(process.Grace)
+	}
+}
+
+// lookPath finds an executable in pathEnv, or relative to the runner's
+// directory when name has a path separator. On Windows it tries the
+// extensions in PATHEXT.
+func (r *Runner) lookPath(name string, pathEnv string) (string, bool) {
+	var pathExt []string
+	if runtime.GOOS == "windows" {
+		value, _ := r.lookup("PATHEXT")
+		if value == "" {
+			value = ".COM;.EXE;.BAT;.CMD"
+		}
+		for _, ext := range strings.Split(strings.ToLower(value), ";") {
+			if ext != "" {
+				pathExt = append(pathExt, ext)
+			}
+		}
+	}
+
+	if strings.ContainsAny(name, `/\`) {
+		return findExecutable(r.abs(name), pathExt)
+	}
+	for _, dir := range filepath.SplitList(pathEnv) {
+		if dir == "" {
+			dir = "."
+		}
+		if !filepath.IsAbs(dir) {
+			dir = r.abs(dir)
+		}
+		if path, ok := findExecutable(filepath.Join(dir, name), pathExt); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// findExecutable checks path for an executable file. With pathExt, the
+// Windows PATHEXT extensions, it tries path with each of them and only
+// tries path itself when it already has one of them, like exec.LookPath:
+// node_modules/.bin holds an extensionless POSIX script next to each .cmd
+// shim, which CreateProcess can't run.
+func findExecutable(path string, pathExt []string) (string, bool) {
+	exts := []string{""}
+	if pathExt != nil {
+		exts = nil
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, e := range pathExt {
+			if e == ext {
+				exts = append(exts, "")
+				break
+			}
+		}
+		exts = append(exts, pathExt...)
+	}
+
+	for _, ext := range exts {
+		candidate := path + ext
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if pathExt != nil || info.Mode()&0111 != 0 {
+			return candidate, true
+		}
+	}
+	return "", false
+}
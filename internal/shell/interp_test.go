@@ -0,0 +1,272 @@
+package shell
+
+import (
+	"bytes"
+	stdcontext "context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixture creates the same small project in a fresh directory
+func fixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/a.js":        "a",
+		"src/b.js":        "b",
+		"src/c.ts":        "c",
+		"src/.hidden.js":  "h",
+		"src/nested/d.js": "d",
+		"dist/old.js":     "old",
+		"two words.txt":   "spaces",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// tree lists the files under dir with their contents
+func tree(t *testing.T, dir string) string {
+	t.Helper()
+	var lines []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if info.IsDir() {
+			lines = append(lines, rel+"/")
+			return nil
+		}
+		data, _ := os.ReadFile(path)
+		lines = append(lines, rel+": "+string(data))
+		return nil
+	})
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+func status(err error) int {
+	var exitErr *ExitError
+	var execErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &execErr):
+		return execErr.ExitCode()
+	default:
+		return -1
+	}
+}
+
+// TestRunMatchesSh runs each script with sh and with the built-in shell
+// and compares stdout, exit status and the files left behind
+func TestRunMatchesSh(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("compares against sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	env := []string{"PATH=" + os.Getenv("PATH"), "HOME=/home/test", "GREETING=hello world"}
+	scripts := []string{
+		// Lists and status
+		"echo one; echo two",
+		"true && echo yes || echo no",
+		"false && echo yes || echo no",
+		"false || false || echo third",
+		"false; echo $?",
+		"! false && echo negated",
+		"exit 3",
+		"echo before; exit 0; echo after",
+		"false",
+		"nonexistent-command-xyz 2>/dev/null; echo $?",
+
+		// Quoting and expansion
+		`echo 'single $GREETING' "double $GREETING" \$escaped`,
+		`echo "${GREETING}" ${MISSING:-fallback} "${MISSING:-two words}"`,
+		`printf '[%s]\n' $GREETING`,
+		`printf '[%s]\n' "$GREETING"`,
+		`printf '[%s]\n' '' "" x`,
+		`echo ~ ~/dir "~"`,
+		`echo "$(echo nested $(echo deep))"`,
+		"echo `echo backticks`",
+		`echo a\
+b`,
+		"echo #comment\necho after-newline",
+
+		// Assignments
+		"FOO=bar; echo $FOO",
+		"FOO=bar sh -c 'echo $FOO'; echo \"[$FOO]\"",
+		"FOO=local; sh -c 'echo [$FOO]'",
+		"export FOO=exported; sh -c 'echo $FOO'",
+		"A=1 B=2; echo $A$B",
+		"X=$(echo sub); echo $X",
+		"unset GREETING; echo \"[$GREETING]\"",
+
+		// Globbing
+		"echo src/*.js",
+		"echo src/*.md",
+		"echo src/?.ts src/*/*.js",
+		"echo 'src/*.js'",
+		"echo src/.*.js",
+		"for_glob=src/*.js; echo $for_glob",
+		"cat two\\ words.txt",
+
+		// Pipes and redirects
+		"echo piped | tr a-z A-Z",
+		"printf 'b\\na\\nc\\n' | sort | head -n 2",
+		"echo out > out.txt; echo more >> out.txt; cat < out.txt",
+		"sh -c 'echo err >&2' 2>err.txt; cat err.txt",
+		"sh -c 'echo both; echo err >&2' > all.txt 2>&1; cat all.txt",
+		"echo to-stderr >&2",
+		"(cd src && ls && pwd | sed 's|.*/||') ; ls -d src",
+		"(exit 4); echo $?",
+		"> empty.txt; ls empty.txt",
+		"false | true; echo $?",
+
+		// Builtins
+		"cd src && ls && cd .. && ls -d src",
+		"cd missing-dir 2>/dev/null || echo cd-failed",
+		"rm -rf dist && ls",
+		"rm src/a.js src/b.js && ls src",
+		"rm src 2>/dev/null; echo $?",
+		"rm -f missing.js; echo $?",
+		"rm missing.js 2>/dev/null; echo $?",
+		"mkdir -p build/deep/er && ls build/deep",
+		"mkdir src 2>/dev/null; echo $?",
+		"cp src/a.js copy.js && cat copy.js",
+		"cp -r src build && ls build build/nested",
+		"mkdir out && cp src/a.js src/b.js out && ls out",
+		"cp src out.js 2>/dev/null; echo $?",
+		"mv dist/old.js new.js && ls dist && cat new.js",
+		"echo -n no-newline; echo",
+		"rm -rf dist build && mkdir -p dist && cp src/*.js dist && ls dist",
+	}
+
+	for _, script := range scripts {
+		shDir, ourDir := fixture(t), fixture(t)
+
+		shCmd := exec.Command("sh", "-c", script)
+		shCmd.Dir = shDir
+		shCmd.Env = env
+		var shOut bytes.Buffer
+		shCmd.Stdout = &shOut
+		shStatus := status(shCmd.Run())
+
+		var ourOut bytes.Buffer
+		ourStatus := status(Run(script, Options{Dir: ourDir, Env: env, Stdout: &ourOut}))
+
+		if shOut.String() != ourOut.String() || shStatus != ourStatus {
+			t.Errorf("%s\n  sh:    %q (status %d)\n  gnpm:  %q (status %d)", script, shOut.String(), shStatus, ourOut.String(), ourStatus)
+		}
+		if shTree, ourTree := tree(t, shDir), tree(t, ourDir); shTree != ourTree {
+			t.Errorf("%s: files differ\n  sh:\n%s\n  gnpm:\n%s", script, shTree, ourTree)
+		}
+	}
+}
+
+func TestRunStderr(t *testing.T) {
+	var stderr bytes.Buffer
+	err := Run("missing-command-xyz", Options{Dir: t.TempDir(), Env: []string{"PATH="}, Stderr: &stderr})
+	if status(err) != 127 || !strings.Contains(stderr.String(), "missing-command-xyz: command not found") {
+		t.Errorf("Run = %v, stderr %q", err, stderr.String())
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), 100*time.Millisecond)
+	defer cancel()
+	var stdout bytes.Buffer
+	start := time.Now()
+	err := Run("sleep 10 | cat; echo after", Options{Dir: t.TempDir(), Env: []string{"PATH=" + os.Getenv("PATH")}, Stdout: &stdout, Context: ctx})
+	if !errors.Is(err, stdcontext.DeadlineExceeded) || stdout.Len() != 0 {
+		t.Errorf("Run = %v, stdout %q", err, stdout.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled script took %v", elapsed)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, script := range []string{
+		"echo 'unterminated",
+		`echo "unterminated`,
+		"echo $(echo",
+		"echo &&",
+		"| echo",
+		"sleep 1 &",
+		"cat <<EOF",
+		"echo >",
+		"( )",
+		"echo ${a/b/c}",
+		"echo $((1+2))",
+		"if true; then echo yes; fi",
+		"for f in a b; do echo $f; done",
+		"{ echo a; }",
+		`echo "$@"`,
+		"echo $1",
+		"echo $$",
+	} {
+		if err := Parse(script); err == nil {
+			t.Errorf("Parse(%q) should fail", script)
+		}
+	}
+}
+
+func TestFindExecutable(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "node_modules", ".bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"foo", "foo.cmd", "plain"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pathExt := []string{".com", ".exe", ".bat", ".cmd"}
+
+	tests := []struct {
+		name    string
+		pathExt []string
+		want    string
+	}{
+		// The POSIX stub npm writes next to the shim isn't runnable on Windows
+		{"foo", pathExt, "foo.cmd"},
+		{"foo.cmd", pathExt, "foo.cmd"},
+		{"plain", pathExt, ""},
+		{"foo", nil, "foo"},
+	}
+	for _, tt := range tests {
+		got, ok := findExecutable(filepath.Join(bin, tt.name), tt.pathExt)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("findExecutable(%s, %v) = %s, want none", tt.name, tt.pathExt, got)
+			}
+			continue
+		}
+		if !ok || got != filepath.Join(bin, tt.want) {
+			t.Errorf("findExecutable(%s, %v) = %s, want %s", tt.name, tt.pathExt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,580 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// list is a sequence of and-or lists separated by ; or newlines
+type list struct {
+	items []*andOr
+}
+
+// andOr is pipelines joined by && and ||
+type andOr struct {
+	first *pipeline
+	rest  []andOrPart
+}
+
+type andOrPart struct {
+	op   string // "&&" or "||"
+	pipe *pipeline
+}
+
+// pipeline is commands joined by |, optionally negated with !
+type pipeline struct {
+	negate bool
+	cmds   []*command
+}
+
+// command is a simple command, or a ( subshell ) when group is set
+type command struct {
+	assigns []assign
+	args    []word
+	redirs  []redirect
+	group   *list
+}
+
+type assign struct {
+	name  string
+	value word
+}
+
+// redirect is [fd]>target, [fd]>>target, [fd]<target, [fd]>&fd or &>target
+type redirect struct {
+	fd     int // 0, 1 or 2; -1 for &> (stdout and stderr)
+	op     string
+	target word
+}
+
+// word is a shell word made of literal, quoted and expanded parts
+type word []part
+
+type partKind int
+
+const (
+	partLit   partKind = iota // literal text
+	partParam                 // $NAME, ${NAME} or ${NAME:-default}
+	partSubst                 // $(...) or `...`
+	partTilde                 // leading ~
+)
+
+type part struct {
+	kind   partKind
+	text   string // literal text or parameter name
+	quoted bool   // quoted parts are not split or globbed
+	def    word   // ${NAME:-def}
+	hasDef bool
+	list   *list // command substitution
+}
+
+// literal returns the word's text when it is made only of literals
+func (w word) literal() (string, bool) {
+	var b strings.Builder
+	for _, p := range w {
+		if p.kind != partLit {
+			return "", false
+		}
+		b.WriteString(p.text)
+	}
+	return b.String(), true
+}
+
+// Parse checks that script is valid for the built-in shell
+func Parse(script string) error {
+	_, err := parse(script)
+	return err
+}
+
+func parse(script string) (*list, error) {
+	p := &parser{src: script}
+	l, err := p.list()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos])
+	}
+	return l, nil
+}
+
+type parser struct {
+	src   string
+	pos   int
+	depth int // nesting of ( and $(
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("syntax error at column %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// skipBlanks skips spaces, tabs, line continuations and comments
+func (p *parser) skipBlanks() {
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r':
+			p.pos++
+		case p.hasPrefix("\\\n"):
+			p.pos += 2
+		case p.peek() == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipSeparators skips blanks and newlines
+func (p *parser) skipNewlines() {
+	for {
+		p.skipBlanks()
+		if p.peek() != '\n' {
+			return
+		}
+		p.pos++
+	}
+}
+
+// list parses and-or lists until the end of input or a closing )
+func (p *parser) list() (*list, error) {
+	l := &list{}
+	for {
+		p.skipNewlines()
+		for p.peek() == ';' {
+			if len(l.items) == 0 {
+				return nil, p.errorf("unexpected ;")
+			}
+			p.pos++
+			p.skipNewlines()
+		}
+		if p.eof() || (p.peek() == ')' && p.depth > 0) {
+			return l, nil
+		}
+
+		item, err := p.andOr()
+		if err != nil {
+			return nil, err
+		}
+		l.items = append(l.items, item)
+
+		p.skipBlanks()
+		switch {
+		case p.eof() || (p.peek() == ')' && p.depth > 0):
+			return l, nil
+		case p.peek() == ';' || p.peek() == '\n':
+			p.pos++
+		case p.peek() == '&':
+			return nil, p.errorf("background jobs (&) are not supported")
+		default:
+			return nil, p.errorf("unexpected %q", p.peek())
+		}
+	}
+}
+
+func (p *parser) andOr() (*andOr, error) {
+	first, err := p.pipeline()
+	if err != nil {
+		return nil, err
+	}
+	ao := &andOr{first: first}
+	for {
+		p.skipBlanks()
+		op := ""
+		if p.hasPrefix("&&") {
+			op = "&&"
+		} else if p.hasPrefix("||") {
+			op = "||"
+		} else {
+			return ao, nil
+		}
+		p.pos += 2
+		p.skipNewlines()
+		pipe, err := p.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		ao.rest = append(ao.rest, andOrPart{op: op, pipe: pipe})
+	}
+}
+
+func (p *parser) pipeline() (*pipeline, error) {
+	pipe := &pipeline{}
+	p.skipBlanks()
+	if p.hasPrefix("! ") || p.hasPrefix("!\t") {
+		pipe.negate = true
+		p.pos++
+	}
+	for {
+		cmd, err := p.command()
+		if err != nil {
+			return nil, err
+		}
+		pipe.cmds = append(pipe.cmds, cmd)
+
+		p.skipBlanks()
+		if p.peek() != '|' || p.hasPrefix("||") {
+			return pipe, nil
+		}
+		p.pos++
+		p.skipNewlines()
+	}
+}
+
+func (p *parser) command() (*command, error) {
+	cmd := &command{}
+	p.skipBlanks()
+
+	if p.peek() == '(' {
+		p.pos++
+		p.depth++
+		group, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		p.depth--
+		if len(group.items) == 0 {
+			return nil, p.errorf("empty subshell")
+		}
+		cmd.group = group
+	}
+
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			break
+		}
+		c := p.peek()
+		if c == ';' || c == '\n' || c == '|' || c == ')' || (c == '&' && !p.hasPrefix("&>")) {
+			break
+		}
+		if c == '(' {
+			return nil, p.errorf("unexpected (")
+		}
+
+		if redir, ok, err := p.redirect(); err != nil {
+			return nil, err
+		} else if ok {
+			cmd.redirs = append(cmd.redirs, redir)
+			continue
+		}
+		if cmd.group != nil {
+			return nil, p.errorf("unexpected word after )")
+		}
+
+		start := p.pos
+		w, err := p.word()
+		if err != nil {
+			return nil, err
+		}
+		if len(cmd.args) == 0 {
+			if keyword := p.src[start:p.pos]; reserved[keyword] {
+				p.pos = start
+				return nil, p.errorf("%s is not supported (control flow and { } groups need sh)", keyword)
+			}
+			if name, ok := assignmentName(p.src[start:p.pos]); ok {
+				// Re-parse the value after NAME=
+				value := &parser{src: p.src[start+len(name)+1 : p.pos]}
+				v, err := value.word()
+				if err != nil {
+					return nil, err
+				}
+				cmd.assigns = append(cmd.assigns, assign{name: name, value: v})
+				continue
+			}
+		}
+		cmd.args = append(cmd.args, w)
+	}
+
+	if cmd.group == nil && len(cmd.args) == 0 && len(cmd.assigns) == 0 && len(cmd.redirs) == 0 {
+		if p.eof() {
+			return nil, p.errorf("unexpected end of script")
+		}
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return cmd, nil
+}
+
+// reserved are the shell's reserved words, which the built-in shell would
+// otherwise run as commands
+var reserved = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "fi": true,
+	"for": true, "while": true, "until": true, "do": true, "done": true,
+	"case": true, "esac": true, "in": true, "function": true,
+	"{": true, "}": true, "[[": true, "]]": true,
+}
+
+// assignmentName returns NAME when raw starts with NAME=
+func assignmentName(raw string) (string, bool) {
+	name, _, ok := strings.Cut(raw, "=")
+	if !ok || !validName(name) {
+		return "", false
+	}
+	return name, true
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') && !(i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// redirect parses a redirection when one starts at the current position
+func (p *parser) redirect() (redirect, bool, error) {
+	start := p.pos
+	r := redirect{fd: -2}
+	if p.hasPrefix("&>") {
+		r.fd, r.op = -1, ">"
+		p.pos += 2
+		if p.peek() == '>' {
+			r.op = ">>"
+			p.pos++
+		}
+	} else {
+		if c := p.peek(); c >= '0' && c <= '2' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '>' || p.src[p.pos+1] == '<') {
+			r.fd = int(c - '0')
+			p.pos++
+		}
+		switch {
+		case p.hasPrefix(">>"):
+			r.op = ">>"
+		case p.hasPrefix(">&"):
+			r.op = ">&"
+		case p.hasPrefix("<<"):
+			return r, false, p.errorf("here-documents are not supported")
+		case p.hasPrefix(">"), p.hasPrefix("<"):
+			r.op = p.src[p.pos : p.pos+1]
+		default:
+			p.pos = start
+			return r, false, nil
+		}
+		p.pos += len(r.op)
+		if r.fd == -2 {
+			r.fd = 1
+			if r.op == "<" {
+				r.fd = 0
+			}
+		}
+	}
+
+	p.skipBlanks()
+	if r.op == ">&" {
+		if c := p.peek(); c == '1' || c == '2' {
+			r.target = word{{kind: partLit, text: string(c)}}
+			p.pos++
+			return r, true, nil
+		}
+		return r, false, p.errorf("expected 1 or 2 after >&")
+	}
+	if p.eof() || strings.ContainsRune(";&|()<>\n", rune(p.peek())) {
+		return r, false, p.errorf("missing redirection target")
+	}
+	target, err := p.word()
+	if err != nil {
+		return r, false, err
+	}
+	r.target = target
+	return r, true, nil
+}
+
+// word parses one word up to an unquoted blank or operator
+func (p *parser) word() (word, error) {
+	var w word
+	lit := func(text string, quoted bool) {
+		if n := len(w); n > 0 && w[n-1].kind == partLit && w[n-1].quoted == quoted {
+			w[n-1].text += text
+			return
+		}
+		w = append(w, part{kind: partLit, text: text, quoted: quoted})
+	}
+
+	if p.peek() == '~' {
+		end := p.pos + 1
+		for end < len(p.src) && !strings.ContainsRune("/ \t\n;&|()<>", rune(p.src[end])) {
+			end++
+		}
+		if end == p.pos+1 {
+			w = append(w, part{kind: partTilde})
+			p.pos++
+		}
+	}
+
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case strings.ContainsRune(" \t\r\n;&|()<>", rune(c)):
+			return w, nil
+		case c == '\\':
+			if p.hasPrefix("\\\n") {
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			if p.eof() {
+				lit("\\", true)
+				return w, nil
+			}
+			lit(string(p.peek()), true)
+			p.pos++
+		case c == '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				return nil, p.errorf("unterminated '")
+			}
+			lit(p.src[p.pos+1:p.pos+1+end], true)
+			// Keep empty quotes so '' still makes an argument
+			if end == 0 {
+				w = append(w, part{kind: partLit, quoted: true})
+			}
+			p.pos += end + 2
+		case c == '"':
+			p.pos++
+			empty := true
+			for {
+				if p.eof() {
+					return nil, p.errorf("unterminated \"")
+				}
+				c := p.peek()
+				if c == '"' {
+					p.pos++
+					break
+				}
+				empty = false
+				switch {
+				case c == '\\' && p.pos+1 < len(p.src) && strings.ContainsRune("$`\"\\\n", rune(p.src[p.pos+1])):
+					if p.src[p.pos+1] != '\n' {
+						lit(string(p.src[p.pos+1]), true)
+					}
+					p.pos += 2
+				case c == '$' || c == '`':
+					part, err := p.expansion(true)
+					if err != nil {
+						return nil, err
+					}
+					w = append(w, part)
+				default:
+					lit(string(c), true)
+					p.pos++
+				}
+			}
+			if empty {
+				w = append(w, part{kind: partLit, quoted: true})
+			}
+		case c == '$' || c == '`':
+			part, err := p.expansion(false)
+			if err != nil {
+				return nil, err
+			}
+			w = append(w, part)
+		default:
+			lit(string(c), false)
+			p.pos++
+		}
+	}
+	return w, nil
+}
+
+// expansion parses $NAME, ${NAME}, ${NAME:-word}, $?, $(...) or `...`
+func (p *parser) expansion(quoted bool) (part, error) {
+	if p.peek() == '`' {
+		end := strings.IndexByte(p.src[p.pos+1:], '`')
+		if end < 0 {
+			return part{}, p.errorf("unterminated `")
+		}
+		inner, err := parse(p.src[p.pos+1 : p.pos+1+end])
+		if err != nil {
+			return part{}, err
+		}
+		p.pos += end + 2
+		return part{kind: partSubst, list: inner, quoted: quoted}, nil
+	}
+
+	p.pos++ // $
+	switch c := p.peek(); {
+	case c == '(':
+		if p.hasPrefix("((") {
+			return part{}, p.errorf("arithmetic expansion $((...)) is not supported")
+		}
+		p.pos++
+		p.depth++
+		inner, err := p.list()
+		if err != nil {
+			return part{}, err
+		}
+		if p.peek() != ')' {
+			return part{}, p.errorf("missing )")
+		}
+		p.pos++
+		p.depth--
+		return part{kind: partSubst, list: inner, quoted: quoted}, nil
+	case c == '{':
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return part{}, p.errorf("missing }")
+		}
+		body := p.src[p.pos+1 : p.pos+end]
+		name, def, hasDef := strings.Cut(body, ":-")
+		if !validName(name) && name != "?" {
+			return part{}, p.errorf("unsupported expansion ${%s}", body)
+		}
+		exp := part{kind: partParam, text: name, quoted: quoted, hasDef: hasDef}
+		if hasDef {
+			defParser := &parser{src: def}
+			w, err := defParser.word()
+			if err != nil {
+				return part{}, err
+			}
+			if !defParser.eof() {
+				// Spaces are allowed in defaults: keep the rest literally
+				w = append(w, part{kind: partLit, text: def[defParser.pos:], quoted: true})
+			}
+			exp.def = w
+		}
+		p.pos += end + 1
+		return exp, nil
+	case c == '?':
+		p.pos++
+		return part{kind: partParam, text: "?", quoted: quoted}, nil
+	case c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+		start := p.pos
+		for !p.eof() {
+			c := p.peek()
+			if c != '_' && !(c >= 'A' && c <= 'Z') && !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') {
+				break
+			}
+			p.pos++
+		}
+		return part{kind: partParam, text: p.src[start:p.pos], quoted: quoted}, nil
+	case c == '@' || c == '*' || c == '#' || c == '!' || c == '$' || c == '-' || (c >= '0' && c <= '9'):
+		return part{}, p.errorf("special parameter $%c is not supported", c)
+	default:
+		// A lone $ is literal
+		return part{kind: partLit, text: "$", quoted: true}, nil
+	}
+}
@@ -0,0 +1,102 @@
+package shell
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// posixSpecial matches arguments a POSIX shell would split, expand or
+// otherwise interpret
+var posixSpecial = regexp.MustCompile("[\\s\"#$&'()*;<>?\\[\\]\\\\`{}|~!]")
+
+// cmdSpecial matches arguments cmd.exe and the C runtime would split
+var cmdSpecial = regexp.MustCompile(`[ \t\n\v"]`)
+
+// cmdMeta matches the characters cmd.exe interprets, escaped with ^
+var cmdMeta = regexp.MustCompile(`[ !%^&()<>|"]`)
+
+// Quote quotes arg for the shell scripts run in: sh on Unix, cmd.exe on
+// Windows
+func Quote(arg string) string {
+	if runtime.GOOS == "windows" {
+		return QuoteCmd(arg)
+	}
+	return QuotePOSIX(arg)
+}
+
+// Join quotes each argument with Quote and joins them with spaces
+func Join(args []string) string {
+	return join(args, Quote)
+}
+
+// JoinPOSIX quotes each argument with QuotePOSIX and joins them with spaces,
+// for sh and the built-in shell on every platform
+func JoinPOSIX(args []string) string {
+	return join(args, QuotePOSIX)
+}
+
+func join(args []string, quote func(string) string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// QuotePOSIX quotes arg so a POSIX shell passes it through as one literal
+// word. Plain arguments are left as they are, the rest are wrapped in single
+// quotes, the same way npm forwards arguments after --.
+func QuotePOSIX(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !posixSpecial.MatchString(arg) {
+		return arg
+	}
+
+	quoted := "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	// Drop the empty '' pairs left when arg starts or ends with a quote
+	for strings.HasPrefix(quoted, "''") && len(quoted) > 2 {
+		quoted = quoted[2:]
+	}
+	for strings.HasSuffix(quoted, `\'''`) {
+		quoted = quoted[:len(quoted)-2]
+	}
+	return quoted
+}
+
+// QuoteCmd quotes arg for cmd.exe: it is quoted for the C runtime argument
+// parser, backslashes before quotes doubled, and then cmd.exe metacharacters
+// are escaped with ^. This matches npm's quoting on Windows.
+func QuoteCmd(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+
+	quoted := arg
+	if cmdSpecial.MatchString(arg) {
+		var b strings.Builder
+		b.WriteByte('"')
+		for i := 0; i <= len(arg); i++ {
+			slashes := 0
+			for i < len(arg) && arg[i] == '\\' {
+				i++
+				slashes++
+			}
+			switch {
+			case i == len(arg):
+				b.WriteString(strings.Repeat(`\`, slashes*2))
+			case arg[i] == '"':
+				b.WriteString(strings.Repeat(`\`, slashes*2+1))
+				b.WriteByte('"')
+			default:
+				b.WriteString(strings.Repeat(`\`, slashes))
+				b.WriteByte(arg[i])
+			}
+		}
+		b.WriteByte('"')
+		quoted = b.String()
+	}
+	return cmdMeta.ReplaceAllString(quoted, "^$0")
+}
@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+var quoteArgs = []string{
+	"",
+	"plain",
+	"--reporter=dot",
+	"two words",
+	"src/**/*.test.ts",
+	`{"a": [1, 2]}`,
+	"$HOME",
+	"it's",
+	"'quoted'",
+	"'",
+	`back\slash`,
+	"tab\there",
+	"line\nbreak",
+	"a;b|c&d",
+	"!history",
+	"~",
+}
+
+func TestQuotePOSIX(t *testing.T) {
+	for arg, want := range map[string]string{
+		"":           "''",
+		"plain":      "plain",
+		"a=b,c:d@e":  "a=b,c:d@e",
+		"two words":  "'two words'",
+		"*.ts":       "'*.ts'",
+		"it's":       `'it'\''s'`,
+		"'quoted'":   `\''quoted'\'`,
+		"$HOME/path": "'$HOME/path'",
+	} {
+		if got := QuotePOSIX(arg); got != want {
+			t.Errorf("QuotePOSIX(%q) = %s, want %s", arg, got, want)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	// The quoted arguments must reach a real shell unchanged
+	for _, arg := range quoteArgs {
+		out, err := exec.Command("sh", "-c", "printf %s "+QuotePOSIX(arg)).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", arg, err)
+		}
+		if string(out) != arg {
+			t.Errorf("sh received %q for %q (quoted as %s)", out, arg, QuotePOSIX(arg))
+		}
+	}
+}
+
+func TestQuoteCmd(t *testing.T) {
+	for arg, want := range map[string]string{
+		"":                `""`,
+		"plain":           "plain",
+		"two words":       `^"two^ words^"`,
+		`say "hi"`:        `^"say^ \^"hi\^"^"`,
+		`C:\dir with\`:    `^"C:\dir^ with\\^"`,
+		"a&b":             "a^&b",
+		"100%":            "100^%",
+		"src/**/*.ts":     "src/**/*.ts",
+		`{"a":1}`:         `^"{\^"a\^":1}^"`,
+		`trailing\"quote`: `^"trailing\\\^"quote^"`,
+	} {
+		if got := QuoteCmd(arg); got != want {
+			t.Errorf("QuoteCmd(%q) = %s, want %s", arg, got, want)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	if got := JoinPOSIX([]string{"--grep", `say "hi"`, "-u"}); got != `--grep 'say "hi"' -u` {
+		t.Errorf("JoinPOSIX = %s", got)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("POSIX quoting")
+	}
+	if got := Join([]string{"--grep", "two words", "-u"}); got != "--grep 'two words' -u" {
+		t.Errorf("Join = %s", got)
+	}
+}
@@ -0,0 +1,145 @@
+package taskcache
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Files inside a cache entry
+const (
+	entryFile  = "task.json"
+	logFile    = "output.log"
+	outputsDir = "outputs"
+)
+
+// Cache stores task results on the local filesystem, one directory per key
+type Cache struct {
+	Dir string
+}
+
+// entry describes a cached result
+type entry struct {
+	Task    string    `json:"task"`
+	Dir     string    `json:"dir"`
+	Outputs []string  `json:"outputs"`
+	Created time.Time `json:"created"`
+}
+
+// Restore copies the outputs cached under key back into the task's
+// directory and returns the recorded log, or nil when nothing is cached
+// under key
+func (c *Cache) Restore(key string, t *Task) (*Log, error) {
+	dir := filepath.Join(c.Dir, key)
+	data, err := os.ReadFile(filepath.Join(dir, entryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+
+	logData, err := os.ReadFile(filepath.Join(dir, logFile))
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range e.Outputs {
+		src := filepath.Join(dir, outputsDir, filepath.FromSlash(rel))
+		if err := copyEntry(src, filepath.Join(t.Dir, filepath.FromSlash(rel))); err != nil {
+			return nil, err
+		}
+	}
+
+	log := &Log{}
+	log.data.Write(logData)
+	return log, nil
+}
+
+// Save stores the task's current outputs and its log under key. The entry
+// is written to a temporary directory first, so a crash never leaves a
+// partial entry behind.
+func (c *Cache) Save(key string, t *Task, log *Log) error {
+	outputs, err := t.OutputFiles()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(c.Dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, rel := range outputs {
+		src := filepath.Join(t.Dir, filepath.FromSlash(rel))
+		if err := copyEntry(src, filepath.Join(tmp, outputsDir, filepath.FromSlash(rel))); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmp, logFile), log.Bytes(), 0644); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry{Task: t.Name, Dir: t.Dir, Outputs: outputs, Created: time.Now().UTC()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, entryFile), data, 0644); err != nil {
+		return err
+	}
+
+	dest := filepath.Join(c.Dir, key)
+	if err := os.Rename(tmp, dest); err != nil {
+		// Another run cached the same result first
+		if _, statErr := os.Stat(filepath.Join(dest, entryFile)); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// copyEntry copies a file or symlink, creating parent directories and
+// replacing whatever is at dest
+func copyEntry(src string, dest string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dest)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
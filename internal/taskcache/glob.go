@@ -0,0 +1,112 @@
+package taskcache
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skipDirs are never walked: installed dependencies and version control
+var skipDirs = map[string]bool{"node_modules": true, ".git": true}
+
+// Match reports whether the slash-separated path name matches pattern. A
+// ** segment matches any number of directories; other segments use
+// path.Match syntax.
+func Match(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(name); i >= 0; i-- {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Matcher selects files with globs relative to a package directory. A glob
+// matching a directory selects everything inside it, and one starting with
+// ! excludes what earlier globs selected.
+type Matcher []string
+
+// Match reports whether the slash-separated relative path rel is selected
+func (m Matcher) Match(rel string) bool {
+	selected := false
+	for _, pattern := range m {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = path.Clean(strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "./"))
+		if matchDirs(pattern, rel) {
+			selected = !exclude
+		}
+	}
+	return selected
+}
+
+// matchDirs reports whether pattern matches rel or one of its parent
+// directories
+func matchDirs(pattern string, rel string) bool {
+	for i := 0; i <= len(rel); i++ {
+		if (i == len(rel) || rel[i] == '/') && Match(pattern, rel[:i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Files lists the files under dir selected by include, as sorted
+// slash-separated relative paths. node_modules, .git and nested packages
+// (directories with their own package.json) are skipped.
+func Files(dir string, include func(rel string) bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "package.json")); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if include(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	sort.Strings(files)
+	return files, err
+}
@@ -0,0 +1,85 @@
+package taskcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Streams a log records output from
+const (
+	streamStdout byte = 1
+	streamStderr byte = 2
+)
+
+// Log records a task's stdout and stderr, interleaved as written, so a
+// cache hit can replay them
+type Log struct {
+	mu   sync.Mutex
+	data bytes.Buffer
+}
+
+// Tee returns writers passing output through to stdout and stderr while
+// recording it
+func (l *Log) Tee(stdout io.Writer, stderr io.Writer) (io.Writer, io.Writer) {
+	return &logWriter{log: l, stream: streamStdout, w: stdout},
+		&logWriter{log: l, stream: streamStderr, w: stderr}
+}
+
+// Replay writes the recorded output to stdout and stderr
+func (l *Log) Replay(stdout io.Writer, stderr io.Writer) error {
+	l.mu.Lock()
+	data := l.data.Bytes()
+	l.mu.Unlock()
+
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return fmt.Errorf("truncated task log")
+		}
+		stream, size := data[0], binary.BigEndian.Uint32(data[1:5])
+		data = data[5:]
+		if uint32(len(data)) < size {
+			return fmt.Errorf("truncated task log")
+		}
+
+		w := stdout
+		if stream == streamStderr {
+			w = stderr
+		}
+		if _, err := w.Write(data[:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// Bytes returns the recorded log in the format Replay reads
+func (l *Log) Bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]byte(nil), l.data.Bytes()...)
+}
+
+func (l *Log) record(stream byte, p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var header [5]byte
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[1:], uint32(len(p)))
+	l.data.Write(header[:])
+	l.data.Write(p)
+}
+
+type logWriter struct {
+	log    *Log
+	stream byte
+	w      io.Writer
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.log.record(w.stream, p)
+	return w.w.Write(p)
+}
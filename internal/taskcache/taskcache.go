@@ -0,0 +1,178 @@
+package taskcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// version is part of every key, so changing what goes into a key
+// invalidates older entries
+const version = "1"
+
+// lockfiles are hashed into every key when present at the workspace root
+var lockfiles = []string{
+	"bun.lockb",
+	"bun.lock",
+	"deno.lock",
+	"pnpm-lock.yaml",
+	"yarn.lock",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+}
+
+// Task is a script whose result can be cached
+type Task struct {
+	Name string
+
+	// Inputs select the files the result depends on, relative to Dir; all
+	// files by default. Outputs never count as inputs.
+	Inputs []string
+	// Outputs select the files the script produces, restored on a hit
+	Outputs []string
+	// Env names the variables the result depends on; globs such as
+	// NEXT_PUBLIC_* are allowed
+	Env []string
+
+	Dir     string // package directory
+	RootDir string // workspace root holding the lockfile
+
+	// Dependencies are the directories of the workspace packages Dir
+	// depends on. Their files, including built outputs, are part of the key.
+	Dependencies []string
+}
+
+// InputFiles lists the task's input files relative to Dir
+func (t *Task) InputFiles() ([]string, error) {
+	inputs := Matcher(t.Inputs)
+	if len(inputs) == 0 {
+		inputs = Matcher{"**"}
+	}
+	outputs := Matcher(t.Outputs)
+	return Files(t.Dir, func(rel string) bool {
+		return inputs.Match(rel) && !outputs.Match(rel)
+	})
+}
+
+// OutputFiles lists the task's output files relative to Dir
+func (t *Task) OutputFiles() ([]string, error) {
+	outputs := Matcher(t.Outputs)
+	return Files(t.Dir, outputs.Match)
+}
+
+// Key hashes everything the task's result depends on: the commands it
+// runs, the values of its env variables in env, its input files, the
+// lockfile and the files of its workspace dependencies
+func (t *Task) Key(commands []string, env []string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "gnpm task %s\x00%s\x00%s/%s\x00", version, t.Name, runtime.GOOS, runtime.GOARCH)
+	for _, command := range commands {
+		fmt.Fprintf(h, "command\x00%s\x00", command)
+	}
+	for _, kv := range t.envValues(env) {
+		fmt.Fprintf(h, "env\x00%s\x00", kv)
+	}
+
+	inputs, err := t.InputFiles()
+	if err != nil {
+		return "", err
+	}
+	for _, rel := range inputs {
+		if err := hashFile(h, "input", t.Dir, rel); err != nil {
+			return "", err
+		}
+	}
+
+	for _, name := range lockfiles {
+		if _, err := os.Lstat(filepath.Join(t.RootDir, name)); err != nil {
+			continue
+		}
+		if err := hashFile(h, "lockfile", t.RootDir, name); err != nil {
+			return "", err
+		}
+	}
+
+	deps := append([]string(nil), t.Dependencies...)
+	sort.Strings(deps)
+	for _, dir := range deps {
+		label := dir
+		if rel, err := filepath.Rel(t.RootDir, dir); err == nil {
+			label = filepath.ToSlash(rel)
+		}
+		files, err := Files(dir, func(string) bool { return true })
+		if err != nil {
+			return "", err
+		}
+		for _, rel := range files {
+			if err := hashFile(h, "dependency "+label, dir, rel); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// envValues returns NAME=value for the variables in env the task depends
+// on, sorted. Later entries of env win; unset variables are left out.
+func (t *Task) envValues(env []string) []string {
+	values := map[string]string{}
+	for _, kv := range env {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		for _, pattern := range t.Env {
+			if ok, _ := path.Match(pattern, name); ok {
+				values[name] = value
+				break
+			}
+		}
+	}
+
+	list := make([]string, 0, len(values))
+	for name, value := range values {
+		list = append(list, name+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// hashFile adds a file's path and contents to h. Symlinks are hashed by
+// their target.
+func hashFile(h hash.Hash, kind string, dir string, rel string) error {
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	info, err := os.Lstat(p)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00symlink\x00%s\x00", kind, rel, target)
+		return nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "%s\x00%s\x00%o\x00%x\x00", kind, rel, info.Mode().Perm(), sum.Sum(nil))
+	return nil
+}
@@ -0,0 +1,196 @@
+package taskcache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"src/*.ts", "src/a.ts", true},
+		{"src/*.ts", "src/deep/a.ts", false},
+		{"src/**/*.ts", "src/a.ts", true},
+		{"src/**/*.ts", "src/deep/er/a.ts", true},
+		{"**", "anything/at/all", true},
+		{"**/*.json", "package.json", true},
+		{"src/**", "lib/a.ts", false},
+		{"?.js", "a.js", true},
+	} {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m := Matcher{"src", "./tsconfig.json", "!src/**/*.test.ts"}
+	for name, want := range map[string]bool{
+		"src/index.ts":          true,
+		"src/deep/util.ts":      true,
+		"src/deep/util.test.ts": false,
+		"tsconfig.json":         true,
+		"README.md":             false,
+	} {
+		if got := m.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json":                 "{}",
+		"src/a.ts":                     "a",
+		"node_modules/dep/index.js":    "dep",
+		".git/HEAD":                    "ref",
+		"packages/nested/package.json": "{}",
+		"packages/nested/index.js":     "nested",
+	})
+
+	files, err := Files(dir, func(string) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"package.json", "src/a.ts"}) {
+		t.Errorf("Files = %v", files)
+	}
+}
+
+func TestKey(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"pnpm-lock.yaml":          "lockfileVersion: '9.0'",
+		"app/package.json":        "{}",
+		"app/src/index.ts":        "index",
+		"app/dist/index.js":       "built",
+		"app/README.md":           "docs",
+		"lib/package.json":        "{}",
+		"lib/dist/index.js":       "lib",
+		"lib/node_modules/x/x.js": "x",
+	})
+	task := &Task{
+		Name:         "build",
+		Inputs:       []string{"src/**", "package.json"},
+		Outputs:      []string{"dist/**"},
+		Env:          []string{"API_*"},
+		Dir:          filepath.Join(root, "app"),
+		RootDir:      root,
+		Dependencies: []string{filepath.Join(root, "lib")},
+	}
+	env := []string{"API_URL=https://example.com", "HOME=/home/a"}
+	commands := []string{"tsc"}
+
+	key := func() string {
+		t.Helper()
+		k, err := task.Key(commands, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	base := key()
+	if key() != base {
+		t.Fatal("Key isn't stable")
+	}
+
+	// Changes the key doesn't depend on
+	env = append(env, "HOME=/home/b")
+	writeFiles(t, root, map[string]string{
+		"app/README.md":           "more docs",
+		"app/dist/index.js":       "rebuilt",
+		"lib/node_modules/x/x.js": "y",
+	})
+	if key() != base {
+		t.Error("Key changed for files and variables outside the task")
+	}
+
+	for name, change := range map[string]func(){
+		"input":      func() { writeFiles(t, root, map[string]string{"app/src/index.ts": "changed"}) },
+		"new input":  func() { writeFiles(t, root, map[string]string{"app/src/extra.ts": "extra"}) },
+		"lockfile":   func() { writeFiles(t, root, map[string]string{"pnpm-lock.yaml": "changed"}) },
+		"dependency": func() { writeFiles(t, root, map[string]string{"lib/dist/index.js": "changed"}) },
+		"env":        func() { env = append(env, "API_URL=https://example.org") },
+		"command":    func() { commands = []string{"tsc --build"} },
+	} {
+		before := key()
+		change()
+		if key() == before {
+			t.Errorf("Key didn't change with the %s", name)
+		}
+	}
+}
+
+func TestSaveRestore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/index.ts":       "index",
+		"dist/index.js":      "built",
+		"dist/deep/chunk.js": "chunk",
+	})
+	task := &Task{Name: "build", Outputs: []string{"dist"}, Dir: dir, RootDir: dir}
+	cache := &Cache{Dir: t.TempDir()}
+
+	if log, err := cache.Restore("missing", task); err != nil || log != nil {
+		t.Fatalf("Restore of a missing key = %v, %v", log, err)
+	}
+
+	log := &Log{}
+	var stdout, stderr bytes.Buffer
+	out, errOut := log.Tee(&stdout, &stderr)
+	out.Write([]byte("compiling\n"))
+	errOut.Write([]byte("warning\n"))
+	out.Write([]byte("done\n"))
+	if stdout.String() != "compiling\ndone\n" || stderr.String() != "warning\n" {
+		t.Fatalf("Tee passed through %q and %q", stdout.String(), stderr.String())
+	}
+	if err := cache.Save("key", task, log); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// Saving the same key again keeps the first entry
+	if err := cache.Save("key", task, log); err != nil {
+		t.Fatalf("second Save failed: %v", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, "dist")); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := cache.Restore("key", task)
+	if err != nil || restored == nil {
+		t.Fatalf("Restore = %v, %v", restored, err)
+	}
+	for name, want := range map[string]string{"dist/index.js": "built", "dist/deep/chunk.js": "chunk"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+
+	var replayed bytes.Buffer
+	if err := restored.Replay(&replayed, &replayed); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if replayed.String() != "compiling\nwarning\ndone\n" {
+		t.Errorf("Replay = %q", replayed.String())
+	}
+}
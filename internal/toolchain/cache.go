@@ -0,0 +1,161 @@
+package toolchain
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// metadataFile is written next to every package manager gnpm installs
+const metadataFile = ".gnpm.json"
+
+// metadata describes a cached package manager installation
+type metadata struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Hash      string `json:"hash,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+	Bin       string `json:"bin,omitempty"`
+}
+
+// CacheDir returns gnpm's cache directory (~/.cache/gnpm by default).
+// GNPM_CACHE_DIR overrides the location.
+func CacheDir() string {
+	if dir := os.Getenv("GNPM_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "gnpm")
+	}
+	return filepath.Join(os.TempDir(), "gnpm")
+}
+
+// PMDir returns the directory a package manager version is cached in
+func PMDir(name string, version string) string {
+	return filepath.Join(CacheDir(), "pm", name, version)
+}
+
+// corepackDir returns the directory corepack caches a package manager version in
+func corepackDir(name string, version string) string {
+	home := os.Getenv("COREPACK_HOME")
+	if home == "" {
+		base := os.Getenv("XDG_CACHE_HOME")
+		if base == "" {
+			base = os.Getenv("LOCALAPPDATA")
+		}
+		if base == "" {
+			userHome, err := os.UserHomeDir()
+			if err != nil {
+				return ""
+			}
+			if runtime.GOOS == "windows" {
+				base = filepath.Join(userHome, "AppData", "Local")
+			} else {
+				base = filepath.Join(userHome, ".cache")
+			}
+		}
+		home = filepath.Join(base, "node", "corepack")
+	}
+	return filepath.Join(home, "v1", name, version)
+}
+
+// readMetadata reads gnpm's metadata file, falling back to corepack's .corepack file
+func readMetadata(dir string) (metadata, bool) {
+	for _, name := range []string{metadataFile, ".corepack"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var meta metadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			continue
+		}
+		return meta, true
+	}
+	return metadata{}, false
+}
+
+// findEntry locates the executable entry point of an installed package manager
+func findEntry(dir string, name string) (string, bool) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", false
+	}
+
+	if meta, ok := readMetadata(dir); ok && meta.Bin != "" {
+		if path := filepath.Join(dir, meta.Bin); fileExists(path) {
+			return path, true
+		}
+	}
+
+	if bin := readPackageBin(dir, name); bin != "" {
+		if path := filepath.Join(dir, bin); fileExists(path) {
+			return path, true
+		}
+	}
+
+	candidates := []string{
+		filepath.Join("bin", name+".cjs"),
+		filepath.Join("bin", name+".js"),
+		filepath.Join("bin", name),
+		name + ".js",
+		name + ".cjs",
+	}
+	if name == "npm" {
+		candidates = append([]string{filepath.Join("bin", "npm-cli.js")}, candidates...)
+	}
+	if runtime.GOOS == "windows" {
+		candidates = append([]string{filepath.Join("bin", name+".exe")}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		if path := filepath.Join(dir, candidate); fileExists(path) {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+// readPackageBin returns the bin entry for name from the package.json in dir
+func readPackageBin(dir string, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
+	}
+
+	var pkg struct {
+		Bin json.RawMessage `json:"bin"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil || len(pkg.Bin) == 0 {
+		return ""
+	}
+
+	var single string
+	if err := json.Unmarshal(pkg.Bin, &single); err == nil {
+		return single
+	}
+
+	var bins map[string]string
+	if err := json.Unmarshal(pkg.Bin, &bins); err == nil {
+		return bins[name]
+	}
+
+	return ""
+}
+
+// isScript reports whether an entry point needs to be run through node
+func isScript(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".js", ".cjs", ".mjs":
+		return true
+	default:
+		return false
+	}
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,424 @@
+package toolchain
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// downloadTimeout is the fetch-timeout default for package manager
+// downloads, as Bun's tarballs are tens of megabytes
+const downloadTimeout = 5 * time.Minute
+
+// InstallOptions for installing a package manager into gnpm's cache
+type InstallOptions struct {
+	Mirror   string          // explicit registry or mirror (http(s):// or file://), such as --registry
+	Registry string          // configured registry, used when neither Mirror nor GNPM_PM_MIRROR is set
+	Config   registry.Config // .npmrc settings for auth, proxies and TLS
+	Tarball  string          // install from this local tarball instead of the registry
+	Hash     string          // expected "<algorithm>.<hex>" hash of the tarball
+}
+
+// Installation describes a package manager installed in gnpm's cache
+type Installation struct {
+	Name      string
+	Version   string
+	Dir       string
+	Integrity string // subresource integrity of the tarball
+	Hash      string // "sha512.<hex>" hash for the packageManager field
+}
+
+// Spec returns the packageManager field value for the installation
+func (i Installation) Spec() string {
+	return i.Name + "@" + i.Version + "+" + i.Hash
+}
+
+// Install downloads (or imports) a package manager version into
+// ~/.cache/gnpm/pm/<name>/<version>, verifying its integrity.
+// The version may be exact, a dist-tag such as "latest", or a range.
+func Install(name string, version string, opts InstallOptions) (Installation, error) {
+	if opts.Tarball != "" {
+		return installTarball(name, version, opts)
+	}
+
+	source := newSource(mirrorURL(opts.Mirror, opts.Registry), opts.Config)
+
+	pkgName, err := packageName(name, version)
+	if err != nil {
+		return Installation{}, err
+	}
+
+	meta, err := source.packument(pkgName)
+	if err != nil {
+		return Installation{}, err
+	}
+
+	manifest, err := meta.Resolve(version)
+	if err != nil {
+		return Installation{}, fmt.Errorf("%s@%s: %w", name, version, err)
+	}
+	resolved := manifest.Version
+
+	// The package name can depend on the resolved version (yarn 1 vs berry)
+	if resolvedName, err := packageName(name, resolved); err == nil && resolvedName != pkgName {
+		pkgName = resolvedName
+		if meta, err = source.packument(pkgName); err != nil {
+			return Installation{}, err
+		}
+		if manifest, err = meta.Resolve(resolved); err != nil {
+			return Installation{}, fmt.Errorf("%s@%s: %w", name, resolved, err)
+		}
+	}
+
+	data, err := source.tarball(pkgName, resolved, manifest.Dist)
+	if err != nil {
+		return Installation{}, fmt.Errorf("%s@%s: %w", name, resolved, err)
+	}
+
+	return install(name, resolved, data, opts.Hash)
+}
+
+// installTarball imports a package manager from a local tarball
+func installTarball(name string, version string, opts InstallOptions) (Installation, error) {
+	data, err := os.ReadFile(opts.Tarball)
+	if err != nil {
+		return Installation{}, err
+	}
+
+	if !semver.Valid(version) {
+		// Take the version from the tarball's package.json
+		version = ""
+	}
+	return install(name, version, data, opts.Hash)
+}
+
+// install verifies the expected hash and extracts the tarball into the
+// cache. The package.json inside must have the version asked for; an empty
+// version installs whichever version the tarball holds.
+func install(name string, version string, data []byte, expectedHash string) (Installation, error) {
+	sum := sha512.Sum512(data)
+	installation := Installation{
+		Name:      name,
+		Integrity: "sha512-" + base64.StdEncoding.EncodeToString(sum[:]),
+		Hash:      "sha512." + hex.EncodeToString(sum[:]),
+	}
+
+	label := name
+	if version != "" {
+		label += "@" + version
+	}
+
+	parent := filepath.Dir(PMDir(name, "0.0.0"))
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return Installation{}, err
+	}
+
+	tmpDir, err := os.MkdirTemp(parent, ".install-")
+	if err != nil {
+		return Installation{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := registry.Extract(data, tmpDir); err != nil {
+		return Installation{}, fmt.Errorf("extract %s: %w", label, err)
+	}
+
+	// A mirror serving the wrong file must not end up cached under the
+	// requested version
+	tarballVersion, err := readVersion(tmpDir)
+	if err != nil {
+		return Installation{}, fmt.Errorf("%s: %w", label, err)
+	}
+	if version == "" {
+		version = tarballVersion
+	} else if tarballVersion != version {
+		return Installation{}, fmt.Errorf("%s: the tarball holds version %s", label, tarballVersion)
+	}
+	installation.Version = version
+	installation.Dir = PMDir(name, version)
+
+	if expectedHash != "" {
+		if err := verifyPinnedHash(name, version, data, tmpDir, expectedHash); err != nil {
+			return Installation{}, fmt.Errorf("%s: %w", label, err)
+		}
+		installation.Hash = expectedHash
+	}
+
+	entry, ok := findEntry(tmpDir, name)
+	if !ok {
+		return Installation{}, fmt.Errorf("%s@%s: no %s executable found in the package", name, version, name)
+	}
+	bin, err := filepath.Rel(tmpDir, entry)
+	if err != nil {
+		return Installation{}, err
+	}
+	if !isScript(entry) {
+		if err := os.Chmod(entry, 0755); err != nil {
+			return Installation{}, err
+		}
+	}
+
+	meta, err := json.MarshalIndent(metadata{
+		Name:      name,
+		Version:   version,
+		Hash:      installation.Hash,
+		Integrity: installation.Integrity,
+		Bin:       filepath.ToSlash(bin),
+	}, "", "  ")
+	if err != nil {
+		return Installation{}, err
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, metadataFile), append(meta, '\n'), 0644); err != nil {
+		return Installation{}, err
+	}
+
+	if err := os.RemoveAll(installation.Dir); err != nil {
+		return Installation{}, err
+	}
+	if err := os.Rename(tmpDir, installation.Dir); err != nil {
+		return Installation{}, err
+	}
+
+	return installation, nil
+}
+
+// mirrorURL returns the registry to download package managers from: an
+// explicit mirror, then GNPM_PM_MIRROR, then the configured registry
+func mirrorURL(mirror string, registryURL string) string {
+	if mirror != "" {
+		return mirror
+	}
+	if mirror := os.Getenv("GNPM_PM_MIRROR"); mirror != "" {
+		return mirror
+	}
+	if registryURL == "" {
+		return registry.DefaultRegistry
+	}
+	return registryURL
+}
+
+// packageName maps a package manager to the npm package it is published as
+func packageName(name string, version string) (string, error) {
+	switch name {
+	case "npm", "pnpm":
+		return name, nil
+	case "yarn":
+		if isYarnClassic(version) {
+			return "yarn", nil
+		}
+		return "@yarnpkg/cli-dist", nil
+	case "bun":
+		platform, err := bunPlatform()
+		if err != nil {
+			return "", err
+		}
+		return "@oven/bun-" + platform, nil
+	default:
+		return "", fmt.Errorf("gnpm can't install %s", name)
+	}
+}
+
+// isYarnClassic reports whether a version or range targets Yarn 1.x, which is
+// published as "yarn" rather than "@yarnpkg/cli-dist"
+func isYarnClassic(version string) bool {
+	rng, err := semver.ParseRange(version)
+	if err != nil {
+		return false
+	}
+	min, ok := rng.MinVersion()
+	return ok && min.Major == 1
+}
+
+// bunPlatform returns the platform suffix of Bun's per-platform npm packages
+func bunPlatform() (string, error) {
+	var arch string
+	switch runtime.GOARCH {
+	case "amd64":
+		arch = "x64"
+	case "arm64":
+		arch = "aarch64"
+	default:
+		return "", fmt.Errorf("bun is not published for %s", runtime.GOARCH)
+	}
+
+	switch runtime.GOOS {
+	case "linux", "darwin", "windows":
+		return runtime.GOOS + "-" + arch, nil
+	default:
+		return "", fmt.Errorf("bun is not published for %s", runtime.GOOS)
+	}
+}
+
+// source fetches package manager packages from a registry, or from a
+// file:// mirror laid out like one
+type source struct {
+	client *registry.Client
+	root   string // directory of a file:// mirror
+}
+
+func newSource(registryURL string, config registry.Config) source {
+	if root, ok := fileMirrorRoot(registryURL); ok {
+		return source{root: root}
+	}
+	return source{client: registry.New(registry.Options{
+		Registry: registryURL,
+		Config:   config,
+		Timeout:  downloadTimeout,
+	})}
+}
+
+// packument loads registry metadata for a package.
+// File mirrors store it at <mirror>/<package>/index.json.
+func (s source) packument(pkgName string) (*registry.Packument, error) {
+	if s.client != nil {
+		meta, err := s.client.AbbreviatedPackument(pkgName)
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s metadata: %w", pkgName, err)
+		}
+		return meta, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(pkgName), "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s metadata: %w", pkgName, err)
+	}
+	var meta registry.Packument
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("parse %s metadata: %w", pkgName, err)
+	}
+	if meta.Name == "" {
+		meta.Name = pkgName
+	}
+	// Hand-made mirrors may leave the version out of each manifest
+	for version, manifest := range meta.Versions {
+		if manifest != nil && manifest.Version == "" {
+			manifest.Version = version
+		}
+	}
+	return &meta, nil
+}
+
+// tarball downloads a package tarball and checks its integrity.
+// File mirrors store it at <mirror>/<package>/-/<file>.tgz.
+func (s source) tarball(pkgName string, version string, dist registry.Dist) ([]byte, error) {
+	if dist.Integrity == "" && dist.Shasum == "" {
+		return nil, fmt.Errorf("registry metadata has no integrity information")
+	}
+
+	fileName := path.Base(pkgName) + "-" + version + ".tgz"
+	if dist.Tarball != "" {
+		if parsed, err := url.Parse(dist.Tarball); err == nil && path.Base(parsed.Path) != "." {
+			fileName = path.Base(parsed.Path)
+		}
+	}
+
+	if s.client != nil {
+		if dist.Tarball == "" {
+			dist.Tarball = s.client.RegistryFor(pkgName) + pkgName + "/-/" + fileName
+		}
+		return s.client.Tarball(dist)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.root, filepath.FromSlash(pkgName), "-", fileName))
+	if err != nil {
+		return nil, err
+	}
+	if err := registry.VerifyIntegrity(data, dist); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// fileMirrorRoot returns the directory of a file:// mirror
+func fileMirrorRoot(registryURL string) (string, bool) {
+	if !strings.HasPrefix(registryURL, "file://") {
+		return "", false
+	}
+	parsed, err := url.Parse(registryURL)
+	if err != nil {
+		return "", false
+	}
+	return filepath.FromSlash(parsed.Path), true
+}
+
+// verifyPinnedHash checks a package manager against the hash of its
+// packageManager pin. Corepack pins Yarn 2+ by the yarn.js bundle it
+// downloads from repo.yarnpkg.com, which is bin/yarn.js of the extracted
+// @yarnpkg/cli-dist package, rather than by the npm tarball.
+func verifyPinnedHash(name string, version string, data []byte, dir string, expected string) error {
+	err := verifyHash(data, expected)
+	if err == nil || name != "yarn" || isYarnClassic(version) {
+		return err
+	}
+	bundle, readErr := os.ReadFile(filepath.Join(dir, "bin", "yarn.js"))
+	if readErr != nil {
+		return err
+	}
+	return verifyHash(bundle, expected)
+}
+
+// verifyHash checks a tarball against a packageManager "<algorithm>.<hex>" hash
+func verifyHash(data []byte, expected string) error {
+	algorithm, digest, ok := strings.Cut(expected, ".")
+	if !ok {
+		return fmt.Errorf("malformed hash %q", expected)
+	}
+	h, err := newHash(algorithm)
+	if err != nil {
+		return err
+	}
+	h.Write(data)
+	if hex.EncodeToString(h.Sum(nil)) != strings.ToLower(digest) {
+		return fmt.Errorf("hash mismatch: expected %s", expected)
+	}
+	return nil
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha224":
+		return sha256.New224(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", algorithm)
+	}
+}
+
+// readVersion reads the version from the package.json of an extracted
+// package
+func readVersion(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return "", fmt.Errorf("no package.json found in the tarball")
+	}
+	var pkg struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "", fmt.Errorf("parse package.json: %w", err)
+	}
+	if pkg.Version == "" {
+		return "", fmt.Errorf("package.json has no version")
+	}
+	return pkg.Version, nil
+}
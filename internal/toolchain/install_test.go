@@ -0,0 +1,323 @@
+package toolchain
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	projectcontext "github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/registry"
+)
+
+// buildTarball creates an npm-style tarball for a fake package manager
+func buildTarball(t *testing.T, name string, version string) []byte {
+	t.Helper()
+	return buildPackage(t, map[string]string{
+		"package/package.json":         `{"name": "` + name + `", "version": "` + version + `", "bin": {"` + name + `": "bin/` + name + `.cjs"}}`,
+		"package/bin/" + name + ".cjs": "console.log('" + version + "')\n",
+	})
+}
+
+// buildPackage creates a gzipped tarball holding the files
+func buildPackage(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for fileName, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: fileName, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeMirror creates a file-based mirror with the given versions of a package
+func writeMirror(t *testing.T, name string, versions []string, latest string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	pkgDir := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Join(pkgDir, "-"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := map[string]interface{}{
+		"dist-tags": map[string]string{"latest": latest},
+	}
+	versionMeta := map[string]interface{}{}
+	for _, version := range versions {
+		data := buildTarball(t, name, version)
+		fileName := name + "-" + version + ".tgz"
+		if err := os.WriteFile(filepath.Join(pkgDir, "-", fileName), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha512.Sum512(data)
+		versionMeta[version] = map[string]interface{}{
+			"dist": map[string]string{
+				"tarball":   "https://registry.example.com/" + name + "/-/" + fileName,
+				"integrity": "sha512-" + base64.StdEncoding.EncodeToString(sum[:]),
+			},
+		}
+	}
+	meta["versions"] = versionMeta
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "index.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return "file://" + filepath.ToSlash(root)
+}
+
+func TestInstallFromFileMirror(t *testing.T) {
+	setupCache(t)
+	mirror := writeMirror(t, "pnpm", []string{"9.0.0", "9.1.0", "10.0.0"}, "10.0.0")
+
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"9", "9.1.0"},
+		{"latest", "10.0.0"},
+		{"", "10.0.0"},
+		{"9.0.0", "9.0.0"},
+		{"^9.0.0", "9.1.0"},
+		{">=9.0.0 <10", "9.1.0"},
+		{"~9.0.0", "9.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			installation, err := Install("pnpm", tt.version, InstallOptions{Registry: mirror})
+			if err != nil {
+				t.Fatalf("Install failed: %v", err)
+			}
+			if installation.Version != tt.expected {
+				t.Fatalf("expected version %s, got %s", tt.expected, installation.Version)
+			}
+			if !strings.HasPrefix(installation.Spec(), "pnpm@"+tt.expected+"+sha512.") {
+				t.Errorf("unexpected packageManager spec %q", installation.Spec())
+			}
+			if _, err := os.Stat(filepath.Join(PMDir("pnpm", tt.expected), "bin", "pnpm.cjs")); err != nil {
+				t.Errorf("expected extracted entry point: %v", err)
+			}
+		})
+	}
+}
+
+func TestInstallRejectsBadIntegrity(t *testing.T) {
+	setupCache(t)
+	mirror := writeMirror(t, "pnpm", []string{"9.1.0"}, "9.1.0")
+
+	root := strings.TrimPrefix(mirror, "file://")
+	tarball := filepath.Join(filepath.FromSlash(root), "pnpm", "-", "pnpm-9.1.0.tgz")
+	if err := os.WriteFile(tarball, buildTarball(t, "pnpm", "6.6.6"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Install("pnpm", "9.1.0", InstallOptions{Registry: mirror})
+	if err == nil || !strings.Contains(err.Error(), "integrity") {
+		t.Fatalf("expected an integrity error, got %v", err)
+	}
+	if _, err := os.Stat(PMDir("pnpm", "9.1.0")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be installed")
+	}
+}
+
+func TestInstallRejectsVersionMismatch(t *testing.T) {
+	setupCache(t)
+	mirror := writeMirror(t, "pnpm", []string{"9.1.0"}, "9.1.0")
+
+	// A consistent mirror entry whose tarball holds another version
+	root := filepath.FromSlash(strings.TrimPrefix(mirror, "file://"))
+	data := buildTarball(t, "pnpm", "6.6.6")
+	if err := os.WriteFile(filepath.Join(root, "pnpm", "-", "pnpm-9.1.0.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha512.Sum512(data)
+	index := `{"dist-tags": {"latest": "9.1.0"}, "versions": {"9.1.0": {"version": "9.1.0", "dist": {"tarball": "https://registry.example.com/pnpm/-/pnpm-9.1.0.tgz", "integrity": "sha512-` + base64.StdEncoding.EncodeToString(sum[:]) + `"}}}}`
+	if err := os.WriteFile(filepath.Join(root, "pnpm", "index.json"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Install("pnpm", "9.1.0", InstallOptions{Registry: mirror})
+	if err == nil || !strings.Contains(err.Error(), "6.6.6") {
+		t.Fatalf("expected a version mismatch error, got %v", err)
+	}
+	if _, err := os.Stat(PMDir("pnpm", "9.1.0")); !os.IsNotExist(err) {
+		t.Error("expected nothing to be installed")
+	}
+}
+
+func TestInstallSendsNpmrcAuth(t *testing.T) {
+	setupCache(t)
+	data := buildTarball(t, "pnpm", "9.1.0")
+	sum := sha512.Sum512(data)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/pnpm":
+			w.Write([]byte(`{"name": "pnpm", "dist-tags": {"latest": "9.1.0"}, "versions": {"9.1.0": {"version": "9.1.0", "dist": {"tarball": "` + server.URL + `/pnpm/-/pnpm-9.1.0.tgz", "integrity": "sha512-` + base64.StdEncoding.EncodeToString(sum[:]) + `"}}}}`))
+		case "/pnpm/-/pnpm-9.1.0.tgz":
+			w.Write(data)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	config := registry.Config{"//" + strings.TrimPrefix(server.URL, "http://") + "/:_authToken": "secret"}
+	installation, err := Install("pnpm", "9.1.0", InstallOptions{Registry: server.URL, Config: config})
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if installation.Version != "9.1.0" {
+		t.Errorf("unexpected installation %+v", installation)
+	}
+}
+
+func TestMirrorURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		mirror     string
+		env        string
+		configured string
+		expected   string
+	}{
+		{"flag wins over env", "https://flag.example.com/", "https://env.example.com/", "https://npmrc.example.com/", "https://flag.example.com/"},
+		{"env wins over npmrc", "", "https://env.example.com/", "https://npmrc.example.com/", "https://env.example.com/"},
+		{"npmrc", "", "", "https://npmrc.example.com/", "https://npmrc.example.com/"},
+		{"default", "", "", "", registry.DefaultRegistry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GNPM_PM_MIRROR", tt.env)
+			if got := mirrorURL(tt.mirror, tt.configured); got != tt.expected {
+				t.Errorf("mirrorURL = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstallFromTarballVerifiesHash(t *testing.T) {
+	setupCache(t)
+
+	data := buildTarball(t, "yarn", "4.1.0")
+	tarball := filepath.Join(t.TempDir(), "yarn.tgz")
+	if err := os.WriteFile(tarball, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Install("yarn", "", InstallOptions{Tarball: tarball, Hash: "sha512.deadbeef"}); err == nil {
+		t.Fatal("expected a hash mismatch error")
+	}
+
+	sum := sha512.Sum512(data)
+	hash := "sha512." + hex.EncodeToString(sum[:])
+	installation, err := Install("yarn", "", InstallOptions{Tarball: tarball, Hash: hash})
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if installation.Version != "4.1.0" || installation.Hash != hash {
+		t.Errorf("unexpected installation %+v", installation)
+	}
+}
+
+func TestResolveCorepackYarnBerryPin(t *testing.T) {
+	setupCache(t)
+
+	// @yarnpkg/cli-dist as published on npm
+	bundle := "#!/usr/bin/env node\n// yarn 4.1.0 bundle\n"
+	data := buildPackage(t, map[string]string{
+		"package/package.json": `{"name": "@yarnpkg/cli-dist", "version": "4.1.0", "bin": {"yarn": "bin/yarn.js"}}`,
+		"package/bin/yarn.js":  bundle,
+	})
+	root := t.TempDir()
+	pkgDir := filepath.Join(root, "@yarnpkg", "cli-dist")
+	if err := os.MkdirAll(filepath.Join(pkgDir, "-"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "-", "cli-dist-4.1.0.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	integrity := sha512.Sum512(data)
+	index := `{"dist-tags": {"latest": "4.1.0"}, "versions": {"4.1.0": {"version": "4.1.0", "dist": {"tarball": "https://registry.yarnpkg.com/@yarnpkg/cli-dist/-/cli-dist-4.1.0.tgz", "integrity": "sha512-` + base64.StdEncoding.EncodeToString(integrity[:]) + `"}}}}`
+	if err := os.WriteFile(filepath.Join(pkgDir, "index.json"), []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// corepack use yarn@4.1.0 pins the hash of the yarn.js bundle it
+	// downloads from repo.yarnpkg.com
+	sum := sha512.Sum512([]byte(bundle))
+	field := "yarn@4.1.0+sha512." + hex.EncodeToString(sum[:])
+	spec, ok := projectcontext.ParsePackageManagerSpec(field)
+	if !ok {
+		t.Fatalf("could not parse %s", field)
+	}
+
+	bin, err := Resolve(pmcombo.Yarn, &spec, t.TempDir(), ResolveOptions{Registry: "file://" + filepath.ToSlash(root)})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if bin.Source != SourceGnpm || bin.Version != "4.1.0" {
+		t.Fatalf("expected yarn installed into the gnpm cache, got %+v", bin)
+	}
+
+	// The cached entry records the pin, so it is used offline afterwards
+	if bin, err := Resolve(pmcombo.Yarn, &spec, t.TempDir(), ResolveOptions{Offline: true}); err != nil || bin.Source != SourceGnpm {
+		t.Fatalf("expected the cached yarn offline, got %+v, %v", bin, err)
+	}
+
+	spec.Hash = "sha512." + strings.Repeat("0", 128)
+	if err := os.RemoveAll(PMDir("yarn", "4.1.0")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Install("yarn", "4.1.0", InstallOptions{Registry: "file://" + filepath.ToSlash(root), Hash: spec.Hash}); err == nil || !strings.Contains(err.Error(), "hash mismatch") {
+		t.Fatalf("expected a hash mismatch, got %v", err)
+	}
+}
+
+func TestResolveInstallsMissingVersion(t *testing.T) {
+	setupCache(t)
+	mirror := writeMirror(t, "pnpm", []string{"9.1.0"}, "9.1.0")
+
+	spec := &projectcontext.PackageManagerSpec{Name: "pnpm", Version: "9.1.0", Source: projectcontext.SpecSourcePackageManager}
+	bin, err := Resolve(pmcombo.PNPM, spec, t.TempDir(), ResolveOptions{Registry: mirror})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if bin.Source != SourceGnpm || bin.Version != "9.1.0" {
+		t.Errorf("expected pnpm installed into the gnpm cache, got %+v", bin)
+	}
+}
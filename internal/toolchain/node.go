@@ -0,0 +1,260 @@
+package toolchain
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	projectcontext "github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+// NodeRequirement is the Node.js version a project asks for
+type NodeRequirement struct {
+	Spec   string // e.g. "20", "v20.11.0", ">=18", "lts/iron"
+	Source string // file or field the spec came from
+}
+
+// NodeInstallation is a locally installed Node.js version
+type NodeInstallation struct {
+	Version string
+	BinDir  string
+	Source  string // nvm, fnm, volta, gnpm or PATH
+}
+
+// ltsCodenames maps Node.js LTS codenames to their major version
+var ltsCodenames = map[string]uint64{
+	"argon":    4,
+	"boron":    6,
+	"carbon":   8,
+	"dubnium":  10,
+	"erbium":   12,
+	"fermium":  14,
+	"gallium":  16,
+	"hydrogen": 18,
+	"iron":     20,
+	"jod":      22,
+	"krypton":  24,
+}
+
+// DetectNodeRequirement finds the Node.js version required for dir.
+// Version files (.nvmrc, .node-version, .tool-versions) and volta.node are
+// searched walking up the tree; engines.node of the nearest package.json is
+// used when none of those exist.
+func DetectNodeRequirement(dir string) (NodeRequirement, bool) {
+	current := dir
+	for {
+		if req, ok := readVersionFiles(current); ok {
+			return req, true
+		}
+
+		pkgPath := filepath.Join(current, "package.json")
+		if pkg, err := projectcontext.ReadPackageJSON(pkgPath); err == nil && pkg.Volta.Node != "" {
+			return NodeRequirement{Spec: pkg.Volta.Node, Source: pkgPath + " (volta.node)"}, true
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	if pkgPath, err := projectcontext.FindPackageJSON(dir); err == nil {
+		if pkg, err := projectcontext.ReadPackageJSON(pkgPath); err == nil && pkg.Engines["node"] != "" {
+			return NodeRequirement{Spec: pkg.Engines["node"], Source: pkgPath + " (engines.node)"}, true
+		}
+	}
+
+	return NodeRequirement{}, false
+}
+
+// readVersionFiles reads .nvmrc, .node-version and .tool-versions in dir
+func readVersionFiles(dir string) (NodeRequirement, bool) {
+	for _, name := range []string{".nvmrc", ".node-version"} {
+		path := filepath.Join(dir, name)
+		if spec := readFirstLine(path); spec != "" {
+			return NodeRequirement{Spec: spec, Source: path}, true
+		}
+	}
+
+	path := filepath.Join(dir, ".tool-versions")
+	file, err := os.Open(path)
+	if err != nil {
+		return NodeRequirement{}, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && (fields[0] == "nodejs" || fields[0] == "node") {
+			return NodeRequirement{Spec: fields[1], Source: path}, true
+		}
+	}
+	return NodeRequirement{}, false
+}
+
+// readFirstLine returns the first non-comment line of a file
+func readFirstLine(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// ResolveNode picks the Node.js installation for a requirement. The node on
+// PATH wins when it already satisfies the requirement; otherwise the highest
+// matching version installed by nvm, fnm, volta or gnpm is used.
+func ResolveNode(req NodeRequirement, dir string) (NodeInstallation, error) {
+	if version, err := ProbeVersion("node", dir); err == nil && NodeVersionMatches(version, req.Spec) {
+		return NodeInstallation{Version: version, Source: SourcePath}, nil
+	}
+
+	var best *NodeInstallation
+	for _, installation := range InstalledNodes() {
+		if !NodeVersionMatches(installation.Version, req.Spec) {
+			continue
+		}
+		if best == nil || semver.Compare(installation.Version, best.Version) > 0 {
+			candidate := installation
+			best = &candidate
+		}
+	}
+
+	if best == nil {
+		return NodeInstallation{}, fmt.Errorf("node %s required by %s is not installed", req.Spec, req.Source)
+	}
+	return *best, nil
+}
+
+// NodeVersionMatches reports whether a Node.js version satisfies a version spec
+// such as "20", "lts/*", "lts/iron" or ">=18 <21"
+func NodeVersionMatches(version string, spec string) bool {
+	spec = strings.TrimSpace(spec)
+	v, err := semver.Parse(version)
+	if err != nil {
+		return false
+	}
+
+	switch lower := strings.ToLower(spec); {
+	case lower == "node" || lower == "stable" || lower == "latest" || lower == "current" || lower == "system":
+		return true
+	case lower == "lts/*" || lower == "lts":
+		return v.Major >= 4 && v.Major%2 == 0
+	case strings.HasPrefix(lower, "lts/"):
+		major, ok := ltsCodenames[strings.TrimPrefix(lower, "lts/")]
+		return ok && v.Major == major
+	}
+
+	rng, err := semver.ParseRange(spec)
+	if err != nil {
+		return false
+	}
+	return rng.Contains(v)
+}
+
+// InstalledNodes lists Node.js versions installed by known version managers
+func InstalledNodes() []NodeInstallation {
+	var installations []NodeInstallation
+	home, _ := os.UserHomeDir()
+
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" && home != "" {
+		nvmDir = filepath.Join(home, ".nvm")
+	}
+	installations = append(installations, scanNodeVersions(filepath.Join(nvmDir, "versions", "node"), "", "nvm")...)
+
+	for _, fnmDir := range fnmDirs(home) {
+		installations = append(installations, scanNodeVersions(filepath.Join(fnmDir, "node-versions"), "installation", "fnm")...)
+	}
+
+	voltaHome := os.Getenv("VOLTA_HOME")
+	if voltaHome == "" && home != "" {
+		voltaHome = filepath.Join(home, ".volta")
+	}
+	installations = append(installations, scanNodeVersions(filepath.Join(voltaHome, "tools", "image", "node"), "", "volta")...)
+
+	installations = append(installations, scanNodeVersions(filepath.Join(CacheDir(), "node"), "", "gnpm")...)
+
+	sort.SliceStable(installations, func(i, j int) bool {
+		return semver.Compare(installations[i].Version, installations[j].Version) > 0
+	})
+	return installations
+}
+
+// fnmDirs returns the directories fnm may keep its installations in
+func fnmDirs(home string) []string {
+	if dir := os.Getenv("FNM_DIR"); dir != "" {
+		return []string{dir}
+	}
+
+	var dirs []string
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "fnm"))
+	}
+	if home != "" {
+		dirs = append(dirs,
+			filepath.Join(home, ".local", "share", "fnm"),
+			filepath.Join(home, ".fnm"),
+			filepath.Join(home, "Library", "Application Support", "fnm"),
+		)
+	}
+	return dirs
+}
+
+// scanNodeVersions lists version directories (v20.11.0 or 20.11.0) under root
+func scanNodeVersions(root string, subdir string, source string) []NodeInstallation {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil
+	}
+
+	var installations []NodeInstallation
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		version := strings.TrimPrefix(entry.Name(), "v")
+		if !semver.Valid(version) {
+			continue
+		}
+
+		installDir := filepath.Join(root, entry.Name(), subdir)
+		binDir := filepath.Join(installDir, "bin")
+		if runtime.GOOS == "windows" {
+			binDir = installDir
+		}
+		if !fileExists(filepath.Join(binDir, nodeExecutable())) {
+			continue
+		}
+
+		installations = append(installations, NodeInstallation{
+			Version: version,
+			BinDir:  binDir,
+			Source:  source,
+		})
+	}
+	return installations
+}
+
+func nodeExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "node.exe"
+	}
+	return "node"
+}
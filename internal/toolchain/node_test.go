@@ -0,0 +1,144 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectNodeRequirement(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		dir      string
+		expected string
+	}{
+		{
+			name:     "nvmrc",
+			files:    map[string]string{".nvmrc": "v20.11.0\n", "package.json": `{"engines": {"node": ">=18"}}`},
+			expected: "v20.11.0",
+		},
+		{
+			name:     "node-version in parent",
+			files:    map[string]string{".node-version": "18\n", "packages/a/package.json": `{}`},
+			dir:      "packages/a",
+			expected: "18",
+		},
+		{
+			name:     "tool-versions",
+			files:    map[string]string{".tool-versions": "python 3.12.0\nnodejs 20.10.0\n"},
+			expected: "20.10.0",
+		},
+		{
+			name:     "volta",
+			files:    map[string]string{"package.json": `{"volta": {"node": "20.9.0"}, "engines": {"node": ">=16"}}`},
+			expected: "20.9.0",
+		},
+		{
+			name:     "engines fallback",
+			files:    map[string]string{"package.json": `{"engines": {"node": ">=18 <21"}}`},
+			expected: ">=18 <21",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for name, content := range tt.files {
+				writeFile(t, filepath.Join(root, name), content)
+			}
+
+			req, ok := DetectNodeRequirement(filepath.Join(root, tt.dir))
+			if !ok {
+				t.Fatal("expected a node requirement")
+			}
+			if req.Spec != tt.expected {
+				t.Errorf("expected %q, got %q (from %s)", tt.expected, req.Spec, req.Source)
+			}
+		})
+	}
+}
+
+func TestNodeVersionMatches(t *testing.T) {
+	tests := []struct {
+		version  string
+		spec     string
+		expected bool
+	}{
+		{"20.11.0", "20", true},
+		{"20.11.0", "v20.11.0", true},
+		{"20.11.1", "20.11.0", false},
+		{"20.11.1", "20.11", true},
+		{"18.19.0", ">=18", true},
+		{"16.20.0", ">=18", false},
+		{"20.11.0", ">=18 <21", true},
+		{"21.0.0", ">=18 <21", false},
+		{"20.11.0", "^20.10.0", true},
+		{"21.0.0", "^20.10.0", false},
+		{"18.5.0", "~18.4", false},
+		{"18.4.9", "~18.4", true},
+		{"16.1.0", "^14 || ^16", true},
+		{"18.0.0", "18.x", true},
+		{"19.0.0", "18.x", false},
+		{"18.1.0", "16 - 18", true},
+		{"20.0.0", "lts/*", true},
+		{"21.0.0", "lts/*", false},
+		{"20.11.0", "lts/iron", true},
+		{"18.19.0", "lts/iron", false},
+		{"22.1.0", "node", true},
+	}
+
+	for _, tt := range tests {
+		if got := NodeVersionMatches(tt.version, tt.spec); got != tt.expected {
+			t.Errorf("NodeVersionMatches(%q, %q) = %v, expected %v", tt.version, tt.spec, got, tt.expected)
+		}
+	}
+}
+
+func TestResolveNodeFromVersionManagers(t *testing.T) {
+	setupCache(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("NVM_DIR", filepath.Join(home, ".nvm"))
+	t.Setenv("VOLTA_HOME", filepath.Join(home, ".volta"))
+	t.Setenv("FNM_DIR", filepath.Join(home, "fnm"))
+
+	writeFile(t, filepath.Join(home, ".nvm", "versions", "node", "v18.19.0", "bin", "node"), "")
+	writeFile(t, filepath.Join(home, ".nvm", "versions", "node", "v20.10.0", "bin", "node"), "")
+	writeFile(t, filepath.Join(home, "fnm", "node-versions", "v20.11.0", "installation", "bin", "node"), "")
+	writeFile(t, filepath.Join(home, ".volta", "tools", "image", "node", "16.20.0", "bin", "node"), "")
+
+	tests := []struct {
+		spec    string
+		version string
+		source  string
+	}{
+		{"20", "20.11.0", "fnm"},
+		{"18", "18.19.0", "nvm"},
+		{"<17", "16.20.0", "volta"},
+	}
+
+	for _, tt := range tests {
+		installation, err := ResolveNode(NodeRequirement{Spec: tt.spec, Source: ".nvmrc"}, home)
+		if err != nil {
+			t.Fatalf("ResolveNode(%q) failed: %v", tt.spec, err)
+		}
+		if installation.Version != tt.version || installation.Source != tt.source {
+			t.Errorf("ResolveNode(%q) = %+v, expected %s from %s", tt.spec, installation, tt.version, tt.source)
+		}
+	}
+
+	if _, err := ResolveNode(NodeRequirement{Spec: "22", Source: ".nvmrc"}, home); err == nil {
+		t.Error("expected an error when no installed version matches")
+	}
+}
@@ -0,0 +1,221 @@
+package toolchain
+
+import (
+	stdcontext "context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	projectcontext "github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/logger"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+	"github.com/AkaraChen/gnpm/internal/registry"
+	"github.com/AkaraChen/gnpm/internal/semver"
+)
+
+const versionProbeTimeout = 3 * time.Second
+
+// Binary sources
+const (
+	SourcePath     = "PATH"
+	SourceGnpm     = "gnpm cache"
+	SourceCorepack = "corepack cache"
+)
+
+// Binary is a resolved package manager executable
+type Binary struct {
+	Path    string   // program to execute
+	Args    []string // arguments placed before the package manager arguments
+	Version string   // resolved version, empty when unknown
+	Source  string   // where the binary was found
+}
+
+// Command builds an exec.Cmd running the binary with the given arguments
+func (b Binary) Command(args ...string) *exec.Cmd {
+	cmdArgs := append(append([]string{}, b.Args...), args...)
+	return exec.Command(b.Path, cmdArgs...)
+}
+
+// ResolveOptions controls package manager resolution
+type ResolveOptions struct {
+	Offline  bool            // never download missing package managers
+	Registry string          // registry or mirror to download from
+	Config   registry.Config // .npmrc settings for auth, proxies and TLS
+}
+
+// Offline reports whether gnpm should avoid the network when resolving
+// package managers (GNPM_OFFLINE=1 or COREPACK_ENABLE_NETWORK=0)
+func Offline() bool {
+	if value := os.Getenv("GNPM_OFFLINE"); value != "" && value != "0" && value != "false" {
+		return true
+	}
+	return os.Getenv("COREPACK_ENABLE_NETWORK") == "0"
+}
+
+// Resolve finds the executable for a package manager, honoring the version
+// pinned in package.json. Without a pin the bare executable from PATH is used.
+// Range pins (common in devEngines) use the highest cached match, then a
+// matching PATH version, then install the highest matching release.
+func Resolve(pm pmcombo.PackageManager, spec *projectcontext.PackageManagerSpec, dir string, opts ResolveOptions) (Binary, error) {
+	pathBinary := Binary{Path: pm.Executable(), Source: SourcePath}
+
+	if spec == nil || spec.Version == "" || spec.Name != pm.Executable() {
+		return pathBinary, nil
+	}
+
+	rng, err := semver.ParseRange(spec.Version)
+	if err != nil {
+		// Dist-tags can't be matched against local versions
+		return pathBinary, nil
+	}
+
+	name := spec.Name
+
+	for _, candidate := range cachedCandidates(name, spec.Version, rng) {
+		if bin, ok := resolveCached(candidate.dir, name, candidate.version, spec.Hash, candidate.source); ok {
+			return bin, nil
+		}
+	}
+
+	pathVersion, pathErr := ProbeVersion(pm.Executable(), dir)
+	if pathErr == nil {
+		if v, err := semver.Parse(pathVersion); err == nil && rng.Contains(v) {
+			pathBinary.Version = pathVersion
+			return pathBinary, nil
+		}
+	}
+
+	if opts.Offline {
+		return pathBinary, offlineError(spec, pathVersion, pathErr)
+	}
+
+	logger.Info("Installing %s@%s...", name, spec.Version)
+	installation, err := Install(name, spec.Version, InstallOptions{
+		Registry: opts.Registry,
+		Config:   opts.Config,
+		Hash:     spec.Hash,
+	})
+	if err != nil {
+		return pathBinary, fmt.Errorf("install %s pinned by %s: %w", spec, spec.Source, err)
+	}
+	if bin, ok := resolveCached(installation.Dir, name, installation.Version, spec.Hash, SourceGnpm); ok {
+		return bin, nil
+	}
+	return pathBinary, fmt.Errorf("%s was installed to %s but has no executable", spec, installation.Dir)
+}
+
+// cacheCandidate is a cached package manager version that may satisfy a pin
+type cacheCandidate struct {
+	dir     string
+	version string
+	source  string
+}
+
+// cachedCandidates lists cached versions matching a pin, highest first, with
+// gnpm's cache preferred over corepack's for the same version
+func cachedCandidates(name string, pinned string, rng semver.Range) []cacheCandidate {
+	if semver.Valid(pinned) {
+		return []cacheCandidate{
+			{dir: PMDir(name, pinned), version: pinned, source: SourceGnpm},
+			{dir: corepackDir(name, pinned), version: pinned, source: SourceCorepack},
+		}
+	}
+
+	var candidates []cacheCandidate
+	for _, root := range []struct {
+		dir    string
+		source string
+	}{
+		{filepath.Dir(PMDir(name, "0.0.0")), SourceGnpm},
+		{filepath.Dir(corepackDir(name, "0.0.0")), SourceCorepack},
+	} {
+		entries, err := os.ReadDir(root.dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			v, err := semver.Parse(entry.Name())
+			if !entry.IsDir() || err != nil || !rng.Contains(v) {
+				continue
+			}
+			candidates = append(candidates, cacheCandidate{
+				dir:     filepath.Join(root.dir, entry.Name()),
+				version: entry.Name(),
+				source:  root.source,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return semver.Compare(candidates[i].version, candidates[j].version) > 0
+	})
+	return candidates
+}
+
+// resolveCached returns a Binary for a package manager cached in dir. With a
+// pinned hash, only entries recording that same hash are used: an entry
+// without one was never checked against it.
+func resolveCached(dir string, name string, version string, hash string, source string) (Binary, bool) {
+	if dir == "" {
+		return Binary{}, false
+	}
+	entry, ok := findEntry(dir, name)
+	if !ok {
+		return Binary{}, false
+	}
+	if hash != "" {
+		if meta, ok := readMetadata(dir); !ok || meta.Hash != hash {
+			return Binary{}, false
+		}
+	}
+
+	if isScript(entry) {
+		return Binary{Path: "node", Args: []string{entry}, Version: version, Source: source}, true
+	}
+	return Binary{Path: entry, Version: version, Source: source}, true
+}
+
+// offlineError explains why a pinned package manager can't be used offline
+func offlineError(spec *projectcontext.PackageManagerSpec, pathVersion string, pathErr error) error {
+	found := fmt.Sprintf("PATH has %s %s", spec.Name, pathVersion)
+	if pathErr != nil {
+		found = fmt.Sprintf("%s is not on PATH", spec.Name)
+	}
+
+	return fmt.Errorf(
+		"%s@%s is pinned by %s but is not available offline (%s and it is not in the gnpm or corepack cache); run gnpm use %s@%s while online or import a tarball with gnpm use --from",
+		spec.Name, spec.Version, spec.Source, found, spec.Name, spec.Version,
+	)
+}
+
+// ProbeVersion runs "<executable> --version" in dir and returns the reported version
+func ProbeVersion(executable string, dir string) (string, error) {
+	if _, err := exec.LookPath(executable); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := stdcontext.WithTimeout(stdcontext.Background(), versionProbeTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executable, "--version")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "COREPACK_ENABLE_DOWNLOAD_PROMPT=0")
+
+	output, err := cmd.Output()
+	if ctx.Err() == stdcontext.DeadlineExceeded {
+		return "", fmt.Errorf("%s --version timed out", executable)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s --version failed: %w", executable, err)
+	}
+
+	version, ok := semver.Extract(string(output))
+	if !ok {
+		return "", fmt.Errorf("%s returned an unparseable version: %q", executable, strings.TrimSpace(string(output)))
+	}
+	return version.String(), nil
+}
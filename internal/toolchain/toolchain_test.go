@@ -0,0 +1,159 @@
+package toolchain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	projectcontext "github.com/AkaraChen/gnpm/internal/context"
+	"github.com/AkaraChen/gnpm/internal/pmcombo"
+)
+
+func setupCache(t *testing.T) {
+	t.Helper()
+	t.Setenv("GNPM_CACHE_DIR", t.TempDir())
+	t.Setenv("COREPACK_HOME", t.TempDir())
+	t.Setenv("PATH", t.TempDir())
+}
+
+func writeFakePM(t *testing.T, dir string, name string, meta string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pkg := `{"name": "` + name + `", "bin": {"` + name + `": "bin/` + name + `.cjs"}}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin", name+".cjs"), []byte("// fake\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if meta != "" {
+		if err := os.WriteFile(filepath.Join(dir, metadataFile), []byte(meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolveWithoutSpecUsesPath(t *testing.T) {
+	setupCache(t)
+
+	bin, err := Resolve(pmcombo.PNPM, nil, t.TempDir(), ResolveOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bin.Path != "pnpm" || bin.Source != SourcePath {
+		t.Errorf("expected bare pnpm from PATH, got %+v", bin)
+	}
+}
+
+func TestResolveFromGnpmCache(t *testing.T) {
+	setupCache(t)
+	writeFakePM(t, PMDir("pnpm", "9.1.0"), "pnpm", "")
+
+	spec := &projectcontext.PackageManagerSpec{Name: "pnpm", Version: "9.1.0", Source: projectcontext.SpecSourcePackageManager}
+	bin, err := Resolve(pmcombo.PNPM, spec, t.TempDir(), ResolveOptions{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bin.Source != SourceGnpm || bin.Path != "node" || bin.Version != "9.1.0" {
+		t.Fatalf("expected node running the cached pnpm, got %+v", bin)
+	}
+	if len(bin.Args) != 1 || !strings.HasSuffix(bin.Args[0], filepath.Join("bin", "pnpm.cjs")) {
+		t.Errorf("expected the cached entry point, got %v", bin.Args)
+	}
+}
+
+func TestResolveFromCorepackCache(t *testing.T) {
+	setupCache(t)
+	writeFakePM(t, corepackDir("yarn", "4.1.0"), "yarn", "")
+
+	spec := &projectcontext.PackageManagerSpec{Name: "yarn", Version: "4.1.0", Source: projectcontext.SpecSourcePackageManager}
+	bin, err := Resolve(pmcombo.Yarn, spec, t.TempDir(), ResolveOptions{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bin.Source != SourceCorepack {
+		t.Errorf("expected corepack cache, got %+v", bin)
+	}
+}
+
+func TestResolveChecksPinnedHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		dir    func() string
+		meta   string
+		source string // empty when the entry must not be used
+	}{
+		{"matching hash", func() string { return PMDir("pnpm", "9.1.0") }, `{"hash": "sha512.bbb"}`, SourceGnpm},
+		{"different hash", func() string { return PMDir("pnpm", "9.1.0") }, `{"hash": "sha512.aaa"}`, ""},
+		{"no recorded hash", func() string { return PMDir("pnpm", "9.1.0") }, `{"name": "pnpm", "version": "9.1.0"}`, ""},
+		{"no metadata", func() string { return PMDir("pnpm", "9.1.0") }, "", ""},
+		{"unverified corepack entry", func() string { return corepackDir("pnpm", "9.1.0") }, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupCache(t)
+			writeFakePM(t, tt.dir(), "pnpm", tt.meta)
+
+			spec := &projectcontext.PackageManagerSpec{Name: "pnpm", Version: "9.1.0", Hash: "sha512.bbb", Source: projectcontext.SpecSourcePackageManager}
+			bin, err := Resolve(pmcombo.PNPM, spec, t.TempDir(), ResolveOptions{Offline: true})
+			if tt.source == "" {
+				if err == nil {
+					t.Fatalf("expected the cache entry to be skipped, got %+v", bin)
+				}
+				return
+			}
+			if err != nil || bin.Source != tt.source {
+				t.Fatalf("expected the %s entry, got %+v, %v", tt.source, bin, err)
+			}
+		})
+	}
+}
+
+func TestResolveOfflineError(t *testing.T) {
+	setupCache(t)
+
+	spec := &projectcontext.PackageManagerSpec{Name: "pnpm", Version: "10.0.0", Source: projectcontext.SpecSourceDevEngines}
+	_, err := Resolve(pmcombo.PNPM, spec, t.TempDir(), ResolveOptions{Offline: true})
+	if err == nil {
+		t.Fatal("expected an error when the pinned version is unavailable")
+	}
+	for _, want := range []string{"pnpm@10.0.0", "devEngines.packageManager", "offline"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %q", want, err)
+		}
+	}
+}
+
+func TestResolveRangeUsesHighestCachedMatch(t *testing.T) {
+	setupCache(t)
+	for _, version := range []string{"10.1.0", "10.4.0", "11.0.0"} {
+		writeFakePM(t, PMDir("pnpm", version), "pnpm", "")
+	}
+	writeFakePM(t, corepackDir("pnpm", "10.2.0"), "pnpm", "")
+
+	spec := &projectcontext.PackageManagerSpec{Name: "pnpm", Version: "^10.0.0", Source: projectcontext.SpecSourceDevEngines}
+	bin, err := Resolve(pmcombo.PNPM, spec, t.TempDir(), ResolveOptions{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bin.Source != SourceGnpm || bin.Version != "10.4.0" {
+		t.Errorf("expected cached pnpm 10.4.0, got %+v", bin)
+	}
+}
+
+func TestResolveIgnoresDistTags(t *testing.T) {
+	setupCache(t)
+
+	spec := &projectcontext.PackageManagerSpec{Name: "pnpm", Version: "latest", Source: projectcontext.SpecSourceDevEngines}
+	bin, err := Resolve(pmcombo.PNPM, spec, t.TempDir(), ResolveOptions{Offline: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bin.Source != SourcePath {
+		t.Errorf("expected PATH binary for a dist-tag, got %+v", bin)
+	}
+}
@@ -0,0 +1,116 @@
+package watch
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AkaraChen/gnpm/internal/taskcache"
+)
+
+// ignoreRule is one pattern from a .gitignore file
+type ignoreRule struct {
+	base    string // directory of the .gitignore file
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// gitignore holds the rules of the .gitignore files that apply to a tree,
+// in the order git reads them: outer files first, later rules winning
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// load adds the rules of dir's .gitignore file, if it has one
+func (g *gitignore) load(dir string) {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(dir, scanner.Text()); ok {
+			g.rules = append(g.rules, rule)
+		}
+	}
+}
+
+// loadParents adds the .gitignore files above dir, up to the root of its
+// git repository
+func (g *gitignore) loadParents(dir string) {
+	var parents []string
+	for current := dir; ; {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			// Not in a repository: only dir's own files count
+			return
+		}
+		current = parent
+		parents = append(parents, current)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		g.load(parents[i])
+	}
+}
+
+// parseIgnoreLine parses a .gitignore line: blank lines and comments are
+// skipped, ! negates, a trailing / only matches directories, and a pattern
+// with a / in it is relative to the .gitignore file rather than matching
+// at any depth
+func parseIgnoreLine(base string, line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if strings.HasSuffix(line, "\\") {
+		line += " "
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+	rule.pattern = line
+	return rule, true
+}
+
+// ignored reports whether the file or directory at path is ignored
+func (g *gitignore) ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range g.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		if taskcache.Match(rule.pattern, filepath.ToSlash(rel)) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
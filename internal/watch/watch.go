@@ -0,0 +1,178 @@
+package watch
+
+import (
+	stdcontext "context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/AkaraChen/gnpm/internal/taskcache"
+)
+
+// Defaults for Options
+const (
+	DefaultInterval = 250 * time.Millisecond
+	DefaultDebounce = 200 * time.Millisecond
+)
+
+// skipDirs are never watched: installed dependencies and version control
+var skipDirs = map[string]bool{"node_modules": true, ".git": true}
+
+// Options configure what Watch looks at
+type Options struct {
+	// Include selects the watched files with globs relative to the
+	// directory; every file by default
+	Include []string
+	// Ignore leaves out files on top of those .gitignore ignores
+	Ignore []string
+
+	// Interval is how often files are checked
+	Interval time.Duration
+	// Debounce is how long files must stay unchanged before a burst of
+	// changes is reported
+	Debounce time.Duration
+}
+
+// fileState is what a change is detected from
+type fileState struct {
+	size    int64
+	modTime int64 // nanoseconds
+	mode    fs.FileMode
+}
+
+// watcher polls a directory for changed files. Polling needs no platform
+// support and copes with editors that replace files on save.
+type watcher struct {
+	dir     string
+	include taskcache.Matcher
+	ignore  taskcache.Matcher
+	parents gitignore
+}
+
+func newWatcher(dir string, opts Options) *watcher {
+	w := &watcher{dir: dir, include: taskcache.Matcher(opts.Include), ignore: taskcache.Matcher(opts.Ignore)}
+	if len(w.include) == 0 {
+		w.include = taskcache.Matcher{"**"}
+	}
+	w.parents.loadParents(dir)
+	return w
+}
+
+// snapshot records the state of the watched files. node_modules, .git,
+// nested packages and files ignored by .gitignore are left out.
+func (w *watcher) snapshot() map[string]fileState {
+	files := map[string]fileState{}
+	ignore := w.parents
+	ignore.rules = append([]ignoreRule(nil), w.parents.rules...)
+	ignore.load(w.dir)
+
+	filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == w.dir {
+			// Files can vanish while we walk; the next snapshot sees that
+			return nil
+		}
+		rel, err := filepath.Rel(w.dir, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if skipDirs[d.Name()] || ignore.ignored(path, true) || w.ignore.Match(rel) {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "package.json")); err == nil {
+				return filepath.SkipDir
+			}
+			ignore.load(path)
+			return nil
+		}
+		if ignore.ignored(path, false) || w.ignore.Match(rel) || !w.include.Match(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano(), mode: info.Mode()}
+		return nil
+	})
+	return files
+}
+
+// changed lists the files added, removed or modified between two
+// snapshots, sorted
+func changed(prev map[string]fileState, next map[string]fileState) []string {
+	var changed []string
+	for name, state := range next {
+		if old, ok := prev[name]; !ok || old != state {
+			changed = append(changed, name)
+		}
+	}
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Watch checks the files under dir until ctx is done, sending the files
+// changed in each burst of changes. The channel is closed when ctx is done.
+func Watch(ctx stdcontext.Context, dir string, opts Options) <-chan []string {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	w := newWatcher(dir, opts)
+	out := make(chan []string)
+	go func() {
+		defer close(out)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+
+		prev := w.snapshot()
+		pending := map[string]bool{}
+		var lastChange time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			next := w.snapshot()
+			names := changed(prev, next)
+			prev = next
+			if len(names) > 0 {
+				for _, name := range names {
+					pending[name] = true
+				}
+				lastChange = time.Now()
+				continue
+			}
+			if len(pending) == 0 || time.Since(lastChange) < opts.Debounce {
+				continue
+			}
+
+			batch := make([]string, 0, len(pending))
+			for name := range pending {
+				batch = append(batch, name)
+			}
+			sort.Strings(batch)
+			pending = map[string]bool{}
+			select {
+			case out <- batch:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
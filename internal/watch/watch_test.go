@@ -0,0 +1,128 @@
+package watch
+
+import (
+	stdcontext "context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func names(files map[string]fileState) []string {
+	var list []string
+	for name := range files {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func TestParseIgnoreLine(t *testing.T) {
+	for line, want := range map[string]ignoreRule{
+		"dist":      {pattern: "**/dist"},
+		"dist/":     {pattern: "**/dist", dirOnly: true},
+		"/build":    {pattern: "build"},
+		"src/gen/*": {pattern: "src/gen/*"},
+		"!keep.log": {pattern: "**/keep.log", negate: true},
+		`\#literal`: {pattern: "**/#literal"},
+		"*.log   ":  {pattern: "**/*.log"},
+	} {
+		got, ok := parseIgnoreLine("", line)
+		if !ok || got != want {
+			t.Errorf("parseIgnoreLine(%q) = %+v, %v; want %+v", line, got, ok, want)
+		}
+	}
+	for _, line := range []string{"", "   ", "# comment", "/"} {
+		if _, ok := parseIgnoreLine("", line); ok {
+			t.Errorf("parseIgnoreLine(%q) should be skipped", line)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{
+		".gitignore":              "*.log\n",
+		"pkg/.gitignore":          "dist/\n/generated.ts\n!keep.log\n",
+		"pkg/package.json":        "{}",
+		"pkg/src/index.ts":        "index",
+		"pkg/src/generated.ts":    "nested, not anchored here",
+		"pkg/generated.ts":        "ignored",
+		"pkg/debug.log":           "ignored by the parent",
+		"pkg/keep.log":            "re-included",
+		"pkg/dist/index.js":       "ignored",
+		"pkg/src/sub/.gitignore":  "*.tmp\n",
+		"pkg/src/sub/a.tmp":       "ignored",
+		"pkg/other/b.tmp":         "not under sub",
+		"pkg/node_modules/x/x.js": "skipped",
+		"pkg/nested/package.json": "{}",
+		"pkg/nested/index.js":     "skipped",
+		"pkg/src/index.test.ts":   "ignored by option",
+	})
+
+	w := newWatcher(filepath.Join(root, "pkg"), Options{Ignore: []string{"**/*.test.ts"}})
+	want := []string{".gitignore", "keep.log", "other/b.tmp", "package.json", "src/generated.ts", "src/index.ts", "src/sub/.gitignore"}
+	if got := names(w.snapshot()); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot = %v\nwant %v", got, want)
+	}
+
+	w = newWatcher(filepath.Join(root, "pkg"), Options{Include: []string{"src"}})
+	want = []string{"src/generated.ts", "src/index.test.ts", "src/index.ts", "src/sub/.gitignore"}
+	if got := names(w.snapshot()); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot with include = %v\nwant %v", got, want)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	prev := map[string]fileState{"a": {size: 1}, "b": {size: 1}, "c": {size: 1}}
+	next := map[string]fileState{"a": {size: 1}, "b": {size: 2}, "d": {size: 1}}
+	if got := changed(prev, next); !reflect.DeepEqual(got, []string{"b", "c", "d"}) {
+		t.Errorf("changed = %v", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.ts": "a"})
+
+	ctx, cancel := stdcontext.WithCancel(stdcontext.Background())
+	defer cancel()
+	changes := Watch(ctx, dir, Options{Interval: 10 * time.Millisecond, Debounce: 50 * time.Millisecond})
+	time.Sleep(30 * time.Millisecond)
+
+	// A burst of writes is reported once
+	writeFiles(t, dir, map[string]string{"src/a.ts": "changed"})
+	time.Sleep(20 * time.Millisecond)
+	writeFiles(t, dir, map[string]string{"src/b.ts": "new"})
+
+	select {
+	case got := <-changes:
+		if !reflect.DeepEqual(got, []string{"src/a.ts", "src/b.ts"}) {
+			t.Errorf("changes = %v", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes reported")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
@@ -2,6 +2,7 @@ package workspace
 
 import (
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 
@@ -12,9 +13,54 @@ import (
 
 // Package represents a workspace package
 type Package struct {
+	Name         string
+	Version      string
+	Path         string
+	Dir          string
+	Dependencies []Dependency
+}
+
+// Dependency is a dependency declared in a workspace package's manifest
+type Dependency struct {
 	Name string
-	Path string
-	Dir  string
+	Spec string
+	Type string // dependencies, devDependencies, optionalDependencies or peerDependencies
+}
+
+// DependencyTypes are the manifest fields dependencies are declared in,
+// strongest first: a package declared in several of them is needed at
+// runtime when any production field lists it
+var DependencyTypes = []string{"dependencies", "peerDependencies", "optionalDependencies", "devDependencies"}
+
+// DependencyField returns the dependency map of pkg named by depType
+func DependencyField(pkg *context.PackageJSON, depType string) map[string]string {
+	switch depType {
+	case "dependencies":
+		return pkg.Dependencies
+	case "devDependencies":
+		return pkg.DevDependencies
+	case "optionalDependencies":
+		return pkg.OptionalDependencies
+	case "peerDependencies":
+		return pkg.PeerDependencies
+	default:
+		return nil
+	}
+}
+
+// dependencies lists the declarations of pkg, sorted by field then name
+func dependencies(pkg *context.PackageJSON) []Dependency {
+	var deps []Dependency
+	for _, depType := range DependencyTypes {
+		start := len(deps)
+		for name, spec := range DependencyField(pkg, depType) {
+			deps = append(deps, Dependency{Name: name, Spec: spec, Type: depType})
+		}
+		sort.Slice(deps[start:], func(i, j int) bool {
+			return deps[start+i].Name < deps[start+j].Name
+		})
+	}
+	return deps
 }
 
 // FindPackages finds all packages in a workspace
@@ -57,9 +103,11 @@ func FindPackages(rootDir string) ([]Package, error) {
 			}
 
 			packages = append(packages, Package{
-				Name: pkg.Name,
-				Path: match,
-				Dir:  dir,
+				Name:         pkg.Name,
+				Version:      pkg.Version,
+				Path:         match,
+				Dir:          dir,
+				Dependencies: dependencies(pkg),
 			})
 		}
 	}
@@ -135,3 +183,42 @@ func FindPackageByName(rootDir string, name string) (*Package, error) {
 
 	return nil, os.ErrNotExist
 }
+
+// Importer is a manifest taking part in a workspace: the root package or one
+// of the workspace packages
+type Importer struct {
+	Dir         string // absolute directory
+	RelDir      string // relative to the root with forward slashes, "." for the root
+	PackageJSON *context.PackageJSON
+}
+
+// ManifestPath returns the package.json path relative to the root
+func (i Importer) ManifestPath() string {
+	return path.Join(i.RelDir, "package.json")
+}
+
+// Importers returns the root manifest followed by the workspace packages
+func Importers(ctx *context.ProjectContext) []Importer {
+	importers := []Importer{{Dir: ctx.RootDir, RelDir: ".", PackageJSON: ctx.PackageJSON}}
+
+	packages, err := FindPackages(ctx.RootDir)
+	if err != nil {
+		return importers
+	}
+	for _, wsPkg := range packages {
+		pkg, err := context.ReadPackageJSON(wsPkg.Path)
+		if err != nil {
+			continue
+		}
+		relDir, err := filepath.Rel(ctx.RootDir, wsPkg.Dir)
+		if err != nil || relDir == "." {
+			continue
+		}
+		importers = append(importers, Importer{
+			Dir:         wsPkg.Dir,
+			RelDir:      filepath.ToSlash(relDir),
+			PackageJSON: pkg,
+		})
+	}
+	return importers
+}
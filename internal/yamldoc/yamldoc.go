@@ -0,0 +1,133 @@
+// Package yamldoc edits YAML config files such as pnpm-workspace.yaml and
+// .yarnrc.yml through yaml.Node, so keys gnpm doesn't touch keep their
+// order and comments.
+package yamldoc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Read parses the YAML document at path. A missing or empty file yields an
+// empty mapping document.
+func Read(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newMappingDocument(), nil
+		}
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return newMappingDocument(), nil
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Root returns the top-level mapping of doc, replacing anything else
+func Root(doc *yaml.Node) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 || doc.Content[0] == nil {
+		doc.Content = []*yaml.Node{{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+		}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		root.Kind = yaml.MappingNode
+		root.Tag = "!!map"
+		root.Value = ""
+		root.Content = nil
+	}
+	if root.Tag == "" {
+		root.Tag = "!!map"
+	}
+	return root
+}
+
+// Write marshals doc to path, creating the parent directory if needed
+func Write(path string, doc *yaml.Node) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Get returns the value of key in a mapping node, or nil
+func Get(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Kind == yaml.ScalarNode && mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// Set replaces the value of key in a mapping node, appending the key when
+// it's missing
+func Set(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Kind == yaml.ScalarNode && mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content, String(key), value)
+}
+
+// Delete removes key from a mapping node and reports whether it was there
+func Delete(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Kind == yaml.ScalarNode && mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// String returns a string scalar node
+func String(value string) *yaml.Node {
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Tag:   "!!str",
+		Value: value,
+	}
+}
+
+// Map returns an empty mapping node in flow style, written as {}
+func Map() *yaml.Node {
+	return &yaml.Node{
+		Kind:  yaml.MappingNode,
+		Tag:   "!!map",
+		Style: yaml.FlowStyle,
+	}
+}
+
+func newMappingDocument() *yaml.Node {
+	return &yaml.Node{
+		Kind: yaml.DocumentNode,
+		Content: []*yaml.Node{{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+		}},
+	}
+}